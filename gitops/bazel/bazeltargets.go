@@ -11,14 +11,39 @@ governing permissions and limitations under the License.
 */
 package bazel
 
-import "strings"
+import (
+	"path"
+	"strings"
+)
 
-// TargetToExecutable converts bazel target name to respective executable name in bazel-bin
+// TargetToExecutable converts bazel target name to respective executable name in bazel-bin.
+// The executables of the external repositories targets, like @repo//pkg:name or bzlmod canonical @@repo~1.0//pkg:name,
+// are in bazel-bin/external/<repo>. The name is returned unchanged if it is not a label.
 func TargetToExecutable(target string) string {
-	if !strings.HasPrefix(target, "//") {
+	repo, rest := "", target
+	if strings.HasPrefix(rest, "@") {
+		rest = strings.TrimPrefix(strings.TrimPrefix(rest, "@"), "@")
+		var found bool
+		if repo, rest, found = strings.Cut(rest, "//"); !found {
+			// @repo is a shorthand for @repo//:repo
+			rest = ":" + repo
+		}
+		rest = "//" + rest
+	}
+	if !strings.HasPrefix(rest, "//") || strings.ContainsAny(repo, "/:") {
 		return target
 	}
-	target = "bazel-bin/" + target[2:]
-	target = strings.Replace(target, ":", "/", 1)
-	return target
+	pkg, name, found := strings.Cut(rest[2:], ":")
+	if !found {
+		// //pkg is a shorthand for //pkg:<last package component>
+		name = path.Base(pkg)
+	}
+	if name == "" || name == "." || name == "/" {
+		return target
+	}
+	dir := "bazel-bin"
+	if repo != "" {
+		dir = path.Join(dir, "external", repo)
+	}
+	return path.Join(dir, pkg, name)
 }
@@ -19,3 +19,24 @@ func TestTargetToExecutableHappypath(t *testing.T) {
 		t.Error("unexpected result", s)
 	}
 }
+
+func TestTargetToExecutable(t *testing.T) {
+	for target, expected := range map[string]string{
+		"//app:deploy.gitops":                "bazel-bin/app/deploy.gitops",
+		"//:deploy":                          "bazel-bin/deploy",
+		"//app/prod":                         "bazel-bin/app/prod/prod",
+		"@//app:deploy.gitops":               "bazel-bin/app/deploy.gitops",
+		"@@//app:deploy.gitops":              "bazel-bin/app/deploy.gitops",
+		"@other_repo//pkg:target":            "bazel-bin/external/other_repo/pkg/target",
+		"@@repo~1.0//pkg:target":             "bazel-bin/external/repo~1.0/pkg/target",
+		"@@rules_foo~~ext~repo//pkg/sub:tgt": "bazel-bin/external/rules_foo~~ext~repo/pkg/sub/tgt",
+		"@repo//:target":                     "bazel-bin/external/repo/target",
+		"@repo":                              "bazel-bin/external/repo/repo",
+		"bazel-bin/app/deploy.gitops":        "bazel-bin/app/deploy.gitops",
+		"//":                                 "//",
+	} {
+		if s := TargetToExecutable(target); s != expected {
+			t.Errorf("TargetToExecutable(%q) = %q, expected %q", target, s, expected)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package bazel
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/adobe/rules_gitops/gitops/exec"
+)
+
+// executableExpr prints the label and the executable path of a target with cquery --output=starlark
+const executableExpr = `str(target.label) + " " + (target.files_to_run.executable.path if target.files_to_run.executable else "")`
+
+// Executables resolves the executables of the targets with bazel cquery, like the executables of
+// the bzlmod external repositories targets. Returns the paths relative to the workspace root,
+// like bazel-out/k8-fastbuild/bin/pkg/name, by the label of the target normalized with MainLabel.
+// The targets without an executable are omitted.
+func Executables(ctx context.Context, bazelCmd string, targets []string, stderr io.Writer) (map[string]string, error) {
+	executables := make(map[string]string)
+	if len(targets) == 0 {
+		return executables, nil
+	}
+	query := "set('" + strings.Join(targets, "' '") + "')"
+	exec.Logger(ctx).Println("Executing bazel cquery", query)
+	args := []string{"cquery", query, "--output=starlark", "--starlark:expr=" + executableExpr}
+	err := run(ctx, bazelCmd, args, query, stderr, func(r io.Reader) error {
+		sc := bufio.NewScanner(r)
+		for sc.Scan() {
+			label, executable, _ := strings.Cut(strings.TrimSpace(sc.Text()), " ")
+			if label == "" || executable == "" {
+				continue
+			}
+			executables[MainLabel(label)] = executable
+		}
+		if err := sc.Err(); err != nil {
+			return fmt.Errorf("unable to read bazel cquery result: %w", err)
+		}
+		return nil
+	})
+	return executables, err
+}
+
+// MainLabel returns the label of a main repository target without the repository, like //pkg:name for @@//pkg:name.
+// The other labels are returned unchanged.
+func MainLabel(label string) string {
+	for _, prefix := range []string{"@@//", "@//"} {
+		if strings.HasPrefix(label, prefix) {
+			return label[len(prefix)-2:]
+		}
+	}
+	return label
+}
@@ -0,0 +1,292 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package bazel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	oe "os/exec"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/adobe/rules_gitops/gitops/exec"
+)
+
+// Query output formats
+const (
+	// JSONProto is a single JSON document with all the results
+	JSONProto = "jsonproto"
+	// StreamedJSONProto is a JSON document per target, written as the targets are found
+	StreamedJSONProto = "streamed_jsonproto"
+)
+
+// maxErrorStderr is the size of the stderr tail kept in QueryError
+const maxErrorStderr = 16 * 1024
+
+// Querier runs bazel queries
+type Querier interface {
+	Query(ctx context.Context, query string) ([]Target, error)
+}
+
+// QuerierFunc is a function implementing Querier, like a fake in tests
+type QuerierFunc func(ctx context.Context, query string) ([]Target, error)
+
+// Query implements Querier
+func (f QuerierFunc) Query(ctx context.Context, query string) ([]Target, error) {
+	return f(ctx, query)
+}
+
+// Target is a target of the query results. Only the fields used by the gitops tools are decoded
+// from the JSON encoding of the blaze_query.Target protobuf.
+type Target struct {
+	// Type is "RULE", "SOURCE_FILE", "GENERATED_FILE", "PACKAGE_GROUP" or "ENVIRONMENT_GROUP"
+	Type          string `json:"type"`
+	Rule          *Rule  `json:"rule,omitempty"`
+	SourceFile    *File  `json:"sourceFile,omitempty"`
+	GeneratedFile *File  `json:"generatedFile,omitempty"`
+}
+
+// Rule is a rule target
+type Rule struct {
+	Name      string      `json:"name"`
+	RuleClass string      `json:"ruleClass"`
+	Attribute []Attribute `json:"attribute,omitempty"`
+	// RuleInput are the labels of the direct dependencies of the rule
+	RuleInput []string `json:"ruleInput,omitempty"`
+}
+
+// File is a source or generated file target
+type File struct {
+	Name string `json:"name"`
+}
+
+// Attribute is a rule attribute. Only the values of the types used by the gitops rules are decoded.
+type Attribute struct {
+	Name                string   `json:"name"`
+	Type                string   `json:"type"`
+	IntValue            int      `json:"intValue,omitempty"`
+	StringValue         string   `json:"stringValue,omitempty"`
+	BooleanValue        bool     `json:"booleanValue,omitempty"`
+	StringListValue     []string `json:"stringListValue,omitempty"`
+	ExplicitlySpecified bool     `json:"explicitlySpecified,omitempty"`
+}
+
+// NewRule returns a rule target with the string attributes, like for a fake Querier
+func NewRule(name, ruleClass string, attrs map[string]string) Target {
+	r := &Rule{Name: name, RuleClass: ruleClass}
+	for k, v := range attrs {
+		r.Attribute = append(r.Attribute, Attribute{Name: k, Type: "STRING", StringValue: v, ExplicitlySpecified: true})
+	}
+	sort.Slice(r.Attribute, func(i, j int) bool { return r.Attribute[i].Name < r.Attribute[j].Name })
+	return Target{Type: "RULE", Rule: r}
+}
+
+// Name returns the label of the target
+func (t *Target) Name() string {
+	switch {
+	case t.Rule != nil:
+		return t.Rule.Name
+	case t.SourceFile != nil:
+		return t.SourceFile.Name
+	case t.GeneratedFile != nil:
+		return t.GeneratedFile.Name
+	}
+	return ""
+}
+
+// StringAttr returns the value of the string attribute of the rule. ok is false if the rule has no such attribute.
+func (r *Rule) StringAttr(name string) (value string, ok bool) {
+	if r == nil {
+		return "", false
+	}
+	for _, a := range r.Attribute {
+		if a.Name == name {
+			return a.StringValue, true
+		}
+	}
+	return "", false
+}
+
+// QueryError is a failed bazel query
+type QueryError struct {
+	Query string
+	// ExitCode is the bazel exit code, like 3 for the partial results of the query with --keep_going.
+	// -1 if bazel did not exit.
+	ExitCode int
+	// Stderr is the end of the bazel standard error output
+	Stderr string
+	Err    error
+}
+
+func (e *QueryError) Error() string {
+	msg := fmt.Sprintf("bazel query %s failed with exit code %d", e.Query, e.ExitCode)
+	if e.ExitCode == -1 && e.Err != nil {
+		msg = fmt.Sprintf("bazel query %s failed: %v", e.Query, e.Err)
+	}
+	if s := strings.TrimSpace(e.Stderr); s != "" {
+		msg += "\n" + s
+	}
+	return msg
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// ExecQuerier is a Querier running bazel cquery or query command in the current directory
+type ExecQuerier struct {
+	// BazelCmd is the bazel binary to use
+	BazelCmd string
+	// Command is "cquery" or "query", "cquery" if empty
+	Command string
+	// Output is JSONProto or StreamedJSONProto, JSONProto if empty.
+	// StreamedJSONProto requires a bazel version supporting it for Command.
+	Output string
+	// Args are additional command flags
+	Args []string
+	// Stderr receives bazel standard error output, os.Stderr if nil
+	Stderr io.Writer
+}
+
+// Query implements Querier. The results are decoded as bazel writes them.
+// The results read before a failure are returned along with a *QueryError.
+func (q *ExecQuerier) Query(ctx context.Context, query string) ([]Target, error) {
+	command, output := q.Command, q.Output
+	if command == "" {
+		command = "cquery"
+	}
+	if output == "" {
+		output = JSONProto
+	}
+	if output != JSONProto && output != StreamedJSONProto {
+		return nil, fmt.Errorf("unsupported bazel query output %q", output)
+	}
+	exec.Logger(ctx).Println("Executing bazel", command, query)
+	var targets []Target
+	err := run(ctx, q.BazelCmd, append([]string{command, query, "--output=" + output}, q.Args...), query, q.Stderr, func(r io.Reader) error {
+		var err error
+		targets, err = DecodeResults(r)
+		if err != nil {
+			return fmt.Errorf("unable to parse bazel %s result: %w", command, err)
+		}
+		return nil
+	})
+	return targets, err
+}
+
+// run runs the bazel query command and decodes its standard output as it is written.
+// Returns *QueryError if the command fails, otherwise the decode error.
+func run(ctx context.Context, bazelCmd string, args []string, query string, stderr io.Writer, decode func(io.Reader) error) error {
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+	cmd := oe.CommandContext(ctx, bazelCmd, args...)
+	tail := &tailBuffer{max: maxErrorStderr}
+	cmd.Stderr = io.MultiWriter(stderr, tail)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return &QueryError{Query: query, ExitCode: -1, Err: err}
+	}
+	decodeErr := decode(stdout)
+	if decodeErr != nil {
+		// drain the output so bazel could exit
+		io.Copy(io.Discard, stdout)
+	}
+	if err := cmd.Wait(); err != nil {
+		qe := &QueryError{Query: query, ExitCode: -1, Stderr: tail.String(), Err: err}
+		var exitErr *oe.ExitError
+		if errors.As(err, &exitErr) {
+			qe.ExitCode = exitErr.ExitCode()
+		}
+		return qe
+	}
+	return decodeErr
+}
+
+// message is any of the JSON documents written by bazel: the cquery or query result with jsonproto
+// or the configured target or target with streamed_jsonproto
+type message struct {
+	Target
+	// Results are the cquery configured targets
+	Results []struct {
+		Target Target `json:"target"`
+	} `json:"results"`
+	// Nested is the target of a streamed cquery configured target or the targets of a query result
+	Nested json.RawMessage `json:"target"`
+}
+
+// DecodeResults decodes the targets of the cquery or query jsonproto or streamed_jsonproto output
+func DecodeResults(r io.Reader) ([]Target, error) {
+	dec := json.NewDecoder(r)
+	var targets []Target
+	for {
+		var m message
+		err := dec.Decode(&m)
+		if err == io.EOF {
+			return targets, nil
+		}
+		if err != nil {
+			return targets, err
+		}
+		for _, res := range m.Results {
+			targets = append(targets, res.Target)
+		}
+		switch t := strings.TrimSpace(string(m.Nested)); {
+		case strings.HasPrefix(t, "["):
+			var list []Target
+			if err := json.Unmarshal(m.Nested, &list); err != nil {
+				return targets, err
+			}
+			targets = append(targets, list...)
+		case strings.HasPrefix(t, "{"):
+			var target Target
+			if err := json.Unmarshal(m.Nested, &target); err != nil {
+				return targets, err
+			}
+			targets = append(targets, target)
+		}
+		if m.Type != "" {
+			targets = append(targets, m.Target)
+		}
+	}
+}
+
+// tailBuffer keeps the last max bytes written
+type tailBuffer struct {
+	mu  sync.Mutex
+	max int
+	b   []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.b = append(t.b, p...)
+	if len(t.b) > t.max {
+		t.b = append(t.b[:0], t.b[len(t.b)-t.max:]...)
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.b)
+}
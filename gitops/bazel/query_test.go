@@ -0,0 +1,166 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package bazel
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const gitopsRule = `{"type": "RULE", "rule": {"name": "//app:prod", "ruleClass": "gitops", "attribute": [{"name": "deployment_branch", "type": "STRING", "stringValue": "prod", "explicitlySpecified": true}, {"name": "deps", "type": "LABEL_LIST", "stringListValue": ["//app:image"]}]}}`
+
+const sourceFile = `{"type": "SOURCE_FILE", "sourceFile": {"name": "//app:main.go"}}`
+
+func TestDecodeResults(t *testing.T) {
+	for name, output := range map[string]string{
+		"cquery jsonproto":          `{"results": [{"target": ` + gitopsRule + `, "configuration": {"checksum": "abc"}}, {"target": ` + sourceFile + `}]}`,
+		"cquery streamed_jsonproto": `{"target": ` + gitopsRule + `, "configuration": {"checksum": "abc"}}` + "\n" + `{"target": ` + sourceFile + "}\n",
+		"query jsonproto":           `{"target": [` + gitopsRule + `, ` + sourceFile + `]}`,
+		"query streamed_jsonproto":  gitopsRule + "\n" + sourceFile + "\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			targets, err := DecodeResults(strings.NewReader(output))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(targets) != 2 || targets[0].Name() != "//app:prod" || targets[1].Name() != "//app:main.go" || targets[1].Type != "SOURCE_FILE" {
+				t.Fatalf("unexpected targets: %+v", targets)
+			}
+			if v, ok := targets[0].Rule.StringAttr("deployment_branch"); !ok || v != "prod" {
+				t.Errorf("unexpected deployment_branch %q", v)
+			}
+			if _, ok := targets[1].Rule.StringAttr("deployment_branch"); ok {
+				t.Error("source file should not have attributes")
+			}
+			expected := NewRule("//app:prod", "gitops", map[string]string{"deployment_branch": "prod"})
+			expected.Rule.Attribute = append(expected.Rule.Attribute, Attribute{Name: "deps", Type: "LABEL_LIST", StringListValue: []string{"//app:image"}})
+			if !reflect.DeepEqual(targets[0], expected) {
+				t.Errorf("unexpected rule: %+v", targets[0].Rule)
+			}
+		})
+	}
+	if targets, err := DecodeResults(strings.NewReader("")); err != nil || targets != nil {
+		t.Errorf("unexpected results of empty output: %v %v", targets, err)
+	}
+	if _, err := DecodeResults(strings.NewReader(`{"results": [`)); err == nil {
+		t.Error("truncated output should fail")
+	}
+}
+
+// fakeBazel writes a script printing the output and the stderr and exiting with the code
+func fakeBazel(t *testing.T, output, stderr string, code int) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "out.json"), []byte(output), 0644); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\necho \"$@\" > " + filepath.Join(dir, "args") + "\ncat " + filepath.Join(dir, "out.json") + "\necho '" + stderr + "' >&2\nexit " + string(rune('0'+code)) + "\n"
+	bin := filepath.Join(dir, "bazel")
+	if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return bin
+}
+
+func TestExecQuerier(t *testing.T) {
+	bin := fakeBazel(t, gitopsRule+"\n", "Loading: 1 packages", 0)
+	q := &ExecQuerier{BazelCmd: bin, Output: StreamedJSONProto, Args: []string{"--keep_going"}, Stderr: io.Discard}
+	targets, err := q.Query(context.Background(), "kind(gitops, //...)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 1 || targets[0].Name() != "//app:prod" {
+		t.Errorf("unexpected targets: %+v", targets)
+	}
+	args, err := os.ReadFile(filepath.Join(filepath.Dir(bin), "args"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(args) != "cquery kind(gitops, //...) --output=streamed_jsonproto --keep_going\n" {
+		t.Errorf("unexpected bazel arguments: %q", args)
+	}
+
+	bin = fakeBazel(t, gitopsRule+"\n", "ERROR: no such package missing", 3)
+	q = &ExecQuerier{BazelCmd: bin, Command: "query", Output: StreamedJSONProto, Stderr: io.Discard}
+	targets, err = q.Query(context.Background(), "//missing/...")
+	var qe *QueryError
+	if !errors.As(err, &qe) || qe.ExitCode != 3 || !strings.Contains(qe.Stderr, "no such package missing") || !strings.HasSuffix(err.Error(), "exit code 3\nERROR: no such package missing") || qe.Query != "//missing/..." {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+	if len(targets) != 1 {
+		t.Errorf("partial results expected: %+v", targets)
+	}
+
+	if _, err := (&ExecQuerier{BazelCmd: bin, Output: "proto"}).Query(context.Background(), "//..."); err == nil {
+		t.Error("unsupported output should fail")
+	}
+	_, err = (&ExecQuerier{BazelCmd: filepath.Join(t.TempDir(), "missing")}).Query(context.Background(), "//...")
+	if !errors.As(err, &qe) || qe.ExitCode != -1 {
+		t.Errorf("unexpected error of missing bazel: %v", err)
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	tb := &tailBuffer{max: 4}
+	io.WriteString(tb, "ab")
+	io.WriteString(tb, "cdef")
+	if tb.String() != "cdef" {
+		t.Errorf("unexpected tail %q", tb.String())
+	}
+}
+
+func TestExecutables(t *testing.T) {
+	bin := fakeBazel(t, "@@//app:prod.gitops bazel-out/k8-fastbuild/bin/app/prod.gitops\n@@repo~1.0//pkg:deploy bazel-out/k8-fastbuild/bin/external/repo~1.0/pkg/deploy\n//app:lib \n", "", 0)
+	executables, err := Executables(context.Background(), bin, []string{"//app:prod.gitops", "@@repo~1.0//pkg:deploy", "//app:lib"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"//app:prod.gitops":      "bazel-out/k8-fastbuild/bin/app/prod.gitops",
+		"@@repo~1.0//pkg:deploy": "bazel-out/k8-fastbuild/bin/external/repo~1.0/pkg/deploy",
+	}
+	if !reflect.DeepEqual(executables, expected) {
+		t.Errorf("unexpected executables: %v", executables)
+	}
+	args, err := os.ReadFile(filepath.Join(filepath.Dir(bin), "args"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(args), "cquery set('//app:prod.gitops' '@@repo~1.0//pkg:deploy' '//app:lib') --output=starlark --starlark:expr=str(target.label)") {
+		t.Errorf("unexpected bazel arguments: %q", args)
+	}
+
+	bin = fakeBazel(t, "", "ERROR: no such target", 7)
+	var qe *QueryError
+	if _, err := Executables(context.Background(), bin, []string{"//app:missing"}, io.Discard); !errors.As(err, &qe) || qe.ExitCode != 7 {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMainLabel(t *testing.T) {
+	for label, expected := range map[string]string{
+		"@@//app:prod":         "//app:prod",
+		"@//app:prod":          "//app:prod",
+		"//app:prod":           "//app:prod",
+		"@@repo~1.0//pkg:name": "@@repo~1.0//pkg:name",
+	} {
+		if s := MainLabel(label); s != expected {
+			t.Errorf("MainLabel(%q) = %q, expected %q", label, s, expected)
+		}
+	}
+}
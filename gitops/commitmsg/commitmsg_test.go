@@ -15,6 +15,7 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/adobe/rules_gitops/gitops/commitmsg"
 )
@@ -28,6 +29,60 @@ func TestRoundtrip(t *testing.T) {
 	}
 }
 
+func TestMetadataRoundtrip(t *testing.T) {
+	m := commitmsg.Metadata{
+		Targets:      []string{"target1", "target2"},
+		SourceRepo:   "https://example.com/repo.git",
+		SourceBranch: "master",
+		SourceCommit: "abc123",
+		Images:       map[string][]commitmsg.Image{"target1": {{Target: "//app:image", Digest: "sha256:1234"}}},
+		ToolVersion:  "v1.2.3",
+		GeneratedAt:  time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+		PromotedFrom: &commitmsg.Promotion{Train: "dev", Commit: "def456"},
+		RolledBack:   []string{"c1", "c2"},
+	}
+	msg := "GitOps\n" + m.Generate()
+	if targets := commitmsg.ExtractTargets(msg); !reflect.DeepEqual(targets, m.Targets) {
+		t.Errorf("Unexpected targets after parsing: %v", targets)
+	}
+	parsed, err := commitmsg.Parse(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Version = commitmsg.Version
+	m.GeneratedAt = m.GeneratedAt.Truncate(time.Second)
+	if !reflect.DeepEqual(parsed, &m) {
+		t.Errorf("Unexpected metadata after parsing: %+v", parsed)
+	}
+}
+
+func TestParseLegacy(t *testing.T) {
+	msg := "GitOps for release branch master from main commit abc123\n" + commitmsg.Generate([]string{"target1"})
+	m, err := commitmsg.Parse(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &commitmsg.Metadata{Targets: []string{"target1"}}
+	if !reflect.DeepEqual(m, expected) {
+		t.Errorf("Unexpected metadata after parsing: %+v", m)
+	}
+	if m, err := commitmsg.Parse("manual change"); err != nil || m.Version != 0 || m.Targets != nil {
+		t.Errorf("Unexpected metadata of a message without markers: %+v %v", m, err)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, msg := range []string{
+		"GitOps\n--- gitops metadata begin ---\n{\"version\": 1}\n",
+		"GitOps\n--- gitops metadata begin ---\nversion: 1\n--- gitops metadata end ---\n",
+		"GitOps\n--- gitops metadata begin ---\n{}\n--- gitops metadata end ---\n",
+	} {
+		if _, err := commitmsg.Parse(msg); err == nil {
+			t.Errorf("Parse(%q) should fail", msg)
+		}
+	}
+}
+
 func ExampleGenerate() {
 	targets := []string{"target1", "target2"}
 	msg := commitmsg.Generate(targets)
@@ -38,3 +93,27 @@ func ExampleGenerate() {
 	// target2
 	// --- gitops targets end ---
 }
+
+func ExampleMetadata_Generate() {
+	m := commitmsg.Metadata{
+		Targets:      []string{"target1"},
+		SourceBranch: "master",
+		SourceCommit: "abc123",
+		ToolVersion:  "v1.2.3",
+		GeneratedAt:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	fmt.Println(m.Generate())
+	// Output:
+	// --- gitops targets begin ---
+	// target1
+	// --- gitops targets end ---
+	// --- gitops metadata begin ---
+	// {
+	//   "version": 1,
+	//   "source_branch": "master",
+	//   "source_commit": "abc123",
+	//   "tool_version": "v1.2.3",
+	//   "generated_at": "2024-01-02T03:04:05Z"
+	// }
+	// --- gitops metadata end ---
+}
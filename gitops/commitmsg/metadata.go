@@ -0,0 +1,172 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package commitmsg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// Version is the version of the metadata written by Metadata.Generate.
+// Version 0 is a commit message with the gitops targets markers only.
+const Version = 1
+
+const metadataBegin = "--- gitops metadata begin ---"
+const metadataEnd = "--- gitops metadata end ---"
+
+// modulePath is the module of the gitops tools
+const modulePath = "github.com/adobe/rules_gitops"
+
+// ToolVersion is recorded in the metadata unless set by the caller. It could be set at link time with
+// -X github.com/adobe/rules_gitops/gitops/commitmsg.ToolVersion=..., otherwise the module version or
+// the VCS revision of the binary build info is recorded, or "dev" if the build info has neither.
+var ToolVersion string
+
+// Metadata describes the change a gitops commit is generated from
+type Metadata struct {
+	Version int `json:"version"`
+	// Targets are the gitops targets of the commit. They are written between the gitops targets markers,
+	// which are understood by the older versions.
+	Targets []string `json:"-"`
+	// SourceRepo, SourceBranch and SourceCommit identify the source change
+	SourceRepo   string `json:"source_repo,omitempty"`
+	SourceBranch string `json:"source_branch,omitempty"`
+	SourceCommit string `json:"source_commit,omitempty"`
+	// Images are the images pushed by the push targets of the gitops targets, by gitops target
+	Images      map[string][]Image `json:"images,omitempty"`
+	ToolVersion string             `json:"tool_version,omitempty"`
+	// GeneratedAt is the time the commit was generated
+	GeneratedAt time.Time `json:"generated_at"`
+	// PromotedFrom is set for the promotion commits
+	PromotedFrom *Promotion `json:"promoted_from,omitempty"`
+	// RolledBack are the deployment commits reverted by a rollback commit
+	RolledBack []string `json:"rolled_back,omitempty"`
+}
+
+// Image is the digest of the image pushed by the push target
+type Image struct {
+	Target string `json:"target"`
+	Digest string `json:"digest,omitempty"`
+}
+
+// Promotion is the release train and the commit the manifests were promoted from
+type Promotion struct {
+	Train  string `json:"train"`
+	Commit string `json:"commit"`
+}
+
+// Generate generates the commit message lines of the gitops targets followed by the metadata block.
+// ToolVersion and GeneratedAt default to the package ToolVersion and the current time.
+func (m Metadata) Generate() string {
+	m.Version = Version
+	if m.ToolVersion == "" {
+		m.ToolVersion = toolVersion()
+	}
+	if m.GeneratedAt.IsZero() {
+		m.GeneratedAt = time.Now()
+	}
+	m.GeneratedAt = m.GeneratedAt.UTC().Truncate(time.Second)
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		// the fields are always marshalable
+		panic(err)
+	}
+	return Generate(m.Targets) + metadataBegin + "\n" + string(b) + "\n" + metadataEnd + "\n"
+}
+
+// toolVersion returns ToolVersion or the version of the tools read from the build info
+func toolVersion() string {
+	if ToolVersion != "" {
+		return ToolVersion
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "dev"
+	}
+	return buildVersion(bi)
+}
+
+// buildVersion returns the version of the gitops tools module, which could be a dependency of the main module,
+// or the VCS revision of the main module built from a checkout, or "dev"
+func buildVersion(bi *debug.BuildInfo) string {
+	mod := &bi.Main
+	if mod.Path != modulePath {
+		mod = nil
+		for _, dep := range bi.Deps {
+			if dep.Path == modulePath {
+				mod = dep
+				break
+			}
+		}
+	}
+	if mod != nil && mod.Replace != nil {
+		mod = mod.Replace
+	}
+	if mod != nil && mod.Version != "" && mod.Version != "(devel)" {
+		return mod.Version
+	}
+	if mod != &bi.Main {
+		return "dev"
+	}
+	var revision, modified string
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value
+		}
+	}
+	if revision == "" {
+		return "dev"
+	}
+	if modified == "true" {
+		return revision + "-dirty"
+	}
+	return revision
+}
+
+// Parse parses the gitops targets and the metadata of a commit message.
+// The messages written before the metadata was introduced are parsed as Version 0 with the Targets only.
+// Returns an error if the metadata block is malformed.
+func Parse(msg string) (*Metadata, error) {
+	m := &Metadata{Targets: ExtractTargets(msg)}
+	var block []string
+	inBlock, found := false, false
+	for _, s := range strings.Split(msg, "\n") {
+		switch {
+		case s == metadataBegin && !found:
+			inBlock, found = true, true
+		case s == metadataEnd && inBlock:
+			inBlock = false
+		case inBlock:
+			block = append(block, s)
+		}
+	}
+	if inBlock {
+		return nil, errors.New("unable to find end marker of gitops metadata")
+	}
+	if !found {
+		return m, nil
+	}
+	if err := json.Unmarshal([]byte(strings.Join(block, "\n")), m); err != nil {
+		return nil, fmt.Errorf("unable to parse gitops metadata: %w", err)
+	}
+	if m.Version < 1 {
+		return nil, fmt.Errorf("invalid gitops metadata version %d", m.Version)
+	}
+	return m, nil
+}
@@ -0,0 +1,40 @@
+/*
+Copyright 2020 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package commitmsg
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestBuildVersion(t *testing.T) {
+	revision := []debug.BuildSetting{{Key: "vcs.revision", Value: "abc123"}, {Key: "vcs.modified", Value: "false"}}
+	for _, tc := range []struct {
+		name     string
+		bi       debug.BuildInfo
+		expected string
+	}{
+		{"installed", debug.BuildInfo{Main: debug.Module{Path: modulePath, Version: "v1.2.3"}}, "v1.2.3"},
+		{"checkout", debug.BuildInfo{Main: debug.Module{Path: modulePath, Version: "(devel)"}, Settings: revision}, "abc123"},
+		{"modified checkout", debug.BuildInfo{Main: debug.Module{Path: modulePath, Version: "(devel)"},
+			Settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "abc123"}, {Key: "vcs.modified", Value: "true"}}}, "abc123-dirty"},
+		{"dependency", debug.BuildInfo{Main: debug.Module{Path: "example.com/tool", Version: "(devel)"}, Settings: revision,
+			Deps: []*debug.Module{{Path: modulePath, Version: "v1.2.3"}}}, "v1.2.3"},
+		{"replaced dependency", debug.BuildInfo{Main: debug.Module{Path: "example.com/tool"},
+			Deps: []*debug.Module{{Path: modulePath, Version: "v1.2.3", Replace: &debug.Module{Path: "../rules_gitops"}}}}, "dev"},
+		{"no version", debug.BuildInfo{Main: debug.Module{Path: "example.com/tool"}, Settings: revision}, "dev"},
+	} {
+		if v := buildVersion(&tc.bi); v != tc.expected {
+			t.Errorf("%s: unexpected version %q, expected %q", tc.name, v, tc.expected)
+		}
+	}
+}
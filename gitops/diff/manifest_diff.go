@@ -0,0 +1,87 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+// manifest_diff prints the differences of the Kubernetes objects between two manifests streams.
+// The exit status is 0 if the objects are the same, 1 if they differ and 2 on errors, like diff.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	diff "github.com/adobe/rules_gitops/gitops/diff/pkg"
+	"github.com/adobe/rules_gitops/gitops/git"
+)
+
+var (
+	gitRevisions          = flag.Bool("git", false, "OLD and NEW are git revisions of the repository in git_dir, only the manifests in gitops_path changed between them are compared")
+	gitDir                = flag.String("git_dir", ".", "the git repository location")
+	gitopsPath            = flag.String("gitops_path", "cloud", "the location of the manifests in the git repository")
+	normalizeHashSuffixes = flag.Bool("normalize_hash_suffixes", false, "ignore the kustomize hash suffixes of ConfigMap and Secret names")
+	output                = flag.String("output", "text", "the output format: 'text' or 'json'")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] OLD NEW\n\nOLD and NEW are manifests files, directories or - for the standard input.\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	changes, err := run(flag.Arg(0), flag.Arg(1), os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if len(changes) > 0 {
+		os.Exit(1)
+	}
+}
+
+// run compares the manifests and writes the changes to w in the output format
+func run(oldArg, newArg string, w io.Writer) ([]diff.Change, error) {
+	var old, new diff.Objects
+	var err error
+	if *gitRevisions {
+		old, new, err = diff.LoadRevisions(&git.ExecRepo{Dir: *gitDir}, oldArg, newArg, *gitopsPath)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if old, err = diff.Load(oldArg); err != nil {
+			return nil, err
+		}
+		if new, err = diff.Load(newArg); err != nil {
+			return nil, err
+		}
+	}
+	changes := diff.Diff(old, new, diff.Options{NormalizeHashSuffixes: *normalizeHashSuffixes})
+	switch *output {
+	case "text":
+		return changes, diff.Write(w, changes)
+	case "json":
+		if changes == nil {
+			changes = []diff.Change{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return changes, enc.Encode(changes)
+	default:
+		return nil, fmt.Errorf("unknown output format %q", *output)
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunJSON(t *testing.T) {
+	dir := t.TempDir()
+	old, new := filepath.Join(dir, "old.yaml"), filepath.Join(dir, "new.yaml")
+	if err := os.WriteFile(old, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\ndata:\n  key: old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(new, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\ndata:\n  key: new\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	oldOutput := *output
+	t.Cleanup(func() { *output = oldOutput })
+	*output = "json"
+
+	var b strings.Builder
+	changes, err := run(old, new, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 {
+		t.Errorf("unexpected changes: %+v", changes)
+	}
+	expected := `[
+  {
+    "key": {
+      "kind": "ConfigMap",
+      "name": "a"
+    },
+    "status": "modified",
+    "fields": [
+      {
+        "path": "data.key",
+        "old": "old",
+        "new": "new"
+      }
+    ]
+  }
+]
+`
+	if b.String() != expected {
+		t.Errorf("unexpected output:\n%s", b.String())
+	}
+
+	b.Reset()
+	if changes, err := run(new, new, &b); err != nil || len(changes) != 0 || b.String() != "[]\n" {
+		t.Errorf("unexpected output of the same manifests %q: %v", b.String(), err)
+	}
+
+	*output = "yaml"
+	if _, err := run(old, new, &b); err == nil {
+		t.Error("unknown output format should fail")
+	}
+}
@@ -0,0 +1,424 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+// Package diff compares Kubernetes manifests object by object and field by field,
+// ignoring the order of the objects, of the fields and of the named list items.
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/adobe/rules_gitops/gitops/git"
+)
+
+// Object change statuses
+const (
+	Added    = "added"
+	Deleted  = "deleted"
+	Modified = "modified"
+)
+
+// Key identifies a Kubernetes object
+type Key struct {
+	Group     string `json:"group,omitempty"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// String returns the object key in kind.group namespace/name format
+func (k Key) String() string {
+	kind := k.Kind
+	if k.Group != "" {
+		kind += "." + k.Group
+	}
+	if k.Namespace == "" {
+		return kind + " " + k.Name
+	}
+	return kind + " " + k.Namespace + "/" + k.Name
+}
+
+func (k Key) less(o Key) bool {
+	if k.Group != o.Group {
+		return k.Group < o.Group
+	}
+	if k.Kind != o.Kind {
+		return k.Kind < o.Kind
+	}
+	if k.Namespace != o.Namespace {
+		return k.Namespace < o.Namespace
+	}
+	return k.Name < o.Name
+}
+
+// Objects are the Kubernetes objects of manifests
+type Objects map[Key]map[string]interface{}
+
+// Parse returns the objects of the YAML or JSON manifests stream.
+// Documents without kind or name, like empty documents, are ignored.
+func Parse(r io.Reader) (Objects, error) {
+	objects := make(Objects)
+	return objects, objects.parse(r)
+}
+
+// parse adds the objects of the manifests stream, the objects must be unique
+func (o Objects) parse(r io.Reader) error {
+	decoder := yaml.NewYAMLOrJSONDecoder(r, 1024)
+	for {
+		var obj map[string]interface{}
+		err := decoder.Decode(&obj)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		kind, _ := obj["kind"].(string)
+		metadata, _ := obj["metadata"].(map[string]interface{})
+		name, _ := metadata["name"].(string)
+		if kind == "" || name == "" {
+			continue
+		}
+		apiVersion, _ := obj["apiVersion"].(string)
+		group := ""
+		if i := strings.LastIndex(apiVersion, "/"); i >= 0 {
+			group = apiVersion[:i]
+		}
+		namespace, _ := metadata["namespace"].(string)
+		k := Key{Group: group, Kind: kind, Namespace: namespace, Name: name}
+		if _, ok := o[k]; ok {
+			return fmt.Errorf("duplicate object %s", k)
+		}
+		o[k] = obj
+	}
+}
+
+// IsManifest reports whether the file name has a YAML or JSON extension
+func IsManifest(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// Load returns the objects of the manifests file or of all the manifests in the directory and its subdirectories.
+// The standard input is read if path is "-".
+func Load(path string) (Objects, error) {
+	objects := make(Objects)
+	if path == "-" {
+		return objects, objects.parse(os.Stdin)
+	}
+	err := filepath.Walk(path, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// the explicitly named file is read regardless of its extension
+		if info.IsDir() || name != path && !IsManifest(name) {
+			return nil
+		}
+		return objects.readFile(name)
+	})
+	return objects, err
+}
+
+func (o Objects) readFile(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := o.parse(f); err != nil {
+		return fmt.Errorf("unable to parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// LoadRevisions returns the objects of the manifests in path changed between from and to revisions of the repo.
+// The objects of the unchanged files are not loaded.
+func LoadRevisions(repo git.Repo, from, to, path string) (old, new Objects, err error) {
+	changes, err := repo.Diff(from, to, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	old, new = make(Objects), make(Objects)
+	for _, ch := range changes {
+		if !IsManifest(ch.Path) {
+			continue
+		}
+		if ch.Status != git.FileAdded {
+			if err := old.readRevision(repo, from, ch.Path); err != nil {
+				return nil, nil, err
+			}
+		}
+		if ch.Status != git.FileDeleted {
+			if err := new.readRevision(repo, to, ch.Path); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return old, new, nil
+}
+
+func (o Objects) readRevision(repo git.Repo, revision, path string) error {
+	data, err := repo.ReadFile(revision, path)
+	if err != nil {
+		return err
+	}
+	source := revision + ":" + path
+	if err := o.parse(bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("unable to parse %s: %w", source, err)
+	}
+	return nil
+}
+
+// Options configures Diff
+type Options struct {
+	// NormalizeHashSuffixes removes the kustomize hash suffixes from the names of ConfigMaps and Secrets
+	// and from the references to them, so the changed ConfigMap is reported as modified, not replaced.
+	NormalizeHashSuffixes bool
+}
+
+// Change is an added, deleted or modified object
+type Change struct {
+	Key    Key    `json:"key"`
+	Status string `json:"status"`
+	// Fields are the changes of the modified object
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// FieldChange is a changed field of the object. Old or New is nil if the field is added or removed.
+// Path uses dots for the fields, [i] for the list items and [name=n] for the list items matched by name.
+type FieldChange struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old"`
+	New  interface{} `json:"new"`
+}
+
+// Diff returns the changes of the objects sorted by Key
+func Diff(old, new Objects, opts Options) []Change {
+	if opts.NormalizeHashSuffixes {
+		old, new = normalizeHashSuffixes(old), normalizeHashSuffixes(new)
+	}
+	var changes []Change
+	for k, obj := range new {
+		oldObj, ok := old[k]
+		if !ok {
+			changes = append(changes, Change{Key: k, Status: Added})
+			continue
+		}
+		var fields []FieldChange
+		diffValues("", oldObj, obj, &fields)
+		if len(fields) > 0 {
+			changes = append(changes, Change{Key: k, Status: Modified, Fields: fields})
+		}
+	}
+	for k := range old {
+		if _, ok := new[k]; !ok {
+			changes = append(changes, Change{Key: k, Status: Deleted})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key.less(changes[j].Key) })
+	return changes
+}
+
+// diffValues appends the changes between old and new values at path.
+// Maps are compared by key, lists of maps with names by name and other lists by index.
+func diffValues(path string, old, new interface{}, changes *[]FieldChange) {
+	switch o := old.(type) {
+	case map[string]interface{}:
+		if n, ok := new.(map[string]interface{}); ok {
+			keys := make(map[string]bool)
+			for k := range o {
+				keys[k] = true
+			}
+			for k := range n {
+				keys[k] = true
+			}
+			for _, k := range sortedKeys(keys) {
+				diffValues(fieldPath(path, k), o[k], n[k], changes)
+			}
+			return
+		}
+	case []interface{}:
+		if n, ok := new.([]interface{}); ok {
+			oldNamed, newNamed := namedItems(o), namedItems(n)
+			if oldNamed != nil && newNamed != nil {
+				names := make(map[string]bool)
+				for name := range oldNamed {
+					names[name] = true
+				}
+				for name := range newNamed {
+					names[name] = true
+				}
+				for _, name := range sortedKeys(names) {
+					diffValues(fmt.Sprintf("%s[name=%s]", path, name), oldNamed[name], newNamed[name], changes)
+				}
+				return
+			}
+			for i := 0; i < len(o) || i < len(n); i++ {
+				var oi, ni interface{}
+				if i < len(o) {
+					oi = o[i]
+				}
+				if i < len(n) {
+					ni = n[i]
+				}
+				diffValues(fmt.Sprintf("%s[%d]", path, i), oi, ni, changes)
+			}
+			return
+		}
+	}
+	if !reflect.DeepEqual(old, new) {
+		*changes = append(*changes, FieldChange{Path: path, Old: old, New: new})
+	}
+}
+
+// namedItems returns the list items by name if all of them are maps with unique names, nil otherwise
+func namedItems(list []interface{}) map[string]interface{} {
+	items := make(map[string]interface{}, len(list))
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		name, ok := m["name"].(string)
+		if !ok {
+			return nil
+		}
+		if _, dup := items[name]; dup {
+			return nil
+		}
+		items[name] = item
+	}
+	return items
+}
+
+var simpleField = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// fieldPath returns the path of the map field, the keys like annotation names are quoted
+func fieldPath(path, key string) string {
+	if !simpleField.MatchString(key) {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// hashSuffix matches the suffix kustomize appends to the ConfigMap and Secret names, like app-config-5h2k7g8c9d
+var hashSuffix = regexp.MustCompile(`-[2456789bcdfghkmt]{10}$`)
+
+// normalizeHashSuffixes returns the copy of the objects without the hash suffixes of ConfigMap and Secret names.
+// The string values equal to the hashed names are replaced, like the volume and environment references.
+func normalizeHashSuffixes(objects Objects) Objects {
+	renamed := make(map[string]string)
+	for k := range objects {
+		if k.Group == "" && (k.Kind == "ConfigMap" || k.Kind == "Secret") && hashSuffix.MatchString(k.Name) {
+			renamed[k.Name] = hashSuffix.ReplaceAllString(k.Name, "")
+		}
+	}
+	if len(renamed) == 0 {
+		return objects
+	}
+	normalized := make(Objects, len(objects))
+	for k, obj := range objects {
+		if name, ok := renamed[k.Name]; ok && k.Group == "" && (k.Kind == "ConfigMap" || k.Kind == "Secret") {
+			k.Name = name
+		}
+		normalized[k] = replaceStrings(obj, renamed).(map[string]interface{})
+	}
+	return normalized
+}
+
+// replaceStrings returns the copy of the value with the strings replaced
+func replaceStrings(v interface{}, replace map[string]string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, field := range v {
+			m[k] = replaceStrings(field, replace)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, item := range v {
+			l[i] = replaceStrings(item, replace)
+		}
+		return l
+	case string:
+		if r, ok := replace[v]; ok {
+			return r
+		}
+	}
+	return v
+}
+
+// Write writes the changes in the text format:
+//
+//	~ Deployment.apps dev/app
+//	    spec.replicas: 1 -> 2
+//	+ Service dev/app
+//	- ConfigMap dev/app-config
+func Write(w io.Writer, changes []Change) error {
+	for _, ch := range changes {
+		mark := "~"
+		switch ch.Status {
+		case Added:
+			mark = "+"
+		case Deleted:
+			mark = "-"
+		}
+		if _, err := fmt.Fprintf(w, "%s %s\n", mark, ch.Key); err != nil {
+			return err
+		}
+		for _, f := range ch.Fields {
+			if _, err := fmt.Fprintf(w, "    %s: %s -> %s\n", f.Path, formatValue(f.Old), formatValue(f.New)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// formatValue returns the JSON representation of the field value, <none> for missing fields
+func formatValue(v interface{}) string {
+	if v == nil {
+		return "<none>"
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package diff_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	diff "github.com/adobe/rules_gitops/gitops/diff/pkg"
+)
+
+const oldManifests = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: dev
+  annotations:
+    example.com/owner: team
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: sidecar
+        image: proxy:1.0
+      - name: app
+        image: app:v1
+        args: ["--port", "8080"]
+      volumes:
+      - name: config
+        configMap:
+          name: app-config-5h2k7g8c9d
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config-5h2k7g8c9d
+  namespace: dev
+data:
+  key: old
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: removed
+  namespace: dev
+`
+
+// newManifests reorders the objects, the fields and the containers
+const newManifests = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  namespace: dev
+  name: app-config-7t9f6m8k4b
+data:
+  key: new
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+  namespace: dev
+---
+kind: Deployment
+apiVersion: apps/v1
+metadata:
+  namespace: dev
+  name: app
+  annotations:
+    example.com/owner: other-team
+spec:
+  template:
+    spec:
+      volumes:
+      - configMap:
+          name: app-config-7t9f6m8k4b
+        name: config
+      containers:
+      - image: app:v2
+        name: app
+        args: ["--port", "8081"]
+      - name: sidecar
+        image: proxy:1.0
+  replicas: 1
+`
+
+func parse(t *testing.T, manifests string) diff.Objects {
+	t.Helper()
+	objects, err := diff.Parse(strings.NewReader(manifests))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return objects
+}
+
+func TestDiff(t *testing.T) {
+	changes := diff.Diff(parse(t, oldManifests), parse(t, newManifests), diff.Options{})
+	expected := []diff.Change{
+		{Key: diff.Key{Kind: "ConfigMap", Namespace: "dev", Name: "app-config-5h2k7g8c9d"}, Status: diff.Deleted},
+		{Key: diff.Key{Kind: "ConfigMap", Namespace: "dev", Name: "app-config-7t9f6m8k4b"}, Status: diff.Added},
+		{Key: diff.Key{Kind: "ConfigMap", Namespace: "dev", Name: "removed"}, Status: diff.Deleted},
+		{Key: diff.Key{Kind: "Service", Namespace: "dev", Name: "app"}, Status: diff.Added},
+		{Key: diff.Key{Group: "apps", Kind: "Deployment", Namespace: "dev", Name: "app"}, Status: diff.Modified, Fields: []diff.FieldChange{
+			{Path: `metadata.annotations["example.com/owner"]`, Old: "team", New: "other-team"},
+			{Path: "spec.template.spec.containers[name=app].args[1]", Old: "8080", New: "8081"},
+			{Path: "spec.template.spec.containers[name=app].image", Old: "app:v1", New: "app:v2"},
+			{Path: "spec.template.spec.volumes[name=config].configMap.name", Old: "app-config-5h2k7g8c9d", New: "app-config-7t9f6m8k4b"},
+		}},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("unexpected changes:\n%+v\nexpected:\n%+v", changes, expected)
+	}
+
+	if changes := diff.Diff(parse(t, newManifests), parse(t, newManifests), diff.Options{}); len(changes) != 0 {
+		t.Errorf("unexpected changes of the same manifests: %+v", changes)
+	}
+}
+
+func TestDiffNormalizeHashSuffixes(t *testing.T) {
+	changes := diff.Diff(parse(t, oldManifests), parse(t, newManifests), diff.Options{NormalizeHashSuffixes: true})
+	expected := []diff.Change{
+		{Key: diff.Key{Kind: "ConfigMap", Namespace: "dev", Name: "app-config"}, Status: diff.Modified, Fields: []diff.FieldChange{
+			{Path: "data.key", Old: "old", New: "new"},
+		}},
+		{Key: diff.Key{Kind: "ConfigMap", Namespace: "dev", Name: "removed"}, Status: diff.Deleted},
+		{Key: diff.Key{Kind: "Service", Namespace: "dev", Name: "app"}, Status: diff.Added},
+		{Key: diff.Key{Group: "apps", Kind: "Deployment", Namespace: "dev", Name: "app"}, Status: diff.Modified, Fields: []diff.FieldChange{
+			{Path: `metadata.annotations["example.com/owner"]`, Old: "team", New: "other-team"},
+			{Path: "spec.template.spec.containers[name=app].args[1]", Old: "8080", New: "8081"},
+			{Path: "spec.template.spec.containers[name=app].image", Old: "app:v1", New: "app:v2"},
+		}},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("unexpected changes:\n%+v\nexpected:\n%+v", changes, expected)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	old := parse(t, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\ndata:\n  key: old\n  removed: x\n")
+	new := parse(t, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\ndata:\n  key: new\n---\napiVersion: v1\nkind: Namespace\nmetadata:\n  name: dev\n")
+	var b strings.Builder
+	if err := diff.Write(&b, diff.Diff(old, new, diff.Options{})); err != nil {
+		t.Fatal(err)
+	}
+	expected := `~ ConfigMap a
+    data.key: "old" -> "new"
+    data.removed: "x" -> <none>
++ Namespace dev
+`
+	if b.String() != expected {
+		t.Errorf("unexpected output:\n%s", b.String())
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"app.yaml":        "apiVersion: v1\nkind: Service\nmetadata:\n  name: app\n",
+		"nested/cm.json":  `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "app"}}`,
+		"nested/notes.md": "not a manifest",
+		"empty.yaml":      "---\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	objects, err := diff.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 || objects[diff.Key{Kind: "ConfigMap", Name: "app"}] == nil || objects[diff.Key{Kind: "Service", Name: "app"}] == nil {
+		t.Errorf("unexpected objects: %v", objects)
+	}
+	if objects, err := diff.Load(filepath.Join(dir, "app.yaml")); err != nil || len(objects) != 1 {
+		t.Errorf("unexpected objects of the file: %v, %v", objects, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "nested/dup.yaml"), []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: app\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := diff.Load(dir); err == nil || !strings.Contains(err.Error(), "duplicate object Service app") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -16,59 +16,65 @@ import (
 	"encoding/hex"
 	"errors"
 	"io"
-	"log"
 	"os"
 )
 
-// CalculateDigest calculates the SHA256 digest of a file specified by the given path
-func CalculateDigest(path string) string {
-	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
-		return ""
-	}
-
+// CalculateDigest calculates the SHA256 digest of a file specified by the given path.
+// Returns empty string if the file does not exist.
+func CalculateDigest(path string) (string, error) {
 	fi, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
 	defer fi.Close()
 
 	h := sha256.New()
 	if _, err := io.Copy(h, fi); err != nil {
-		log.Fatal(err)
+		return "", err
 	}
 
-	return hex.EncodeToString(h.Sum(nil))
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
 // GetDigest retrieves the digest of a file from a file with the same name but with a ".digest" extension
-func GetDigest(path string) string {
+func GetDigest(path string) (string, error) {
 	digestPath := path + ".digest"
 
-	if _, err := os.Stat(digestPath); errors.Is(err, os.ErrNotExist) {
-		return ""
-	}
-
 	digest, err := os.ReadFile(digestPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
 
-	return string(digest)
+	return string(digest), nil
 }
 
 // VerifyDigest verifies the integrity of a file by comparing its calculated digest with the stored digest
-func VerifyDigest(path string) bool {
-	return CalculateDigest(path) == GetDigest(path)
+func VerifyDigest(path string) (bool, error) {
+	digest, err := CalculateDigest(path)
+	if err != nil {
+		return false, err
+	}
+	saved, err := GetDigest(path)
+	if err != nil {
+		return false, err
+	}
+	return digest == saved, nil
 }
 
 // SaveDigest calculates the digest of a file at the given path and saves it to a file with the same name but with a ".digest" extension.
-func SaveDigest(path string) {
-	digest := CalculateDigest(path)
+func SaveDigest(path string) error {
+	digest, err := CalculateDigest(path)
+	if err != nil {
+		return err
+	}
 
 	digestPath := path + ".digest"
 
-	err := os.WriteFile(digestPath, []byte(digest), 0666)
-	if err != nil {
-		log.Fatal(err)
-	}
+	return os.WriteFile(digestPath, []byte(digest), 0666)
 }
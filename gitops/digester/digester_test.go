@@ -0,0 +1,54 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package digester
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveAndVerifyDigest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deployment.yaml")
+	if err := os.WriteFile(path, []byte("kind: Deployment\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyDigest(path); err != nil || ok {
+		t.Errorf("VerifyDigest() = %v, %v before digest is saved", ok, err)
+	}
+	if err := SaveDigest(path); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyDigest(path); err != nil || !ok {
+		t.Errorf("VerifyDigest() = %v, %v after digest is saved", ok, err)
+	}
+	if err := os.WriteFile(path, []byte("kind: StatefulSet\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyDigest(path); err != nil || ok {
+		t.Errorf("VerifyDigest() = %v, %v after file is changed", ok, err)
+	}
+}
+
+func TestCalculateDigestMissingFile(t *testing.T) {
+	digest, err := CalculateDigest(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil || digest != "" {
+		t.Errorf("CalculateDigest() = %q, %v", digest, err)
+	}
+}
+
+func TestCalculateDigestError(t *testing.T) {
+	// directory could be opened but not read
+	if _, err := CalculateDigest(t.TempDir()); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -12,29 +12,63 @@ governing permissions and limitations under the License.
 package exec
 
 import (
+	"context"
 	"log"
+	"os"
 	"os/exec"
 	"strings"
 )
 
 // Ex is a shortcut for executing the command in specified dir
 func Ex(dir, name string, arg ...string) (output string, err error) {
-	log.Println("executing:", name, strings.Join(arg, " "))
-	cmd := exec.Command(name, arg...)
-	if dir != "" {
-		cmd.Dir = dir
-	}
-	b, err := cmd.CombinedOutput()
-	log.Printf("%s", string(b))
-	return string(b), err
+	return ExContext(context.Background(), dir, name, arg...)
 }
 
 // Mustex executes the command name arg... in directory dir
-// it will exit with fatal error if execution was not successful
+// it will exit with fatal error if execution was not successful.
+//
+// Deprecated: use Ex and handle the error.
 func Mustex(dir, name string, arg ...string) {
 	_, err := Ex(dir, name, arg...)
 	if err != nil {
 		log.Fatalf("ERROR: %s", err)
 	}
+}
+
+// ExContext is like Ex but the command is killed if the context is done before the command completes.
+// The command and its output are logged to the logger of the context.
+func ExContext(ctx context.Context, dir, name string, arg ...string) (output string, err error) {
+	return ExEnv(ctx, dir, nil, name, arg...)
+}
+
+// ExEnv is like ExContext but the command environment is extended with env "key=value" pairs.
+// The environment is not logged.
+func ExEnv(ctx context.Context, dir string, env []string, name string, arg ...string) (output string, err error) {
+	logger := Logger(ctx)
+	logger.Println("executing:", name, strings.Join(arg, " "))
+	cmd := exec.CommandContext(ctx, name, arg...)
+	if dir != "" {
+		cmd.Dir = dir
+	}
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	b, err := cmd.CombinedOutput()
+	logger.Printf("%s", string(b))
+	return string(b), err
+}
+
+type loggerKey struct{}
 
+// WithLogger returns a copy of ctx carrying the logger used by ExContext
+func WithLogger(ctx context.Context, logger *log.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// Logger returns the logger set by WithLogger or the standard logger
+func Logger(ctx context.Context) *log.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*log.Logger); ok {
+		return logger
+	}
+	return log.Default()
 }
@@ -0,0 +1,369 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+// Package azure implements git.Server for Azure DevOps Repos.
+package azure
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/adobe/rules_gitops/gitops/git"
+)
+
+var (
+	azureHost          = flag.String("azure_host", "https://dev.azure.com", "the Azure DevOps Services or Azure DevOps Server URL")
+	organization       = flag.String("azure_organization", "", "the organization (or collection for Azure DevOps Server) to use for azure api requests")
+	project            = flag.String("azure_project", "", "the project to use for azure api requests")
+	repo               = flag.String("azure_repo", "", "the repository to use for azure api requests")
+	accessToken        = flag.String("azure_access_token", os.Getenv("AZURE_DEVOPS_EXT_PAT"), "the personal access token to authenticate requests")
+	mergeStrategy      = flag.String("azure_merge_strategy", "", "the merge strategy of the pull requests auto-completed with the default merge method: 'noFastForward', 'squash', 'rebase' or 'rebaseMerge'. Repository default if empty")
+	deleteSourceBranch = flag.Bool("azure_delete_source_branch", false, "delete the deployment branch when the auto-completed pull request is merged")
+)
+
+func init() {
+	// the deprecated alias of --auto_merge, it is read by create_gitops_prs
+	flag.Bool("azure_auto_complete", false, "deprecated, use --auto_merge: set the pull requests to complete automatically once all policies pass")
+}
+
+const apiVersion = "7.0"
+
+// maxDescription is the maximum length of the pull request description accepted by the api
+const maxDescription = 4000
+
+var guid = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+type identityRef struct {
+	ID string `json:"id"`
+}
+
+type repository struct {
+	WebURL string `json:"webUrl"`
+}
+
+type pullrequest struct {
+	PullRequestID int         `json:"pullRequestId"`
+	Title         string      `json:"title"`
+	SourceRefName string      `json:"sourceRefName"`
+	TargetRefName string      `json:"targetRefName"`
+	CreatedBy     identityRef `json:"createdBy"`
+	Repository    repository  `json:"repository"`
+}
+
+type pullrequestList struct {
+	Value []pullrequest `json:"value"`
+}
+
+type completionOptions struct {
+	MergeStrategy      string `json:"mergeStrategy,omitempty"`
+	DeleteSourceBranch bool   `json:"deleteSourceBranch"`
+}
+
+type apiError struct {
+	Message string `json:"message"`
+}
+
+// statusError is returned for unsuccessful api responses
+type statusError struct {
+	StatusCode int
+	Status     string
+	Message    string
+}
+
+func (e *statusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("azure response %s", e.Status)
+	}
+	return fmt.Sprintf("azure response %s: %s", e.Status, e.Message)
+}
+
+func (p *pullrequest) toPullRequest(created bool) *git.PullRequest {
+	pr := &git.PullRequest{
+		Number:  p.PullRequestID,
+		Created: created,
+	}
+	if p.Repository.WebURL != "" {
+		pr.URL = fmt.Sprintf("%s/pullrequest/%d", p.Repository.WebURL, p.PullRequestID)
+	}
+	return pr
+}
+
+// Server implements git.Server using the Azure DevOps API of the azure_organization/azure_project/azure_repo repository
+type Server struct{}
+
+var _ git.Server = Server{}
+
+// CreatePR creates a pull request using branch names from and to. The active pull request is reused if it already exists.
+func (Server) CreatePR(from, to, title, body string) (*git.PullRequest, error) {
+	existing, err := findPR(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find existing PR: %w", err)
+	}
+	var pr *git.PullRequest
+	if existing != nil {
+		log.Println("Reusing existing PR")
+		pr = existing.toPullRequest(false)
+	} else {
+		req := map[string]string{
+			"sourceRefName": "refs/heads/" + from,
+			"targetRefName": "refs/heads/" + to,
+			"title":         title,
+			"description":   truncate(body),
+		}
+		var created pullrequest
+		if err := request("POST", "/pullrequests", req, &created); err != nil {
+			return nil, fmt.Errorf("unable to create PR: %w", err)
+		}
+		pr = created.toPullRequest(true)
+		log.Println("Created PR: ", pr.URL)
+	}
+	return pr, nil
+}
+
+// EnableAutoMerge sets the pull request to complete automatically once all policies pass.
+// azure_merge_strategy applies to the default merge method.
+func (Server) EnableAutoMerge(number int, method string) error {
+	if err := git.ValidMergeMethod(method); err != nil {
+		return err
+	}
+	strategy := *mergeStrategy
+	switch method {
+	case git.MergeMethodMerge:
+		strategy = "noFastForward"
+	case git.MergeMethodSquash:
+		strategy = "squash"
+	case git.MergeMethodRebase:
+		strategy = "rebase"
+	}
+	var pr pullrequest
+	if err := request("GET", fmt.Sprintf("/pullrequests/%d", number), nil, &pr); err != nil {
+		return fmt.Errorf("unable to get PR %d: %w", number, err)
+	}
+	return setAutoComplete(&pr, strategy)
+}
+
+// setAutoComplete sets the pull request to complete automatically on behalf of its creator
+func setAutoComplete(pr *pullrequest, strategy string) error {
+	req := struct {
+		AutoCompleteSetBy identityRef       `json:"autoCompleteSetBy"`
+		CompletionOptions completionOptions `json:"completionOptions"`
+	}{
+		AutoCompleteSetBy: pr.CreatedBy,
+		CompletionOptions: completionOptions{
+			MergeStrategy:      strategy,
+			DeleteSourceBranch: *deleteSourceBranch,
+		},
+	}
+	if err := request("PATCH", fmt.Sprintf("/pullrequests/%d", pr.PullRequestID), &req, nil); err != nil {
+		return fmt.Errorf("unable to set auto-complete of PR %d: %w", pr.PullRequestID, err)
+	}
+	return nil
+}
+
+// FindPR returns the active pull request from the source branch. Returns nil if there is none.
+func (Server) FindPR(from string) (*git.PullRequest, error) {
+	pr, err := findPR(from, "")
+	if err != nil {
+		return nil, fmt.Errorf("unable to find PR from %s: %w", from, err)
+	}
+	if pr == nil {
+		return nil, nil
+	}
+	return pr.toPullRequest(false), nil
+}
+
+// findPR looks up the active pull request from source branch into target branch. Any target branch matches if empty.
+func findPR(from, to string) (*pullrequest, error) {
+	q := url.Values{}
+	q.Set("searchCriteria.status", "active")
+	q.Set("searchCriteria.sourceRefName", "refs/heads/"+from)
+	if to != "" {
+		q.Set("searchCriteria.targetRefName", "refs/heads/"+to)
+	}
+	var prs pullrequestList
+	if err := request("GET", "/pullrequests?"+q.Encode(), nil, &prs); err != nil {
+		return nil, err
+	}
+	if len(prs.Value) == 0 {
+		return nil, nil
+	}
+	return &prs.Value[0], nil
+}
+
+// UpdatePR replaces the title and the description of the pull request
+func (Server) UpdatePR(number int, title, body string) error {
+	return updatePR(number, map[string]interface{}{"title": title, "description": truncate(body)})
+}
+
+// SetDraft marks the pull request as a draft or as ready for review
+func (Server) SetDraft(number int, draft bool) error {
+	return updatePR(number, map[string]interface{}{"isDraft": draft})
+}
+
+// ClosePR abandons the pull request
+func (Server) ClosePR(number int) error {
+	return updatePR(number, map[string]interface{}{"status": "abandoned"})
+}
+
+func updatePR(number int, update map[string]interface{}) error {
+	if err := request("PATCH", fmt.Sprintf("/pullrequests/%d", number), update, nil); err != nil {
+		return fmt.Errorf("unable to update PR %d: %w", number, err)
+	}
+	return nil
+}
+
+// AddLabels adds the labels (tags) to the pull request
+func (Server) AddLabels(number int, labels []string) error {
+	for _, l := range labels {
+		req := map[string]string{"name": l}
+		if err := request("POST", fmt.Sprintf("/pullrequests/%d/labels", number), req, nil); err != nil {
+			return fmt.Errorf("unable to add label %s to PR %d: %w", l, number, err)
+		}
+	}
+	return nil
+}
+
+// RequestReviewers adds the reviewers to the pull request.
+// Reviewers are identity ids or names (like email) resolved with the identities api.
+func (Server) RequestReviewers(number int, reviewers []string) error {
+	for _, r := range reviewers {
+		id := r
+		if !guid.MatchString(r) {
+			var err error
+			if id, err = identityID(r); err != nil {
+				return err
+			}
+		}
+		req := map[string]int{"vote": 0}
+		if err := request("PUT", fmt.Sprintf("/pullrequests/%d/reviewers/%s", number, url.PathEscape(id)), req, nil); err != nil {
+			return fmt.Errorf("unable to add reviewer %s to PR %d: %w", r, number, err)
+		}
+	}
+	return nil
+}
+
+// identityID resolves the identity name to the id
+func identityID(name string) (string, error) {
+	// Azure DevOps Services serves identities from a separate host
+	host := strings.TrimSuffix(*azureHost, "/")
+	if host == "https://dev.azure.com" {
+		host = "https://vssps.dev.azure.com"
+	}
+	q := url.Values{}
+	q.Set("searchFilter", "General")
+	q.Set("filterValue", name)
+	q.Set("api-version", apiVersion)
+	var identities struct {
+		Value []identityRef `json:"value"`
+	}
+	endpoint := fmt.Sprintf("%s/%s/_apis/identities?%s", host, url.PathEscape(*organization), q.Encode())
+	if err := do("GET", endpoint, nil, &identities); err != nil {
+		return "", fmt.Errorf("unable to find identity %s: %w", name, err)
+	}
+	if len(identities.Value) == 0 {
+		return "", fmt.Errorf("identity %s not found", name)
+	}
+	return identities.Value[0].ID, nil
+}
+
+// truncate shortens the description to the maximum length accepted by the api
+func truncate(body string) string {
+	if len(body) <= maxDescription {
+		return body
+	}
+	const suffix = "\n..."
+	cut := maxDescription - len(suffix)
+	// do not split multibyte characters
+	for cut > 0 && body[cut]&0xC0 == 0x80 {
+		cut--
+	}
+	return body[:cut] + suffix
+}
+
+// request sends the repository api request, see do
+func request(method, path string, in, out interface{}) error {
+	if *organization == "" {
+		return errors.New("azure_organization must be set")
+	}
+	if *project == "" {
+		return errors.New("azure_project must be set")
+	}
+	if *repo == "" {
+		return errors.New("azure_repo must be set")
+	}
+	endpoint := fmt.Sprintf("%s/%s/%s/_apis/git/repositories/%s%s", strings.TrimSuffix(*azureHost, "/"),
+		url.PathEscape(*organization), url.PathEscape(*project), url.PathEscape(*repo), path)
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return do(method, endpoint+sep+"api-version="+apiVersion, in, out)
+}
+
+// do sends the api request with the json body in and parses the json response into out.
+// Both in and out are optional.
+func do(method, endpoint string, in, out interface{}) error {
+	if *accessToken == "" {
+		return errors.New("azure_access_token must be set")
+	}
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth("", *accessToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	responseBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusNonAuthoritativeInfo {
+		// the sign-in page is returned for invalid access tokens
+		return &statusError{StatusCode: resp.StatusCode, Status: resp.Status, Message: "authentication failed, check azure_access_token"}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e apiError
+		json.Unmarshal(responseBody, &e)
+		return &statusError{StatusCode: resp.StatusCode, Status: resp.Status, Message: e.Message}
+	}
+	if out == nil || len(responseBody) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(responseBody, out); err != nil {
+		return fmt.Errorf("unable to parse azure response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package azure
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/adobe/rules_gitops/gitops/git"
+)
+
+const repoPath = "/org/my%20project/_apis/git/repositories/repo/pullrequests"
+
+// fakeAzure serves pull request 12 from deploy/existing into master and records the other requests
+func fakeAzure(t *testing.T) *[]string {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte(":secret")) {
+			w.WriteHeader(http.StatusNonAuthoritativeInfo)
+			fmt.Fprintln(w, "<html>Sign In</html>")
+			return
+		}
+		if r.URL.Query().Get("api-version") != "7.0" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, `{"message":"api-version is required"}`)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		q := r.URL.Query()
+		switch {
+		case r.Method == "GET" && r.URL.EscapedPath() == repoPath:
+			if q.Get("searchCriteria.status") == "active" && q.Get("searchCriteria.sourceRefName") == "refs/heads/deploy/existing" &&
+				(q.Get("searchCriteria.targetRefName") == "" || q.Get("searchCriteria.targetRefName") == "refs/heads/master") {
+				fmt.Fprintln(w, `{"value":[{"pullRequestId":12,"createdBy":{"id":"creator"},"repository":{"webUrl":"https://dev.azure.com/org/project/_git/repo"}}],"count":1}`)
+			} else {
+				fmt.Fprintln(w, `{"value":[],"count":0}`)
+			}
+		case r.Method == "POST" && r.URL.EscapedPath() == repoPath:
+			requests = append(requests, fmt.Sprintf("POST %s", body))
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintln(w, `{"pullRequestId":13,"createdBy":{"id":"creator"},"repository":{"webUrl":"https://dev.azure.com/org/project/_git/repo"}}`)
+		case r.Method == "GET" && r.URL.Path == "/org/_apis/identities":
+			if q.Get("filterValue") == "alice@example.com" {
+				fmt.Fprintln(w, `{"value":[{"id":"alice-id"}]}`)
+			} else {
+				fmt.Fprintln(w, `{"value":[]}`)
+			}
+		case r.Method == "GET" && r.URL.EscapedPath() == repoPath+"/12":
+			fmt.Fprintln(w, `{"pullRequestId":12,"createdBy":{"id":"creator"},"repository":{"webUrl":"https://dev.azure.com/org/project/_git/repo"}}`)
+		case strings.HasPrefix(r.URL.EscapedPath(), repoPath+"/"):
+			requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, strings.TrimPrefix(r.URL.EscapedPath(), repoPath), body))
+			fmt.Fprintln(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintln(w, `{"message":"not found"}`)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	oldHost, oldOrg, oldProject, oldRepo, oldToken := *azureHost, *organization, *project, *repo, *accessToken
+	oldStrategy, oldDelete := *mergeStrategy, *deleteSourceBranch
+	t.Cleanup(func() {
+		*azureHost, *organization, *project, *repo, *accessToken = oldHost, oldOrg, oldProject, oldRepo, oldToken
+		*mergeStrategy, *deleteSourceBranch = oldStrategy, oldDelete
+	})
+	*azureHost, *organization, *project, *repo, *accessToken = ts.URL, "org", "my project", "repo", "secret"
+	return &requests
+}
+
+func TestCreatePR(t *testing.T) {
+	requests := fakeAzure(t)
+	s := Server{}
+	pr, err := s.CreatePR("deploy/new", "master", "GitOps deployment deploy/new", "body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &git.PullRequest{Number: 13, URL: "https://dev.azure.com/org/project/_git/repo/pullrequest/13", Created: true}
+	if !reflect.DeepEqual(pr, expected) {
+		t.Errorf("Unexpected PR: %+v", pr)
+	}
+	pr, err = s.CreatePR("deploy/existing", "master", "GitOps deployment deploy/existing", "body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = &git.PullRequest{Number: 12, URL: "https://dev.azure.com/org/project/_git/repo/pullrequest/12"}
+	if !reflect.DeepEqual(pr, expected) {
+		t.Errorf("Existing PR should be reused: %+v", pr)
+	}
+	expectedRequests := []string{
+		`POST {"description":"body","sourceRefName":"refs/heads/deploy/new","targetRefName":"refs/heads/master","title":"GitOps deployment deploy/new"}`,
+	}
+	if !reflect.DeepEqual(*requests, expectedRequests) {
+		t.Errorf("Unexpected requests: %q", *requests)
+	}
+}
+
+func TestCreatePRAutoComplete(t *testing.T) {
+	requests := fakeAzure(t)
+	f := flag.Lookup("azure_auto_complete")
+	t.Cleanup(func() { f.Value.Set(f.DefValue) })
+	// the deprecated flag enables --auto_merge, CreatePR does not set the auto-complete
+	if err := f.Value.Set("true"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (Server{}).CreatePR("deploy/existing", "master", "title", "body"); err != nil {
+		t.Fatal(err)
+	}
+	if len(*requests) != 0 {
+		t.Errorf("Unexpected requests: %q", *requests)
+	}
+}
+
+func TestEnableAutoMergeDefaultMethod(t *testing.T) {
+	requests := fakeAzure(t)
+	*mergeStrategy, *deleteSourceBranch = "squash", true
+	if err := (Server{}).EnableAutoMerge(12, git.MergeMethodDefault); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`PATCH /12 {"autoCompleteSetBy":{"id":"creator"},"completionOptions":{"mergeStrategy":"squash","deleteSourceBranch":true}}`,
+	}
+	if !reflect.DeepEqual(*requests, expected) {
+		t.Errorf("Unexpected requests: %q", *requests)
+	}
+}
+
+func TestFindPR(t *testing.T) {
+	fakeAzure(t)
+	pr, err := Server{}.FindPR("deploy/existing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr == nil || pr.Number != 12 {
+		t.Errorf("Unexpected PR: %+v", pr)
+	}
+	pr, err = Server{}.FindPR("deploy/missing")
+	if err != nil || pr != nil {
+		t.Errorf("Unexpected PR: %+v %v", pr, err)
+	}
+}
+
+func TestUpdatePR(t *testing.T) {
+	requests := fakeAzure(t)
+	s := Server{}
+	if err := s.UpdatePR(12, "title", strings.Repeat("x", maxDescription+1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddLabels(12, []string{"gitops"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RequestReviewers(12, []string{"alice@example.com", "0d3bfa57-4a0e-4b8e-9d5e-2c7a0b6f1e3a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetDraft(12, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ClosePR(12); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.EnableAutoMerge(12, git.MergeMethodRebase); err != nil {
+		t.Fatal(err)
+	}
+	if len(*requests) != 7 {
+		t.Fatalf("Unexpected requests: %q", *requests)
+	}
+	var update map[string]string
+	if err := json.Unmarshal([]byte(strings.TrimPrefix((*requests)[0], "PATCH /12 ")), &update); err != nil {
+		t.Fatal(err)
+	}
+	if update["title"] != "title" || len(update["description"]) != maxDescription || !strings.HasSuffix(update["description"], "\n...") {
+		t.Errorf("Unexpected update: %q", (*requests)[0])
+	}
+	expected := []string{
+		`POST /12/labels {"name":"gitops"}`,
+		`PUT /12/reviewers/alice-id {"vote":0}`,
+		`PUT /12/reviewers/0d3bfa57-4a0e-4b8e-9d5e-2c7a0b6f1e3a {"vote":0}`,
+		`PATCH /12 {"isDraft":true}`,
+		`PATCH /12 {"status":"abandoned"}`,
+		`PATCH /12 {"autoCompleteSetBy":{"id":"creator"},"completionOptions":{"mergeStrategy":"rebase","deleteSourceBranch":false}}`,
+	}
+	if !reflect.DeepEqual((*requests)[1:], expected) {
+		t.Errorf("Unexpected requests: %q", (*requests)[1:])
+	}
+	if err := s.RequestReviewers(12, []string{"bob@example.com"}); err == nil || err.Error() != "identity bob@example.com not found" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestAuthenticationFailure(t *testing.T) {
+	fakeAzure(t)
+	*accessToken = "expired"
+	_, err := Server{}.CreatePR("deploy/new", "master", "title", "body")
+	if err == nil || !strings.Contains(err.Error(), "authentication failed") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -14,20 +14,35 @@ package bitbucket
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/adobe/rules_gitops/gitops/git"
 )
 
 var (
-	apiEndpoint       = flag.String("bitbucket_api_pr_endpoint", "https://bitbucket.tubemogul.info/rest/api/1.0/projects/TM/repos/repo/pull-requests", "bitbucket pull request api endpoint with project and repo")
+	apiEndpoint       = flag.String("bitbucket_api_pr_endpoint", "", "bitbucket pull request api endpoint, like https://bitbucket.example.com/rest/api/1.0/projects/PROJ/repos/repo/pull-requests")
+	projectKey        = flag.String("bitbucket_project", "", "bitbucket project key of the repository. Derived from bitbucket_api_pr_endpoint if empty")
+	repoSlug          = flag.String("bitbucket_repo", "", "bitbucket repository slug. Derived from bitbucket_api_pr_endpoint if empty")
 	bitbucketUser     = flag.String("bitbucket_user", os.Getenv("BITBUCKET_USER"), "bitbucket api user")
 	bitbucketPassword = flag.String("bitbucket_password", os.Getenv("BITBUCKET_PASSWORD"), "bitbucket api user password")
+	bitbucketToken    = flag.String("bitbucket_token", os.Getenv("BITBUCKET_TOKEN"), "bitbucket HTTP access token. Used instead of bitbucket_user and bitbucket_password if set")
+	defaultReviewers  = flag.Bool("bitbucket_default_reviewers", false, "add the default reviewers of the repository to the created pull requests")
 )
 
+// endpointRepository matches the project key and the repository slug of the pull request api endpoint
+var endpointRepository = regexp.MustCompile(`/projects/([^/]+)/repos/([^/]+)/pull-requests/?$`)
+
 type project struct {
 	Key string `json:"key,omitempty"`
 }
@@ -62,11 +77,289 @@ type pullrequest struct {
 	Reviewers   []account            `json:"reviewers,omitempty"`
 }
 
-// CreatePR creates a pull request using branch names from and to
-func CreatePR(from, to, title, body string) error {
+type link struct {
+	Href string `json:"href"`
+}
+
+// pullrequestResponse is the subset of the pull request returned by the api
+type pullrequestResponse struct {
+	ID          int    `json:"id"`
+	Version     int    `json:"version"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Draft       bool   `json:"draft"`
+	Links       struct {
+		Self []link `json:"self"`
+	} `json:"links"`
+}
+
+// pullrequestUpdate is the body of the pull request update request, version must match the current one
+type pullrequestUpdate struct {
+	Version     int    `json:"version"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Draft       *bool  `json:"draft,omitempty"`
+}
+
+type participant struct {
+	User user   `json:"user"`
+	Role string `json:"role"`
+}
+
+type pullrequestsPage struct {
+	Values []pullrequestResponse `json:"values"`
+}
+
+type repositoryResponse struct {
+	ID int `json:"id"`
+}
+
+type errorsResponse struct {
+	Errors []struct {
+		Message             string               `json:"message"`
+		ExistingPullRequest *pullrequestResponse `json:"existingPullRequest"`
+	} `json:"errors"`
+}
+
+func (p *pullrequestResponse) toPullRequest(created bool) *git.PullRequest {
+	pr := &git.PullRequest{
+		Number:  p.ID,
+		Created: created,
+	}
+	if len(p.Links.Self) > 0 {
+		pr.URL = p.Links.Self[0].Href
+	}
+	return pr
+}
+
+// Server implements git.Server using the Bitbucket Server pull request api at bitbucket_api_pr_endpoint
+type Server struct{}
+
+var _ git.Server = Server{}
+
+// CreatePR creates a pull request using branch names from and to. The open pull request is reused if it already exists.
+func (Server) CreatePR(from, to, title, body string) (*git.PullRequest, error) {
+	return CreatePR(from, to, title, body)
+}
+
+// FindPR returns the open pull request from the head branch. Returns nil if there is none.
+func (Server) FindPR(from string) (*git.PullRequest, error) {
+	q := url.Values{}
+	q.Set("direction", "OUTGOING")
+	q.Set("state", "OPEN")
+	q.Set("at", "refs/heads/"+from)
+	var page pullrequestsPage
+	if err := request("GET", *apiEndpoint+"?"+q.Encode(), nil, &page); err != nil {
+		return nil, fmt.Errorf("unable to find PR from %s: %w", from, err)
+	}
+	if len(page.Values) == 0 {
+		return nil, nil
+	}
+	return page.Values[0].toPullRequest(false), nil
+}
+
+// UpdatePR replaces the title and the description of the pull request
+func (Server) UpdatePR(number int, title, body string) error {
+	pr, err := getPR(number)
+	if err != nil {
+		return err
+	}
+	return updatePR(number, &pullrequestUpdate{Version: pr.Version, Title: title, Description: body})
+}
+
+// AddLabels is not supported, Bitbucket Server pull requests have no labels
+func (Server) AddLabels(number int, labels []string) error {
+	return fmt.Errorf("bitbucket pull request labels: %w", git.ErrNotSupported)
+}
+
+// RequestReviewers adds the users to the reviewers of the pull request
+func (Server) RequestReviewers(number int, reviewers []string) error {
+	for _, name := range reviewers {
+		p := participant{User: user{Name: name}, Role: "REVIEWER"}
+		if err := request("POST", fmt.Sprintf("%s/%d/participants", *apiEndpoint, number), &p, nil); err != nil {
+			return fmt.Errorf("unable to add reviewer %s to PR %d: %w", name, number, err)
+		}
+	}
+	return nil
+}
+
+// SetDraft marks the pull request as a draft or as ready for review. Requires Bitbucket Server 8.18 or later.
+func (Server) SetDraft(number int, draft bool) error {
+	pr, err := getPR(number)
+	if err != nil {
+		return err
+	}
+	if pr.Draft == draft {
+		return nil
+	}
+	return updatePR(number, &pullrequestUpdate{Version: pr.Version, Title: pr.Title, Description: pr.Description, Draft: &draft})
+}
+
+// ClosePR declines the pull request
+func (Server) ClosePR(number int) error {
+	pr, err := getPR(number)
+	if err != nil {
+		return err
+	}
+	if err := request("POST", fmt.Sprintf("%s/%d/decline?version=%d", *apiEndpoint, number, pr.Version), struct{}{}, nil); err != nil {
+		return fmt.Errorf("unable to decline PR %d: %w", number, err)
+	}
+	return nil
+}
+
+// EnableAutoMerge requests the merge of the pull request once the merge checks pass.
+// Requires Bitbucket Data Center 8.15 or later.
+func (Server) EnableAutoMerge(number int, method string) error {
+	if err := git.ValidMergeMethod(method); err != nil {
+		return err
+	}
+	req := struct {
+		StrategyID string `json:"strategyId,omitempty"`
+	}{mergeStrategies[method]}
+	if err := request("POST", fmt.Sprintf("%s/%d/auto-merge", *apiEndpoint, number), &req, nil); err != nil {
+		return fmt.Errorf("unable to enable auto-merge of PR %d: %w", number, err)
+	}
+	return nil
+}
+
+// mergeStrategies are the merge strategy ids of the merge methods
+var mergeStrategies = map[string]string{
+	git.MergeMethodMerge:  "no-ff",
+	git.MergeMethodSquash: "squash",
+	git.MergeMethodRebase: "rebase-no-ff",
+}
+
+// targetRepository returns the repository of the pull requests set by the flags or derived from the endpoint
+func targetRepository() (repository, error) {
 	repo := repository{
-		Slug:    "repo",
-		Project: project{"TM"},
+		Slug:    *repoSlug,
+		Project: project{*projectKey},
+	}
+	if m := endpointRepository.FindStringSubmatch(*apiEndpoint); m != nil {
+		if repo.Project.Key == "" {
+			repo.Project.Key = m[1]
+		}
+		if repo.Slug == "" {
+			repo.Slug = m[2]
+		}
+	}
+	if repo.Project.Key == "" || repo.Slug == "" {
+		return repo, errors.New("bitbucket_project and bitbucket_repo must be set if bitbucket_api_pr_endpoint is not like .../projects/PROJ/repos/repo/pull-requests")
+	}
+	return repo, nil
+}
+
+// getDefaultReviewers returns the default reviewers of the pull request from branch into branch
+func getDefaultReviewers(repo repository, from, to string) ([]account, error) {
+	i := strings.Index(*apiEndpoint, "/rest/")
+	if i < 0 {
+		return nil, fmt.Errorf("unable to find the rest api base of %s", *apiEndpoint)
+	}
+	base := (*apiEndpoint)[:i]
+	repoPath := fmt.Sprintf("projects/%s/repos/%s", url.PathEscape(repo.Project.Key), url.PathEscape(repo.Slug))
+	var r repositoryResponse
+	if err := request("GET", base+"/rest/api/1.0/"+repoPath, nil, &r); err != nil {
+		return nil, fmt.Errorf("unable to get repository %s: %w", repoPath, err)
+	}
+	q := url.Values{}
+	q.Set("sourceRepoId", strconv.Itoa(r.ID))
+	q.Set("targetRepoId", strconv.Itoa(r.ID))
+	q.Set("sourceRefId", "refs/heads/"+from)
+	q.Set("targetRefId", "refs/heads/"+to)
+	var users []user
+	if err := request("GET", base+"/rest/default-reviewers/1.0/"+repoPath+"/reviewers?"+q.Encode(), nil, &users); err != nil {
+		return nil, fmt.Errorf("unable to get default reviewers: %w", err)
+	}
+	reviewers := make([]account, 0, len(users))
+	for _, u := range users {
+		reviewers = append(reviewers, account{User: u})
+	}
+	return reviewers, nil
+}
+
+// setAuth sets the bearer token or the basic authentication of the request
+func setAuth(req *http.Request) {
+	if *bitbucketToken != "" {
+		req.Header.Set("Authorization", "Bearer "+*bitbucketToken)
+		return
+	}
+	req.SetBasicAuth(*bitbucketUser, *bitbucketPassword)
+}
+
+func getPR(number int) (*pullrequestResponse, error) {
+	var pr pullrequestResponse
+	if err := request("GET", fmt.Sprintf("%s/%d", *apiEndpoint, number), nil, &pr); err != nil {
+		return nil, fmt.Errorf("unable to get PR %d: %w", number, err)
+	}
+	return &pr, nil
+}
+
+func updatePR(number int, update *pullrequestUpdate) error {
+	if err := request("PUT", fmt.Sprintf("%s/%d", *apiEndpoint, number), update, nil); err != nil {
+		return fmt.Errorf("unable to update PR %d: %w", number, err)
+	}
+	return nil
+}
+
+// request sends the api request with the json body in and parses the json response into out.
+// Both in and out are optional.
+func request(method, endpoint string, in, out interface{}) error {
+	if *apiEndpoint == "" {
+		return errors.New("bitbucket_api_pr_endpoint must be set")
+	}
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	setAuth(req)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	responseBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var errs errorsResponse
+		if json.Unmarshal(responseBody, &errs) == nil && len(errs.Errors) > 0 {
+			return fmt.Errorf("bitbucket response %s: %s", resp.Status, errs.Errors[0].Message)
+		}
+		return fmt.Errorf("bitbucket response %s", resp.Status)
+	}
+	if out == nil || len(responseBody) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(responseBody, out); err != nil {
+		return fmt.Errorf("unable to parse bitbucket response: %w", err)
+	}
+	return nil
+}
+
+// CreatePR creates a pull request using branch names from and to
+func CreatePR(from, to, title, body string) (*git.PullRequest, error) {
+	if *apiEndpoint == "" {
+		return nil, errors.New("bitbucket_api_pr_endpoint must be set")
+	}
+	repo, err := targetRepository()
+	if err != nil {
+		return nil, err
+	}
+	reviewers := []account{}
+	if *defaultReviewers {
+		if reviewers, err = getDefaultReviewers(repo, from, to); err != nil {
+			return nil, err
+		}
 	}
 	prReq := pullrequest{
 		Title:       title,
@@ -83,21 +376,21 @@ func CreatePR(from, to, title, body string) error {
 			Repository: repo,
 		},
 		Locked:    false,
-		Reviewers: []account{},
+		Reviewers: reviewers,
 	}
-	json, err := json.Marshal(&prReq)
+	reqBody, err := json.Marshal(&prReq)
 	if err != nil {
-		return fmt.Errorf("Unable to marshal CreatePR request: %w", err)
+		return nil, fmt.Errorf("Unable to marshal CreatePR request: %w", err)
 	}
-	req, err := http.NewRequest("POST", *apiEndpoint, bytes.NewBuffer(json))
+	req, err := http.NewRequest("POST", *apiEndpoint, bytes.NewBuffer(reqBody))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json; charset=utf-8")
-	req.SetBasicAuth(*bitbucketUser, *bitbucketPassword)
+	setAuth(req)
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("Unable to send CreatePR request: %w", err)
+		return nil, fmt.Errorf("Unable to send CreatePR request: %w", err)
 	}
 	log.Printf("bitbucket api response: %s", resp.Status)
 	defer resp.Body.Close()
@@ -107,11 +400,24 @@ func CreatePR(from, to, title, body string) error {
 	// 409 already exists
 	if resp.StatusCode == 201 {
 		log.Print("PR was created")
-		return nil
+		var created pullrequestResponse
+		if err := json.Unmarshal(responseBody, &created); err != nil {
+			log.Print("unable to parse bitbucket response: ", err)
+		}
+		return created.toPullRequest(true), nil
 	}
 	if resp.StatusCode == 409 {
 		log.Print("reusing existing PR")
-		return nil
+		var errs errorsResponse
+		if err := json.Unmarshal(responseBody, &errs); err != nil {
+			log.Print("unable to parse bitbucket response: ", err)
+		}
+		for _, e := range errs.Errors {
+			if e.ExistingPullRequest != nil {
+				return e.ExistingPullRequest.toPullRequest(false), nil
+			}
+		}
+		return &git.PullRequest{}, nil
 	}
-	return fmt.Errorf("Unrecognized bitbucket response %d", resp.StatusCode)
+	return nil, fmt.Errorf("Unrecognized bitbucket response %d", resp.StatusCode)
 }
@@ -12,11 +12,16 @@ governing permissions and limitations under the License.
 package bitbucket
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/adobe/rules_gitops/gitops/git"
 )
 
 func TestCreatePRRemote(t *testing.T) {
@@ -25,12 +30,14 @@ func TestCreatePRRemote(t *testing.T) {
 	pass := "*************"
 	bitbucketUser = &user
 	bitbucketPassword = &pass
-	err := CreatePR("deploy/test1", "feature/AP-0000", "test", "hello world")
+	_, err := CreatePR("deploy/test1", "feature/AP-0000", "test", "hello world")
 	if err != nil {
 		t.Error("Unexpected error from server: ", err)
 	}
 }
 
+const prPath = "/rest/api/1.0/projects/TM/repos/repo/pull-requests"
+
 func TestCreatePRNew(t *testing.T) {
 	var buf []byte
 	var srverr error
@@ -42,11 +49,14 @@ func TestCreatePRNew(t *testing.T) {
 	defer ts.Close()
 	oldendpoint := *apiEndpoint
 	defer func() { *apiEndpoint = oldendpoint }()
-	*apiEndpoint = ts.URL
-	err := CreatePR("deploy/test1", "feature/AP-0000", "test", "hello world")
+	*apiEndpoint = ts.URL + prPath
+	pr, err := CreatePR("deploy/test1", "feature/AP-0000", "test", "hello world")
 	if err != nil {
 		t.Error("Unexpected error from server: ", err)
 	}
+	if pr == nil || !pr.Created {
+		t.Error("Unexpected PR: ", pr)
+	}
 	if srverr != nil {
 		t.Error("Unexpected error: ", srverr)
 	}
@@ -55,3 +65,170 @@ func TestCreatePRNew(t *testing.T) {
 		t.Error("Unexpected request body: ", string(buf))
 	}
 }
+
+func TestCreatePRExisting(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(409)
+		fmt.Fprintln(w, `{"errors":[{"message":"Only one pull request may be open for a given source and target branch","existingPullRequest":{"id":42,"links":{"self":[{"href":"https://bitbucket.example.com/projects/TM/repos/repo/pull-requests/42"}]}}}]}`)
+	}))
+	defer ts.Close()
+	oldendpoint := *apiEndpoint
+	defer func() { *apiEndpoint = oldendpoint }()
+	*apiEndpoint = ts.URL + prPath
+	pr, err := CreatePR("deploy/test1", "feature/AP-0000", "test", "hello world")
+	if err != nil {
+		t.Error("Unexpected error from server: ", err)
+	}
+	expected := &git.PullRequest{
+		Number: 42,
+		URL:    "https://bitbucket.example.com/projects/TM/repos/repo/pull-requests/42",
+	}
+	if !reflect.DeepEqual(pr, expected) {
+		t.Errorf("Unexpected PR: %+v", pr)
+	}
+}
+
+// fakeAPI serves pull request 42 and records the other requests
+func fakeAPI(t *testing.T) *[]string {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		switch {
+		case r.Method == "GET" && r.URL.Path == prPath:
+			if r.URL.Query().Get("at") == "refs/heads/deploy/test1" && r.URL.Query().Get("state") == "OPEN" {
+				fmt.Fprintln(w, `{"values":[{"id":42,"version":3,"links":{"self":[{"href":"https://bitbucket.example.com/pull-requests/42"}]}}]}`)
+			} else {
+				fmt.Fprintln(w, `{"values":[]}`)
+			}
+		case r.Method == "GET" && r.URL.Path == prPath+"/42":
+			fmt.Fprintln(w, `{"id":42,"version":3,"title":"old","description":"old body","draft":false}`)
+		case r.URL.Path == prPath+"/43":
+			w.WriteHeader(404)
+			fmt.Fprintln(w, `{"errors":[{"message":"Pull request 43 does not exist"}]}`)
+		default:
+			requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, strings.TrimPrefix(r.URL.RequestURI(), prPath), body))
+		}
+	}))
+	t.Cleanup(ts.Close)
+	oldendpoint := *apiEndpoint
+	t.Cleanup(func() { *apiEndpoint = oldendpoint })
+	*apiEndpoint = ts.URL + prPath
+	return &requests
+}
+
+func TestFindPR(t *testing.T) {
+	fakeAPI(t)
+	pr, err := Server{}.FindPR("deploy/test1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &git.PullRequest{Number: 42, URL: "https://bitbucket.example.com/pull-requests/42"}
+	if !reflect.DeepEqual(pr, expected) {
+		t.Errorf("Unexpected PR: %+v", pr)
+	}
+	pr, err = Server{}.FindPR("deploy/test2")
+	if err != nil || pr != nil {
+		t.Errorf("Unexpected PR: %+v %v", pr, err)
+	}
+}
+
+func TestUpdatePR(t *testing.T) {
+	requests := fakeAPI(t)
+	s := Server{}
+	if err := s.UpdatePR(42, "new", "new body"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RequestReviewers(42, []string{"alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetDraft(42, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetDraft(42, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ClosePR(42); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.EnableAutoMerge(42, git.MergeMethodDefault); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.EnableAutoMerge(42, git.MergeMethodSquash); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`PUT /42 {"version":3,"title":"new","description":"new body"}`,
+		`POST /42/participants {"user":{"name":"alice"},"role":"REVIEWER"}`,
+		`PUT /42 {"version":3,"title":"old","description":"old body","draft":true}`,
+		`POST /42/decline?version=3 {}`,
+		`POST /42/auto-merge {}`,
+		`POST /42/auto-merge {"strategyId":"squash"}`,
+	}
+	if !reflect.DeepEqual(*requests, expected) {
+		t.Errorf("Unexpected requests: %q", *requests)
+	}
+	if err := s.AddLabels(42, []string{"gitops"}); !errors.Is(err, git.ErrNotSupported) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := s.UpdatePR(43, "new", "new body"); err == nil || err.Error() != "unable to get PR 43: bitbucket response 404 Not Found: Pull request 43 does not exist" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestCreatePRRepository(t *testing.T) {
+	var buf []byte
+	var auth string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/rest/api/1.0/projects/OPS/repos/deploy":
+			fmt.Fprintln(w, `{"id":7,"slug":"deploy"}`)
+		case "/rest/default-reviewers/1.0/projects/OPS/repos/deploy/reviewers":
+			q := r.URL.Query()
+			if q.Get("sourceRepoId") != "7" || q.Get("targetRepoId") != "7" || q.Get("sourceRefId") != "refs/heads/deploy/test1" || q.Get("targetRefId") != "refs/heads/master" {
+				t.Errorf("Unexpected default reviewers query: %s", r.URL.RawQuery)
+			}
+			fmt.Fprintln(w, `[{"name":"alice","id":1},{"name":"bob","id":2}]`)
+		default:
+			buf, _ = ioutil.ReadAll(r.Body)
+			w.WriteHeader(201)
+			fmt.Fprintln(w, `{"id":1}`)
+		}
+	}))
+	defer ts.Close()
+	oldendpoint, oldproject, oldrepo, oldtoken, olddefault := *apiEndpoint, *projectKey, *repoSlug, *bitbucketToken, *defaultReviewers
+	defer func() {
+		*apiEndpoint, *projectKey, *repoSlug, *bitbucketToken, *defaultReviewers = oldendpoint, oldproject, oldrepo, oldtoken, olddefault
+	}()
+	// the endpoint does not include the repository
+	*apiEndpoint = ts.URL + "/rest/api/1.0/projects/OPS/repos/deploy/pull-requests/"
+	*projectKey, *repoSlug = "", ""
+	*bitbucketToken = "token"
+	*defaultReviewers = true
+	if _, err := CreatePR("deploy/test1", "master", "test", "hello world"); err != nil {
+		t.Fatal(err)
+	}
+	if auth != "Bearer token" {
+		t.Errorf("Unexpected authorization: %q", auth)
+	}
+	expectedreq := `{"title":"test","description":"hello world","state":"OPEN","open":true,"closed":false,"fromRef":{"id":"refs/heads/deploy/test1","repository":{"slug":"deploy","project":{"key":"OPS"}}},"toRef":{"id":"refs/heads/master","repository":{"slug":"deploy","project":{"key":"OPS"}}},"locked":false,"reviewers":[{"user":{"name":"alice"}},{"user":{"name":"bob"}}]}`
+	if string(buf) != expectedreq {
+		t.Error("Unexpected request body: ", string(buf))
+	}
+
+	// the flags take precedence
+	*projectKey, *repoSlug = "~USER", "fork"
+	*defaultReviewers = false
+	if _, err := CreatePR("deploy/test1", "master", "test", "hello world"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(buf), `"repository":{"slug":"fork","project":{"key":"~USER"}}`) {
+		t.Error("Unexpected request body: ", string(buf))
+	}
+
+	*apiEndpoint = ts.URL + "/pull-requests"
+	*projectKey, *repoSlug = "", ""
+	if _, err := CreatePR("deploy/test1", "master", "test", "hello world"); err == nil {
+		t.Error("Repository should be required")
+	}
+}
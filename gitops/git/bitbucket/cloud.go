@@ -0,0 +1,310 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package bitbucket
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/adobe/rules_gitops/gitops/git"
+)
+
+var (
+	cloudAPIURL           = flag.String("bitbucket_cloud_api_url", "https://api.bitbucket.org/2.0", "bitbucket cloud api url")
+	cloudWorkspace        = flag.String("bitbucket_cloud_workspace", "", "bitbucket cloud workspace of the repository")
+	cloudRepo             = flag.String("bitbucket_cloud_repo", "", "bitbucket cloud repository slug")
+	cloudUser             = flag.String("bitbucket_cloud_user", os.Getenv("BITBUCKET_CLOUD_USER"), "bitbucket cloud user name")
+	cloudAppPassword      = flag.String("bitbucket_cloud_app_password", os.Getenv("BITBUCKET_CLOUD_APP_PASSWORD"), "bitbucket cloud app password of bitbucket_cloud_user")
+	cloudToken            = flag.String("bitbucket_cloud_token", os.Getenv("BITBUCKET_CLOUD_TOKEN"), "bitbucket cloud repository or workspace access token. Used instead of bitbucket_cloud_user and bitbucket_cloud_app_password if set")
+	cloudDefaultReviewers = flag.Bool("bitbucket_cloud_default_reviewers", false, "add the effective default reviewers of the repository to the created pull requests")
+)
+
+type cloudBranch struct {
+	Name string `json:"name"`
+}
+
+type cloudRef struct {
+	Branch cloudBranch `json:"branch"`
+}
+
+// cloudUserRef identifies a user by uuid or by account id
+type cloudUserRef struct {
+	UUID      string `json:"uuid,omitempty"`
+	AccountID string `json:"account_id,omitempty"`
+}
+
+type cloudPullrequest struct {
+	Title             string         `json:"title"`
+	Description       string         `json:"description"`
+	Source            cloudRef       `json:"source"`
+	Destination       cloudRef       `json:"destination"`
+	CloseSourceBranch bool           `json:"close_source_branch"`
+	Reviewers         []cloudUserRef `json:"reviewers"`
+}
+
+// cloudPullrequestResponse is the subset of the pull request returned by the api
+type cloudPullrequestResponse struct {
+	ID        int            `json:"id"`
+	Title     string         `json:"title"`
+	Draft     bool           `json:"draft"`
+	Reviewers []cloudUserRef `json:"reviewers"`
+	Links     struct {
+		HTML link `json:"html"`
+	} `json:"links"`
+}
+
+type cloudPullrequestsPage struct {
+	Values []cloudPullrequestResponse `json:"values"`
+}
+
+type cloudDefaultReviewer struct {
+	User cloudUserRef `json:"user"`
+}
+
+type cloudDefaultReviewersPage struct {
+	Values []cloudDefaultReviewer `json:"values"`
+	Next   string                 `json:"next"`
+}
+
+type cloudErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (p *cloudPullrequestResponse) toPullRequest(created bool) *git.PullRequest {
+	return &git.PullRequest{
+		Number:  p.ID,
+		URL:     p.Links.HTML.Href,
+		Created: created,
+	}
+}
+
+// CloudServer implements git.Server using the Bitbucket Cloud 2.0 api of the bitbucket_cloud_workspace/bitbucket_cloud_repo repository
+type CloudServer struct{}
+
+var _ git.Server = CloudServer{}
+
+// CreatePR creates a pull request using branch names from and to. The open pull request is reused if it already exists.
+func (CloudServer) CreatePR(from, to, title, body string) (*git.PullRequest, error) {
+	existing, err := cloudFindPR(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find PR from %s: %w", from, err)
+	}
+	if existing != nil {
+		log.Println("Reusing existing PR: ", existing.Links.HTML.Href)
+		return existing.toPullRequest(false), nil
+	}
+	reviewers := []cloudUserRef{}
+	if *cloudDefaultReviewers {
+		if reviewers, err = cloudGetDefaultReviewers(); err != nil {
+			return nil, err
+		}
+	}
+	req := cloudPullrequest{
+		Title:       title,
+		Description: body,
+		Source:      cloudRef{cloudBranch{from}},
+		Destination: cloudRef{cloudBranch{to}},
+		Reviewers:   reviewers,
+	}
+	var created cloudPullrequestResponse
+	if err := cloudRequest("POST", "/pullrequests", &req, &created); err != nil {
+		return nil, fmt.Errorf("unable to create PR: %w", err)
+	}
+	log.Println("Created PR: ", created.Links.HTML.Href)
+	return created.toPullRequest(true), nil
+}
+
+// FindPR returns the open pull request from the head branch. Returns nil if there is none.
+func (CloudServer) FindPR(from string) (*git.PullRequest, error) {
+	pr, err := cloudFindPR(from, "")
+	if err != nil {
+		return nil, fmt.Errorf("unable to find PR from %s: %w", from, err)
+	}
+	if pr == nil {
+		return nil, nil
+	}
+	return pr.toPullRequest(false), nil
+}
+
+// cloudFindPR looks up the open pull request from branch into branch. Any destination branch matches if empty.
+func cloudFindPR(from, to string) (*cloudPullrequestResponse, error) {
+	query := fmt.Sprintf("source.branch.name=%q AND state=\"OPEN\"", from)
+	if to != "" {
+		query += fmt.Sprintf(" AND destination.branch.name=%q", to)
+	}
+	var page cloudPullrequestsPage
+	if err := cloudRequest("GET", "/pullrequests?q="+url.QueryEscape(query), nil, &page); err != nil {
+		return nil, err
+	}
+	if len(page.Values) == 0 {
+		return nil, nil
+	}
+	return &page.Values[0], nil
+}
+
+// cloudGetDefaultReviewers returns the effective default reviewers of the repository
+func cloudGetDefaultReviewers() ([]cloudUserRef, error) {
+	var reviewers []cloudUserRef
+	path := "/effective-default-reviewers?pagelen=100"
+	for path != "" {
+		var page cloudDefaultReviewersPage
+		if err := cloudRequest("GET", path, nil, &page); err != nil {
+			return nil, fmt.Errorf("unable to get default reviewers: %w", err)
+		}
+		for _, r := range page.Values {
+			reviewers = append(reviewers, cloudUserRef{UUID: r.User.UUID})
+		}
+		path = page.Next
+	}
+	return reviewers, nil
+}
+
+// UpdatePR replaces the title and the description of the pull request
+func (CloudServer) UpdatePR(number int, title, body string) error {
+	return cloudUpdatePR(number, map[string]interface{}{"title": title, "description": body})
+}
+
+// AddLabels is not supported, Bitbucket Cloud pull requests have no labels
+func (CloudServer) AddLabels(number int, labels []string) error {
+	return fmt.Errorf("bitbucket cloud pull request labels: %w", git.ErrNotSupported)
+}
+
+// RequestReviewers adds the users to the reviewers of the pull request.
+// Reviewers are identified by uuid, like {123e4567-e89b-12d3-a456-426614174000}, or by account id.
+func (CloudServer) RequestReviewers(number int, reviewers []string) error {
+	pr, err := cloudGetPR(number)
+	if err != nil {
+		return err
+	}
+	refs := make([]cloudUserRef, 0, len(pr.Reviewers)+len(reviewers))
+	for _, r := range pr.Reviewers {
+		refs = append(refs, cloudUserRef{UUID: r.UUID})
+	}
+	for _, r := range reviewers {
+		if strings.HasPrefix(r, "{") {
+			refs = append(refs, cloudUserRef{UUID: r})
+		} else {
+			refs = append(refs, cloudUserRef{AccountID: r})
+		}
+	}
+	// the title is required by the update
+	return cloudUpdatePR(number, map[string]interface{}{"title": pr.Title, "reviewers": refs})
+}
+
+// SetDraft marks the pull request as a draft or as ready for review
+func (CloudServer) SetDraft(number int, draft bool) error {
+	pr, err := cloudGetPR(number)
+	if err != nil {
+		return err
+	}
+	if pr.Draft == draft {
+		return nil
+	}
+	return cloudUpdatePR(number, map[string]interface{}{"title": pr.Title, "draft": draft})
+}
+
+// ClosePR declines the pull request
+func (CloudServer) ClosePR(number int) error {
+	if err := cloudRequest("POST", fmt.Sprintf("/pullrequests/%d/decline", number), nil, nil); err != nil {
+		return fmt.Errorf("unable to decline PR %d: %w", number, err)
+	}
+	return nil
+}
+
+// EnableAutoMerge is not supported by the Bitbucket Cloud api
+func (CloudServer) EnableAutoMerge(number int, method string) error {
+	return fmt.Errorf("bitbucket cloud auto-merge: %w", git.ErrNotSupported)
+}
+
+func cloudGetPR(number int) (*cloudPullrequestResponse, error) {
+	var pr cloudPullrequestResponse
+	if err := cloudRequest("GET", fmt.Sprintf("/pullrequests/%d", number), nil, &pr); err != nil {
+		return nil, fmt.Errorf("unable to get PR %d: %w", number, err)
+	}
+	return &pr, nil
+}
+
+func cloudUpdatePR(number int, update map[string]interface{}) error {
+	if err := cloudRequest("PUT", fmt.Sprintf("/pullrequests/%d", number), update, nil); err != nil {
+		return fmt.Errorf("unable to update PR %d: %w", number, err)
+	}
+	return nil
+}
+
+// cloudRequest sends the repository api request with the json body in and parses the json response into out.
+// Both in and out are optional. Absolute urls, like the next page links, are requested as is.
+func cloudRequest(method, path string, in, out interface{}) error {
+	if *cloudWorkspace == "" {
+		return errors.New("bitbucket_cloud_workspace must be set")
+	}
+	if *cloudRepo == "" {
+		return errors.New("bitbucket_cloud_repo must be set")
+	}
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	endpoint := path
+	if !strings.HasPrefix(path, "https://") && !strings.HasPrefix(path, "http://") {
+		endpoint = fmt.Sprintf("%s/repositories/%s/%s%s", strings.TrimSuffix(*cloudAPIURL, "/"), url.PathEscape(*cloudWorkspace), url.PathEscape(*cloudRepo), path)
+	}
+	req, err := http.NewRequest(method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if *cloudToken != "" {
+		req.Header.Set("Authorization", "Bearer "+*cloudToken)
+	} else {
+		req.SetBasicAuth(*cloudUser, *cloudAppPassword)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	responseBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e cloudErrorResponse
+		if json.Unmarshal(responseBody, &e) == nil && e.Error.Message != "" {
+			return fmt.Errorf("bitbucket cloud response %s: %s", resp.Status, e.Error.Message)
+		}
+		return fmt.Errorf("bitbucket cloud response %s", resp.Status)
+	}
+	if out == nil || len(responseBody) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(responseBody, out); err != nil {
+		return fmt.Errorf("unable to parse bitbucket cloud response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package bitbucket
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/adobe/rules_gitops/gitops/git"
+)
+
+const cloudRepoPath = "/repositories/ws/deploy"
+
+// fakeCloud records the requests to the bitbucket cloud api of ws/deploy
+func fakeCloud(t *testing.T, handler http.HandlerFunc) *[]string {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"type":"error","error":{"message":"Token is invalid"}}`)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.RequestURI()[len(cloudRepoPath):], body))
+		handler(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	oldurl, oldws, oldrepo, oldtoken, olddefault := *cloudAPIURL, *cloudWorkspace, *cloudRepo, *cloudToken, *cloudDefaultReviewers
+	t.Cleanup(func() {
+		*cloudAPIURL, *cloudWorkspace, *cloudRepo, *cloudToken, *cloudDefaultReviewers = oldurl, oldws, oldrepo, oldtoken, olddefault
+	})
+	*cloudAPIURL = ts.URL + "/"
+	*cloudWorkspace = "ws"
+	*cloudRepo = "deploy"
+	*cloudToken = "token"
+	*cloudDefaultReviewers = false
+	return &requests
+}
+
+func TestCloudCreatePR(t *testing.T) {
+	requests := fakeCloud(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == cloudRepoPath+"/pullrequests":
+			fmt.Fprint(w, `{"values":[]}`)
+		case r.Method == "GET" && r.URL.Path == cloudRepoPath+"/effective-default-reviewers":
+			fmt.Fprint(w, `{"values":[{"user":{"uuid":"{a}","display_name":"Alice"}}]}`)
+		case r.Method == "POST" && r.URL.Path == cloudRepoPath+"/pullrequests":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id":7,"links":{"html":{"href":"https://bitbucket.org/ws/deploy/pull-requests/7"}}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	*cloudDefaultReviewers = true
+	pr, err := CloudServer{}.CreatePR("deploy/test1", "master", "test", "hello world")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &git.PullRequest{Number: 7, URL: "https://bitbucket.org/ws/deploy/pull-requests/7", Created: true}
+	if !reflect.DeepEqual(pr, expected) {
+		t.Errorf("Unexpected PR: %+v", pr)
+	}
+	expectedRequests := []string{
+		`GET /pullrequests?q=source.branch.name%3D%22deploy%2Ftest1%22+AND+state%3D%22OPEN%22+AND+destination.branch.name%3D%22master%22 `,
+		`GET /effective-default-reviewers?pagelen=100 `,
+		`POST /pullrequests {"title":"test","description":"hello world","source":{"branch":{"name":"deploy/test1"}},"destination":{"branch":{"name":"master"}},"close_source_branch":false,"reviewers":[{"uuid":"{a}"}]}`,
+	}
+	if !reflect.DeepEqual(*requests, expectedRequests) {
+		t.Errorf("Unexpected requests:\n%q", *requests)
+	}
+}
+
+func TestCloudCreatePRExisting(t *testing.T) {
+	requests := fakeCloud(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"values":[{"id":3,"links":{"html":{"href":"https://bitbucket.org/ws/deploy/pull-requests/3"}}}]}`)
+	})
+	pr, err := CloudServer{}.CreatePR("deploy/test1", "master", "test", "hello world")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &git.PullRequest{Number: 3, URL: "https://bitbucket.org/ws/deploy/pull-requests/3"}
+	if !reflect.DeepEqual(pr, expected) {
+		t.Errorf("Unexpected PR: %+v", pr)
+	}
+	if len(*requests) != 1 {
+		t.Errorf("Unexpected requests:\n%q", *requests)
+	}
+}
+
+func TestCloudUpdatePR(t *testing.T) {
+	requests := fakeCloud(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, `{"id":3,"title":"old","draft":false,"reviewers":[{"uuid":"{a}","display_name":"Alice"}]}`)
+		}
+	})
+	s := CloudServer{}
+	if err := s.UpdatePR(3, "new", "body"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RequestReviewers(3, []string{"{b}", "557058:c"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetDraft(3, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ClosePR(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddLabels(3, []string{"deploy"}); !errors.Is(err, git.ErrNotSupported) {
+		t.Errorf("Unexpected AddLabels error: %v", err)
+	}
+	if err := s.EnableAutoMerge(3, git.MergeMethodDefault); !errors.Is(err, git.ErrNotSupported) {
+		t.Errorf("Unexpected EnableAutoMerge error: %v", err)
+	}
+	expectedRequests := []string{
+		`PUT /pullrequests/3 {"description":"body","title":"new"}`,
+		`GET /pullrequests/3 `,
+		`PUT /pullrequests/3 {"reviewers":[{"uuid":"{a}"},{"uuid":"{b}"},{"account_id":"557058:c"}],"title":"old"}`,
+		`GET /pullrequests/3 `,
+		`PUT /pullrequests/3 {"draft":true,"title":"old"}`,
+		`POST /pullrequests/3/decline `,
+	}
+	if !reflect.DeepEqual(*requests, expectedRequests) {
+		t.Errorf("Unexpected requests:\n%q", *requests)
+	}
+}
+
+func TestCloudUnauthorized(t *testing.T) {
+	fakeCloud(t, nil)
+	*cloudToken = "invalid"
+	_, err := CloudServer{}.FindPR("deploy/test1")
+	if err == nil || err.Error() != "unable to find PR from deploy/test1: bitbucket cloud response 401 Unauthorized: Token is invalid" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
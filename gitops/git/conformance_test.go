@@ -0,0 +1,77 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package git_test
+
+import (
+	"bytes"
+	"log"
+	oe "os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adobe/rules_gitops/gitops/git"
+	"github.com/adobe/rules_gitops/gitops/git/gittest"
+)
+
+func TestExecRepo(t *testing.T) {
+	if _, err := oe.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	gittest.TestRepo(t, func(repo, dir, mirrorDir, primaryBranch, gitopsPath string) (git.Repo, error) {
+		return git.Clone(repo, dir, mirrorDir, primaryBranch, gitopsPath)
+	})
+}
+
+func TestExecRepoCredentials(t *testing.T) {
+	if _, err := oe.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	gittest.TestCredentials(t, func(repo, dir string, creds git.Credentials) (git.Repo, error) {
+		return git.CloneWithCredentials(repo, dir, "", "master", "cloud", creds)
+	})
+}
+
+func TestExecRepoWorktreeLogger(t *testing.T) {
+	if _, err := oe.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	origin := gittest.NewOrigin(t)
+	dir := t.TempDir()
+	r, err := git.Clone(origin.Dir, filepath.Join(dir, "clone"), "", "master", "cloud")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Clean()
+	var repoLog, trainLog bytes.Buffer
+	r.Logger = log.New(&repoLog, "", 0)
+	wt, err := r.Worktree(filepath.Join(dir, "worktree"), "master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.Clean()
+	wt.(git.LoggerSetter).SetLogger(log.New(&trainLog, "", 0))
+	if _, err := wt.SwitchToBranch("deploy/train", "master"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.GetLastCommitMessage(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(repoLog.String(), "worktree add") {
+		t.Errorf("the repo commands should be logged to the repo logger:\n%s", repoLog.String())
+	}
+	for _, s := range []string{"checkout deploy/train", "log -1"} {
+		if !strings.Contains(trainLog.String(), s) || strings.Contains(repoLog.String(), s) {
+			t.Errorf("%q should be logged to the worktree logger only:\nworktree:\n%s\nrepo:\n%s", s, trainLog.String(), repoLog.String())
+		}
+	}
+}
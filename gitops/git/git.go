@@ -13,13 +13,17 @@ package git
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	oe "os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/adobe/rules_gitops/gitops/exec"
 )
@@ -28,13 +32,193 @@ var (
 	git = "git"
 )
 
-// Clone clones a repository. Pass the full repository name, such as
+// Repo is a clone of a git repository used to prepare deployment branches.
+// Create with Clone, and don't forget to clean it up after.
+type Repo interface {
+	// WorkDir returns the location of the working copy.
+	WorkDir() string
+	// Remote returns the name of the remote that tracks upstream repository.
+	Remote() string
+	// Clean cleans up the repo
+	Clean() error
+	// Fetch branches from the remote repository based on a specified pattern.
+	// The branches will be be added to the list tracked remote branches ready to be pushed.
+	// Tracked branches deleted in the remote repository are pruned.
+	Fetch(pattern string) error
+	// SwitchToBranch switch the repo to specified branch and checkout primaryBranch files over it.
+	// if branch does not exist it will be created
+	SwitchToBranch(branch, primaryBranch string) (new bool, err error)
+	// RecreateBranch discards a branch content and reset it from primaryBranch.
+	RecreateBranch(branch, primaryBranch string) error
+	// DeleteBranch deletes the local branch. The next SwitchToBranch starts from the fetched remote branch.
+	DeleteBranch(branch string) error
+	// GetLastCommitMessage fetches the commit message from the most recent change of the branch
+	GetLastCommitMessage() (string, error)
+	// Commit all changes in gitopsPath to the current branch. returns true if there were any changes
+	Commit(message, gitopsPath string) (bool, error)
+	// RestoreFile restores the specified file in the repository to its original state
+	RestoreFile(fileName string) error
+	// GetChangedFiles returns a list of files that have been changed in the repository
+	GetChangedFiles() ([]string, error)
+	// GetLastCommitFiles returns a list of files changed by the most recent commit of the current branch
+	GetLastCommitFiles() ([]string, error)
+	// IsClean returns true if there is no local changes (nothing to commit)
+	IsClean() (bool, error)
+	// Diff returns the files in path changed between from and to revisions, like a branch or a remote branch.
+	// Renamed files are reported as deleted and added.
+	Diff(from, to, path string) ([]FileChange, error)
+	// ReadFile returns the content of the file in the revision.
+	// The error wraps os.ErrNotExist if the file does not exist in the revision.
+	ReadFile(revision, path string) ([]byte, error)
+	// Log returns up to max most recent commits of the revision changing files in path, newest first.
+	// Only the first parents are followed, so the merged branches are represented by the merge commits.
+	// All commits are returned if max is 0.
+	Log(revision, path string, max int) ([]Commit, error)
+	// RemoteBranches returns the fetched remote branches with names starting with prefix, sorted by name.
+	RemoteBranches(prefix string) ([]RemoteBranch, error)
+	// DeleteRemoteBranch deletes the branch in the remote repository and its remote tracking branch
+	DeleteRemoteBranch(branch string) error
+	// Push pushes all local changes to the remote repository
+	// all changes should be already commited.
+	// The result of every branch is returned along with the error.
+	// The error wraps ErrStaleLease if the only failures are branches rejected by the lease.
+	Push(branches []string, mode PushMode) ([]PushResult, error)
+	// Worktree adds a working copy of the repository in dir with primaryBranch commit checked out.
+	// The worktree shares branches and objects with the repository and is removed by its Clean.
+	// Worktrees could be used concurrently as long as they switch to different branches,
+	// Fetch and Push should be called on the repository only.
+	Worktree(dir, primaryBranch string) (Repo, error)
+}
+
+// LoggerSetter is implemented by the repos logging the commands they run.
+// The worktrees used concurrently get their own logger, so their output is not interleaved.
+type LoggerSetter interface {
+	// SetLogger sets the logger of the commands, the standard logger if nil
+	SetLogger(logger *log.Logger)
+}
+
+// PushMode selects how Push overwrites the remote branches
+type PushMode int
+
+const (
+	// PushForce overwrites the remote branches unconditionally
+	PushForce PushMode = iota
+	// PushForceWithLease overwrites the remote branches only if they still point to the fetched commits.
+	// Branches which were not fetched must not exist in the remote repository.
+	PushForceWithLease
+)
+
+// Branch push statuses
+const (
+	// PushOK is a branch updated in the remote repository
+	PushOK = "ok"
+	// PushUpToDate is a branch which already matches the remote branch
+	PushUpToDate = "up-to-date"
+	// PushRejected is a branch rejected by the lease because the remote branch was updated since the last fetch
+	PushRejected = "rejected"
+	// PushFailed is a branch which could not be pushed for any other reason
+	PushFailed = "failed"
+)
+
+// PushResult is the outcome of a single branch push
+type PushResult struct {
+	Branch string
+	Status string
+	// Reason explains why the branch was rejected or failed
+	Reason string
+}
+
+// File change statuses
+const (
+	FileAdded    = "added"
+	FileDeleted  = "deleted"
+	FileModified = "modified"
+)
+
+// FileChange is a file changed between two revisions
+type FileChange struct {
+	Path   string
+	Status string
+}
+
+// Commit describes a commit returned by Log
+type Commit struct {
+	Hash string
+	// Parents are the hashes of the parent commits, the first parent first
+	Parents []string
+	// Message is the commit message without the trailing newlines
+	Message string
+	// Author is the author name and email, like "Name <email>"
+	Author string
+	// Committed is the committer time
+	Committed time.Time
+}
+
+// RemoteBranch is a branch of the remote repository
+type RemoteBranch struct {
+	Name string
+	// Committed is the committer time of the last commit of the branch
+	Committed time.Time
+}
+
+// ErrStaleLease is returned by Push when the remote branches were updated since the last fetch
+var ErrStaleLease = errors.New("remote branch was updated since the last fetch")
+
+// PushError returns the error for the push results of Repo implementations.
+// ErrStaleLease is wrapped only if there are no other failures.
+func PushError(results []PushResult) error {
+	var rejected, failed []string
+	for _, r := range results {
+		switch r.Status {
+		case PushRejected:
+			rejected = append(rejected, r.Branch)
+		case PushFailed:
+			failed = append(failed, fmt.Sprintf("%s (%s)", r.Branch, r.Reason))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("unable to push %s", strings.Join(failed, ", "))
+	}
+	if len(rejected) > 0 {
+		return fmt.Errorf("unable to push %s: %w", strings.Join(rejected, ", "), ErrStaleLease)
+	}
+	return nil
+}
+
+// CommandError is returned by ExecRepo when the git command fails
+type CommandError struct {
+	// Args are the git command arguments
+	Args []string
+	// Output is the combined output of the command
+	Output string
+	Err    error
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("%s %s: %v", git, strings.Join(e.Args, " "), e.Err)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// Credentials returns the user name and the password for the HTTPS remote repository.
+// It is called before every command accessing the remote, so short-lived tokens could be refreshed.
+type Credentials func() (username, password string, err error)
+
+// Clone clones a repository using git command. Pass the full repository name, such as
 // "https://aleksey.pesternikov@bitbucket.tubemogul.info/scm/tm/repo.git" as the repo.
 // Cloned directory will be clean of local changes with primaryBranch branch checked out.
 // repo: https://aleksey.pesternikov@bitbucket.tubemogul.info/scm/tm/repo.git
 // dir: /tmp/cloudrepo
 // mirrorDir: optional (if not empty) local mirror of the repository
-func Clone(repo, dir, mirrorDir, primaryBranch, gitopsPath string) (*Repo, error) {
+func Clone(repo, dir, mirrorDir, primaryBranch, gitopsPath string) (*ExecRepo, error) {
+	return CloneWithCredentials(repo, dir, mirrorDir, primaryBranch, gitopsPath, nil)
+}
+
+// CloneWithCredentials is like Clone but authenticates to the remote repository with creds.
+// URL credentials and the configured credential helpers are used if creds is nil.
+func CloneWithCredentials(repo, dir, mirrorDir, primaryBranch, gitopsPath string, creds Credentials) (*ExecRepo, error) {
 	if err := os.RemoveAll(dir); err != nil {
 		return nil, fmt.Errorf("Unable to clone repo: %w", err)
 	}
@@ -44,126 +228,455 @@ func Clone(repo, dir, mirrorDir, primaryBranch, gitopsPath string) (*Repo, error
 		args = append(args, "--reference", mirrorDir)
 	}
 	args = append(args, repo, dir)
-	exec.Mustex("", "git", args...)
+	r := &ExecRepo{
+		Dir:         dir,
+		RemoteName:  remoteName,
+		Credentials: creds,
+	}
+	if _, err := r.runRemote("", args...); err != nil {
+		return nil, fmt.Errorf("Unable to clone repo: %w", err)
+	}
 	// Enable sparse-checkout when restricting to a subdir
 	if !isRootPath(gitopsPath) {
-		exec.Mustex(dir, "git", "config", "--local", "core.sparsecheckout", "true")
+		if _, err := r.run(dir, "config", "--local", "core.sparsecheckout", "true"); err != nil {
+			return nil, err
+		}
 		genPath := fmt.Sprintf("%s/\n", gitopsPath)
 		if err := ioutil.WriteFile(filepath.Join(dir, ".git/info/sparse-checkout"), []byte(genPath), 0644); err != nil {
 			return nil, fmt.Errorf("Unable to create .git/info/sparse-checkout: %w", err)
 		}
 	}
-	exec.Mustex(dir, "git", "checkout", primaryBranch)
-	return &Repo{
-		Dir:        dir,
-		RemoteName: remoteName,
-	}, nil
+	if _, err := r.run(dir, "checkout", primaryBranch); err != nil {
+		return nil, err
+	}
+	return r, nil
 }
 
-// Repo is a clone of a git repository. Create with Clone, and don't
-// forget to clean it up after.
-type Repo struct {
+// ExecRepo is a Repo implementation running git command.
+type ExecRepo struct {
 	// Dir is the location of the git repo.
 	Dir string
 	// RemoteName is the name of the remote that tracks upstream repository.
 	RemoteName string
+	// Credentials authenticate fetch and push if set.
+	Credentials Credentials
+	// Logger receives the git commands and their output, the standard logger if nil.
+	// The worktrees start with the logger of the repository.
+	Logger *log.Logger
+
+	// mainDir is the location of the repo of a worktree
+	mainDir string
+}
+
+var _ Repo = &ExecRepo{}
+var _ LoggerSetter = &ExecRepo{}
+
+// SetLogger sets Logger
+func (r *ExecRepo) SetLogger(logger *log.Logger) {
+	r.Logger = logger
+}
+
+// WorkDir returns the location of the git repo
+func (r *ExecRepo) WorkDir() string {
+	return r.Dir
+}
+
+// Remote returns the name of the remote that tracks upstream repository
+func (r *ExecRepo) Remote() string {
+	return r.RemoteName
 }
 
 // Clean cleans up the repo
-func (r *Repo) Clean() error {
+func (r *ExecRepo) Clean() error {
+	if r.mainDir != "" {
+		_, err := r.run(r.mainDir, "worktree", "remove", "--force", r.Dir)
+		return err
+	}
 	return os.RemoveAll(r.Dir)
 }
 
 // Fetch branches from the remote repository based on a specified pattern.
 // The branches will be be added to the list tracked remote branches ready to be pushed.
-func (r *Repo) Fetch(pattern string) {
-	exec.Mustex(r.Dir, "git", "remote", "set-branches", "--add", r.RemoteName, pattern)
-	exec.Mustex(r.Dir, "git", "fetch", "--force", "--filter=blob:none", "--no-tags", r.RemoteName)
+func (r *ExecRepo) Fetch(pattern string) error {
+	if _, err := r.run(r.Dir, "remote", "set-branches", "--add", r.RemoteName, pattern); err != nil {
+		return err
+	}
+	_, err := r.runRemote(r.Dir, "fetch", "--force", "--prune", "--filter=blob:none", "--no-tags", r.RemoteName)
+	return err
 }
 
 // SwitchToBranch switch the repo to specified branch and checkout primaryBranch files over it.
 // if branch does not exist it will be created
-func (r *Repo) SwitchToBranch(branch, primaryBranch string) (new bool) {
-	if _, err := exec.Ex(r.Dir, "git", "checkout", branch); err != nil {
+func (r *ExecRepo) SwitchToBranch(branch, primaryBranch string) (new bool, err error) {
+	// do not write the tracking configuration shared by worktrees, push sets the upstream
+	if _, err := exec.ExContext(r.context(), r.Dir, git, "-c", "branch.autoSetupMerge=false", "checkout", branch); err != nil {
 		// error checking out, create new
-		exec.Mustex(r.Dir, "git", "branch", branch, primaryBranch)
-		exec.Mustex(r.Dir, "git", "checkout", branch)
-		return true
+		if _, err := r.run(r.Dir, "branch", branch, primaryBranch); err != nil {
+			return false, err
+		}
+		if _, err := r.run(r.Dir, "checkout", branch); err != nil {
+			return false, err
+		}
+		return true, nil
 	}
-	return false
+	return false, nil
 }
 
 // RecreateBranch discards a branch content and reset it from primaryBranch.
-func (r *Repo) RecreateBranch(branch, primaryBranch string) {
-	exec.Mustex(r.Dir, "git", "checkout", primaryBranch)
-	exec.Mustex(r.Dir, "git", "branch", "-f", branch, primaryBranch)
-	exec.Mustex(r.Dir, "git", "checkout", branch)
+func (r *ExecRepo) RecreateBranch(branch, primaryBranch string) error {
+	// primaryBranch could be checked out in another worktree
+	_, err := r.run(r.Dir, "checkout", "-B", branch, primaryBranch)
+	return err
 }
 
-// GetLastCommitMessage fetches the commit message from the most recent change of the branch
-func (r *Repo) GetLastCommitMessage() (msg string) {
-	msg, err := exec.Ex(r.Dir, "git", "log", "-1", "--pretty=%B")
-	if err != nil {
-		return ""
+// DeleteBranch deletes the local branch. The next SwitchToBranch starts from the fetched remote branch.
+func (r *ExecRepo) DeleteBranch(branch string) error {
+	if _, err := r.run(r.Dir, "checkout", "--detach"); err != nil {
+		return err
 	}
-	return msg
+	_, err := r.run(r.Dir, "branch", "-D", branch)
+	return err
+}
+
+// GetLastCommitMessage fetches the commit message from the most recent change of the branch
+func (r *ExecRepo) GetLastCommitMessage() (msg string, err error) {
+	return r.run(r.Dir, "log", "-1", "--pretty=%B")
 }
 
 // Commit all changes to the current branch. returns true if there were any changes
-func (r *Repo) Commit(message, gitopsPath string) bool {
+func (r *ExecRepo) Commit(message, gitopsPath string) (bool, error) {
+	addPath := gitopsPath
 	if isRootPath(gitopsPath) {
-		exec.Mustex(r.Dir, "git", "add", ".")
-	} else {
-		exec.Mustex(r.Dir, "git", "add", gitopsPath)
+		addPath = "."
+	}
+	if _, err := r.run(r.Dir, "add", addPath); err != nil {
+		return false, err
+	}
+	clean, err := r.IsClean()
+	if err != nil || clean {
+		return false, err
 	}
-	if r.IsClean() {
-		return false
+	if _, err := r.run(r.Dir, "commit", "-a", "-m", message); err != nil {
+		return false, err
 	}
-	exec.Mustex(r.Dir, "git", "commit", "-a", "-m", message)
-	return true
+	return true, nil
 }
 
 // RestoreFile restores the specified file in the repository to its original state
-func (r *Repo) RestoreFile(fileName string) {
-	exec.Mustex(r.Dir, "git", "checkout", "--", fileName)
+func (r *ExecRepo) RestoreFile(fileName string) error {
+	_, err := r.run(r.Dir, "checkout", "--", fileName)
+	return err
 }
 
 // GetChangedFiles returns a list of files that have been changed in the repository
-func (r *Repo) GetChangedFiles() []string {
-	s, err := exec.Ex(r.Dir, "git", "diff", "--name-only")
+func (r *ExecRepo) GetChangedFiles() ([]string, error) {
+	s, err := r.run(r.Dir, "diff", "--name-only")
 	if err != nil {
-		log.Fatalf("ERROR: %s", err)
+		return nil, err
 	}
-	var files []string
-	sc := bufio.NewScanner(strings.NewReader(s))
-	for sc.Scan() {
-		files = append(files, sc.Text())
-	}
-	if err := sc.Err(); err != nil {
-		log.Fatalf("ERROR: %s", err)
+	return splitLines(s)
+}
+
+// GetLastCommitFiles returns a list of files changed by the most recent commit of the current branch
+func (r *ExecRepo) GetLastCommitFiles() ([]string, error) {
+	s, err := r.run(r.Dir, "diff-tree", "--no-commit-id", "--name-only", "-r", "HEAD")
+	if err != nil {
+		return nil, err
 	}
-	return files
+	return splitLines(s)
 }
 
 // IsClean returns true if there is no local changes (nothing to commit)
-func (r *Repo) IsClean() bool {
-	cmd := oe.Command("git", "status", "--porcelain")
+func (r *ExecRepo) IsClean() (bool, error) {
+	cmd := oe.Command(git, "status", "--porcelain")
 	cmd.Dir = r.Dir
 	b, err := cmd.CombinedOutput()
 	if err != nil {
-		log.Fatalf("ERROR: %s", err)
+		return false, &CommandError{Args: cmd.Args[1:], Output: string(b), Err: err}
+	}
+	return len(b) == 0, nil
+}
+
+// Diff returns the files in path changed between from and to revisions
+func (r *ExecRepo) Diff(from, to, path string) ([]FileChange, error) {
+	if isRootPath(path) {
+		path = "."
+	}
+	b, err := output(r.Dir, "diff", "--name-status", "--no-renames", "-z", from, to, "--", path)
+	if err != nil {
+		return nil, err
+	}
+	// NUL separated status and path pairs
+	fields := strings.Split(strings.TrimSuffix(string(b), "\x00"), "\x00")
+	var changes []FileChange
+	for i := 0; i+1 < len(fields); i += 2 {
+		status := FileModified
+		switch fields[i] {
+		case "A":
+			status = FileAdded
+		case "D":
+			status = FileDeleted
+		}
+		changes = append(changes, FileChange{Path: fields[i+1], Status: status})
+	}
+	return changes, nil
+}
+
+// ReadFile returns the content of the file in the revision
+func (r *ExecRepo) ReadFile(revision, path string) ([]byte, error) {
+	path = filepath.ToSlash(path)
+	// ls-tree fails for unknown revisions and lists nothing for missing files
+	found, err := output(r.Dir, "ls-tree", "--name-only", revision, "--", path)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("%s:%s: %w", revision, path, os.ErrNotExist)
+	}
+	return output(r.Dir, "cat-file", "blob", revision+":"+path)
+}
+
+// Log returns up to max most recent commits of the revision changing files in path
+func (r *ExecRepo) Log(revision, path string, max int) ([]Commit, error) {
+	if isRootPath(path) {
+		path = "."
+	}
+	args := []string{"log", "-z", "--first-parent", "--format=%H%n%P%n%ct%n%an <%ae>%n%B"}
+	if max > 0 {
+		args = append(args, fmt.Sprintf("--max-count=%d", max))
+	}
+	b, err := output(r.Dir, append(args, revision, "--", path)...)
+	if err != nil {
+		return nil, err
+	}
+	var commits []Commit
+	// NUL separated commits of hash, parents, time, author and message lines
+	for _, record := range strings.Split(strings.TrimSuffix(string(b), "\x00"), "\x00") {
+		if record == "" {
+			continue
+		}
+		fields := strings.SplitN(record, "\n", 5)
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("unable to parse git log record %q", record)
+		}
+		sec, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse the commit date of %s: %w", fields[0], err)
+		}
+		commits = append(commits, Commit{Hash: fields[0], Parents: strings.Fields(fields[1]), Author: fields[3], Message: strings.TrimRight(fields[4], "\n"), Committed: time.Unix(sec, 0)})
+	}
+	return commits, nil
+}
+
+// RemoteBranches returns the fetched remote branches with names starting with prefix
+func (r *ExecRepo) RemoteBranches(prefix string) ([]RemoteBranch, error) {
+	refPrefix := "refs/remotes/" + r.RemoteName + "/"
+	b, err := output(r.Dir, "for-each-ref", "--sort=refname", "--format=%(refname) %(committerdate:unix)", refPrefix)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := splitLines(string(b))
+	if err != nil {
+		return nil, err
 	}
-	return len(b) == 0
+	var branches []RemoteBranch
+	for _, line := range lines {
+		ref, date, _ := strings.Cut(line, " ")
+		name := strings.TrimPrefix(ref, refPrefix)
+		// the symbolic HEAD reference has no committer date
+		if !strings.HasPrefix(name, prefix) || name == "HEAD" {
+			continue
+		}
+		sec, err := strconv.ParseInt(date, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse the commit date of %s: %w", ref, err)
+		}
+		branches = append(branches, RemoteBranch{Name: name, Committed: time.Unix(sec, 0)})
+	}
+	return branches, nil
+}
+
+// DeleteRemoteBranch deletes the branch in the remote repository and its remote tracking branch
+func (r *ExecRepo) DeleteRemoteBranch(branch string) error {
+	_, err := r.runRemote(r.Dir, "push", "--delete", r.RemoteName, branch)
+	return err
 }
 
 // Push pushes all local changes to the remote repository
 // all changes should be already commited
-func (r *Repo) Push(branches []string) {
-	args := append([]string{"push", r.RemoteName, "-f", "--set-upstream"}, branches...)
-	exec.Mustex(r.Dir, "git", args...)
+func (r *ExecRepo) Push(branches []string, mode PushMode) ([]PushResult, error) {
+	if len(branches) == 0 {
+		return nil, nil
+	}
+	args := []string{"push", "--porcelain", "--set-upstream"}
+	switch mode {
+	case PushForce:
+		args = append(args, "-f")
+	case PushForceWithLease:
+		for _, b := range branches {
+			// empty expected value requires the branch to not exist in the remote repository
+			expected, err := r.run(r.Dir, "rev-parse", "--verify", "--quiet", "refs/remotes/"+r.RemoteName+"/"+b)
+			if err != nil {
+				expected = ""
+			}
+			args = append(args, fmt.Sprintf("--force-with-lease=refs/heads/%s:%s", b, strings.TrimSpace(expected)))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported push mode %d", mode)
+	}
+	args = append(args, r.RemoteName)
+	args = append(args, branches...)
+	out, err := r.runRemote(r.Dir, args...)
+	statuses := parsePushPorcelain(out)
+	results := make([]PushResult, 0, len(branches))
+	for _, b := range branches {
+		res, ok := statuses[b]
+		if !ok {
+			res = PushResult{Branch: b, Status: PushFailed, Reason: "no push status reported"}
+			if err != nil {
+				res.Reason = err.Error()
+			}
+		}
+		results = append(results, res)
+	}
+	if perr := PushError(results); perr != nil {
+		return results, perr
+	}
+	return results, err
+}
+
+// Worktree adds a working copy of the repository in dir with primaryBranch commit checked out.
+// The sparse checkout of the repository applies to the worktree.
+func (r *ExecRepo) Worktree(dir, primaryBranch string) (Repo, error) {
+	if _, err := r.run(r.Dir, "worktree", "add", "--detach", "--no-checkout", dir, primaryBranch); err != nil {
+		return nil, fmt.Errorf("Unable to add worktree: %w", err)
+	}
+	wt := &ExecRepo{
+		Dir:         dir,
+		RemoteName:  r.RemoteName,
+		Credentials: r.Credentials,
+		Logger:      r.Logger,
+		mainDir:     r.Dir,
+	}
+	sparse, err := ioutil.ReadFile(filepath.Join(r.Dir, ".git/info/sparse-checkout"))
+	if err == nil {
+		// sparse-checkout patterns are per worktree
+		gitDir, err := r.run(dir, "rev-parse", "--absolute-git-dir")
+		if err != nil {
+			return nil, err
+		}
+		infoDir := filepath.Join(strings.TrimSpace(gitDir), "info")
+		if err := os.MkdirAll(infoDir, 0755); err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(filepath.Join(infoDir, "sparse-checkout"), sparse, 0644); err != nil {
+			return nil, fmt.Errorf("Unable to create worktree sparse-checkout: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if _, err := r.run(dir, "reset", "--hard", "--quiet"); err != nil {
+		return nil, err
+	}
+	return wt, nil
+}
+
+// parsePushPorcelain parses git push --porcelain output into push results by branch.
+// Ref status lines have "<flag>\t<from>:<to>\t<summary>" format.
+func parsePushPorcelain(out string) map[string]PushResult {
+	results := make(map[string]PushResult)
+	lines, _ := splitLines(out)
+	for _, line := range lines {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 || len(fields[0]) != 1 {
+			continue
+		}
+		_, to, found := strings.Cut(fields[1], ":")
+		if !found || !strings.HasPrefix(to, "refs/heads/") {
+			continue
+		}
+		res := PushResult{Branch: strings.TrimPrefix(to, "refs/heads/"), Status: PushOK}
+		switch fields[0] {
+		case "=":
+			res.Status = PushUpToDate
+		case "!":
+			res.Status = PushFailed
+			if strings.HasPrefix(fields[2], "[rejected]") {
+				res.Status = PushRejected
+			}
+			res.Reason = fields[2]
+		}
+		results[res.Branch] = res
+	}
+	return results
+}
+
+// context returns the context of the commands carrying the repo logger
+func (r *ExecRepo) context() context.Context {
+	ctx := context.Background()
+	if r.Logger != nil {
+		ctx = exec.WithLogger(ctx, r.Logger)
+	}
+	return ctx
+}
+
+// run is an internal helper to execute git command in dir logged to the repo logger.
+// The returned error is *CommandError.
+func (r *ExecRepo) run(dir string, args ...string) (string, error) {
+	out, err := exec.ExContext(r.context(), dir, git, args...)
+	if err != nil {
+		return out, &CommandError{Args: args, Output: out, Err: err}
+	}
+	return out, nil
+}
+
+// output is like run but returns the standard output of the command without logging it
+func output(dir string, args ...string) ([]byte, error) {
+	cmd := oe.Command(git, args...)
+	cmd.Dir = dir
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	b, err := cmd.Output()
+	if err != nil {
+		return b, &CommandError{Args: args, Output: stderr.String(), Err: err}
+	}
+	return b, nil
+}
+
+// credentialHelper answers git credential requests with the credentials passed in the environment
+const credentialHelper = `!f() { test "$1" = get && echo "username=${GITOPS_GIT_USERNAME}" && echo "password=${GITOPS_GIT_PASSWORD}"; }; f`
+
+// runRemote is like run but passes the repo credentials to the git command accessing the remote.
+// The credentials are kept out of the command line and the log.
+func (r *ExecRepo) runRemote(dir string, args ...string) (string, error) {
+	if r.Credentials == nil {
+		return r.run(dir, args...)
+	}
+	username, password, err := r.Credentials()
+	if err != nil {
+		return "", fmt.Errorf("unable to get git credentials: %w", err)
+	}
+	// the empty helper resets the configured credential helpers
+	args = append([]string{"-c", "credential.helper=", "-c", "credential.helper=" + credentialHelper}, args...)
+	env := []string{"GITOPS_GIT_USERNAME=" + username, "GITOPS_GIT_PASSWORD=" + password}
+	out, err := exec.ExEnv(r.context(), dir, env, git, args...)
+	if err != nil {
+		return out, &CommandError{Args: args, Output: out, Err: err}
+	}
+	return out, nil
+}
+
+// splitLines is an internal helper to parse a multiline command output.
+func splitLines(s string) ([]string, error) {
+	var lines []string
+	sc := bufio.NewScanner(strings.NewReader(s))
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	return lines, sc.Err()
 }
 
 // isRootPath is an internal helper to detect "full repo" case.
 func isRootPath(gitopsPath string) bool {
 	return gitopsPath == "" || gitopsPath == "."
-}
\ No newline at end of file
+}
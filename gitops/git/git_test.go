@@ -0,0 +1,84 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package git
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCloneError(t *testing.T) {
+	dir := t.TempDir()
+	_, err := Clone(filepath.Join(dir, "missing.git"), filepath.Join(dir, "clone"), "", "master", "cloud")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), "git clone") {
+		t.Errorf("error should include git command: %v", err)
+	}
+}
+
+func TestRepoErrors(t *testing.T) {
+	r := &ExecRepo{Dir: t.TempDir(), RemoteName: "origin"}
+	if err := r.Fetch("deploy/*"); err == nil {
+		t.Error("Fetch: expected error")
+	}
+	if _, err := r.SwitchToBranch("deploy/test", "master"); err == nil {
+		t.Error("SwitchToBranch: expected error")
+	}
+	if _, err := r.Commit("test", "cloud"); err == nil {
+		t.Error("Commit: expected error")
+	}
+	if _, err := r.IsClean(); err == nil {
+		t.Error("IsClean: expected error")
+	}
+	if _, err := r.Push([]string{"deploy/test"}, PushForce); err == nil {
+		t.Error("Push: expected error")
+	}
+}
+
+func TestParsePushPorcelain(t *testing.T) {
+	out := "To /tmp/origin.git\n" +
+		"*\trefs/heads/deploy/new:refs/heads/deploy/new\t[new branch]\n" +
+		"+\trefs/heads/deploy/prod:refs/heads/deploy/prod\t1234567...89abcde (forced update)\n" +
+		"=\trefs/heads/deploy/dev:refs/heads/deploy/dev\t[up to date]\n" +
+		"!\trefs/heads/deploy/stage:refs/heads/deploy/stage\t[rejected] (stale info)\n" +
+		"!\trefs/heads/deploy/qa:refs/heads/deploy/qa\t[remote rejected] (hook declined)\n" +
+		"Done\n"
+	expected := map[string]PushResult{
+		"deploy/new":   {Branch: "deploy/new", Status: PushOK},
+		"deploy/prod":  {Branch: "deploy/prod", Status: PushOK},
+		"deploy/dev":   {Branch: "deploy/dev", Status: PushUpToDate},
+		"deploy/stage": {Branch: "deploy/stage", Status: PushRejected, Reason: "[rejected] (stale info)"},
+		"deploy/qa":    {Branch: "deploy/qa", Status: PushFailed, Reason: "[remote rejected] (hook declined)"},
+	}
+	if got := parsePushPorcelain(out); !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected push results:\n%+v\nexpected:\n%+v", got, expected)
+	}
+}
+
+func TestPushError(t *testing.T) {
+	err := PushError([]PushResult{{Branch: "a", Status: PushOK}, {Branch: "b", Status: PushRejected}})
+	if !errors.Is(err, ErrStaleLease) {
+		t.Errorf("expected ErrStaleLease, got %v", err)
+	}
+	err = PushError([]PushResult{{Branch: "b", Status: PushRejected}, {Branch: "c", Status: PushFailed, Reason: "denied"}})
+	if err == nil || errors.Is(err, ErrStaleLease) || !strings.Contains(err.Error(), "c (denied)") {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := PushError([]PushResult{{Branch: "a", Status: PushUpToDate}}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
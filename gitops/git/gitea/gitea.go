@@ -0,0 +1,298 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+// Package gitea implements git.Server for Gitea and Forgejo.
+package gitea
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/adobe/rules_gitops/gitops/git"
+)
+
+var (
+	giteaHost   = flag.String("gitea_host", "", "the base URL of the gitea or forgejo instance, e.g. https://gitea.example.com")
+	repoOwner   = flag.String("gitea_repo_owner", "", "the owner user/organization to use for gitea api requests")
+	repo        = flag.String("gitea_repo", "", "the repo to use for gitea api requests")
+	accessToken = flag.String("gitea_access_token", os.Getenv("GITEA_TOKEN"), "the access token to authenticate requests")
+)
+
+// pageSize is the number of pull requests requested per page
+const pageSize = 50
+
+// wipPrefix is the default title prefix marking a pull request as work in progress
+const wipPrefix = "WIP: "
+
+type branch struct {
+	Ref string `json:"ref"`
+}
+
+type pullrequest struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+	Title   string `json:"title"`
+	Head    branch `json:"head"`
+	Base    branch `json:"base"`
+}
+
+type label struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type apiError struct {
+	Message string `json:"message"`
+}
+
+// statusError is returned for unsuccessful api responses
+type statusError struct {
+	StatusCode int
+	Status     string
+	Message    string
+}
+
+func (e *statusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("gitea response %s", e.Status)
+	}
+	return fmt.Sprintf("gitea response %s: %s", e.Status, e.Message)
+}
+
+func (p *pullrequest) toPullRequest(created bool) *git.PullRequest {
+	return &git.PullRequest{
+		Number:  p.Number,
+		URL:     p.HTMLURL,
+		Created: created,
+	}
+}
+
+// Server implements git.Server using the Gitea API of the gitea_repo_owner/gitea_repo repository
+type Server struct{}
+
+var _ git.Server = Server{}
+
+// CreatePR creates a pull request using branch names from and to. The open pull request is reused if it already exists.
+func (Server) CreatePR(from, to, title, body string) (*git.PullRequest, error) {
+	req := map[string]string{
+		"head":  from,
+		"base":  to,
+		"title": title,
+		"body":  body,
+	}
+	var created pullrequest
+	err := request("POST", "/pulls", req, &created)
+	if err == nil {
+		log.Println("Created PR: ", created.HTMLURL)
+		return created.toPullRequest(true), nil
+	}
+	var se *statusError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusConflict {
+		return nil, fmt.Errorf("unable to create PR: %w", err)
+	}
+	// Handle the case: "Create PR" request fails because it already exists
+	pr, ferr := findPR(from, to)
+	if ferr != nil {
+		return nil, fmt.Errorf("unable to create PR: %w: unable to find existing PR: %v", err, ferr)
+	}
+	if pr == nil {
+		return nil, fmt.Errorf("unable to create PR: %w: no open PR from %s into %s", err, from, to)
+	}
+	log.Println("Reusing existing PR: ", pr.HTMLURL)
+	return pr.toPullRequest(false), nil
+}
+
+// FindPR returns the open pull request from the head branch. Returns nil if there is none.
+func (Server) FindPR(from string) (*git.PullRequest, error) {
+	pr, err := findPR(from, "")
+	if err != nil {
+		return nil, fmt.Errorf("unable to find PR from %s: %w", from, err)
+	}
+	if pr == nil {
+		return nil, nil
+	}
+	return pr.toPullRequest(false), nil
+}
+
+// findPR looks up the open pull request from head branch into base branch. Any base branch matches if empty.
+func findPR(head, base string) (*pullrequest, error) {
+	for page := 1; ; page++ {
+		var prs []pullrequest
+		if err := request("GET", fmt.Sprintf("/pulls?state=open&limit=%d&page=%d", pageSize, page), nil, &prs); err != nil {
+			return nil, err
+		}
+		for i := range prs {
+			if prs[i].Head.Ref == head && (base == "" || prs[i].Base.Ref == base) {
+				return &prs[i], nil
+			}
+		}
+		if len(prs) < pageSize {
+			return nil, nil
+		}
+	}
+}
+
+// UpdatePR replaces the title and the description of the pull request
+func (Server) UpdatePR(number int, title, body string) error {
+	return editPR(number, map[string]string{"title": title, "body": body})
+}
+
+// ClosePR closes the pull request without merging
+func (Server) ClosePR(number int) error {
+	return editPR(number, map[string]string{"state": "closed"})
+}
+
+func editPR(number int, edit map[string]string) error {
+	if err := request("PATCH", fmt.Sprintf("/pulls/%d", number), edit, nil); err != nil {
+		return fmt.Errorf("unable to update PR %d: %w", number, err)
+	}
+	return nil
+}
+
+// AddLabels adds the repository labels to the pull request
+func (Server) AddLabels(number int, labels []string) error {
+	var repoLabels []label
+	if err := request("GET", "/labels?limit=1000", nil, &repoLabels); err != nil {
+		return fmt.Errorf("unable to list labels: %w", err)
+	}
+	ids := make(map[string]int64, len(repoLabels))
+	for _, l := range repoLabels {
+		ids[l.Name] = l.ID
+	}
+	req := struct {
+		Labels []int64 `json:"labels"`
+	}{}
+	for _, name := range labels {
+		id, ok := ids[name]
+		if !ok {
+			return fmt.Errorf("label %s not found in %s/%s", name, *repoOwner, *repo)
+		}
+		req.Labels = append(req.Labels, id)
+	}
+	if err := request("POST", fmt.Sprintf("/issues/%d/labels", number), &req, nil); err != nil {
+		return fmt.Errorf("unable to add labels to PR %d: %w", number, err)
+	}
+	return nil
+}
+
+// RequestReviewers requests the review of the pull request from the users
+func (Server) RequestReviewers(number int, reviewers []string) error {
+	req := struct {
+		Reviewers []string `json:"reviewers"`
+	}{reviewers}
+	if err := request("POST", fmt.Sprintf("/pulls/%d/requested_reviewers", number), &req, nil); err != nil {
+		return fmt.Errorf("unable to request reviewers of PR %d: %w", number, err)
+	}
+	return nil
+}
+
+// SetDraft marks the pull request as work in progress using the "WIP:" title prefix
+func (Server) SetDraft(number int, draft bool) error {
+	var pr pullrequest
+	if err := request("GET", fmt.Sprintf("/pulls/%d", number), nil, &pr); err != nil {
+		return fmt.Errorf("unable to get PR %d: %w", number, err)
+	}
+	title := pr.Title
+	for _, prefix := range []string{wipPrefix, "WIP:", "[WIP]"} {
+		title = strings.TrimPrefix(title, prefix)
+	}
+	title = strings.TrimSpace(title)
+	if draft {
+		title = wipPrefix + title
+	}
+	if title == pr.Title {
+		return nil
+	}
+	return editPR(number, map[string]string{"title": title})
+}
+
+// EnableAutoMerge schedules the merge of the pull request once all checks succeed. Requires Gitea 1.17 or later.
+func (Server) EnableAutoMerge(number int, method string) error {
+	if err := git.ValidMergeMethod(method); err != nil {
+		return err
+	}
+	if method == git.MergeMethodDefault {
+		method = git.MergeMethodMerge
+	}
+	req := struct {
+		Do                     string `json:"Do"`
+		MergeWhenChecksSucceed bool   `json:"merge_when_checks_succeed"`
+	}{method, true}
+	if err := request("POST", fmt.Sprintf("/pulls/%d/merge", number), &req, nil); err != nil {
+		return fmt.Errorf("unable to enable auto-merge of PR %d: %w", number, err)
+	}
+	return nil
+}
+
+// request sends the repository api request with the json body in and parses the json response into out.
+// Both in and out are optional.
+func request(method, path string, in, out interface{}) error {
+	if *giteaHost == "" {
+		return errors.New("gitea_host must be set")
+	}
+	if *repoOwner == "" {
+		return errors.New("gitea_repo_owner must be set")
+	}
+	if *repo == "" {
+		return errors.New("gitea_repo must be set")
+	}
+	if *accessToken == "" {
+		return errors.New("gitea_access_token must be set")
+	}
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	endpoint := fmt.Sprintf("%s/api/v1/repos/%s/%s%s", strings.TrimSuffix(*giteaHost, "/"), url.PathEscape(*repoOwner), url.PathEscape(*repo), path)
+	req, err := http.NewRequest(method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "token "+*accessToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	responseBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e apiError
+		json.Unmarshal(responseBody, &e)
+		return &statusError{StatusCode: resp.StatusCode, Status: resp.Status, Message: e.Message}
+	}
+	if out == nil || len(responseBody) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(responseBody, out); err != nil {
+		return fmt.Errorf("unable to parse gitea response: %w", err)
+	}
+	return nil
+}
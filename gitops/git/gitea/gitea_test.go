@@ -0,0 +1,277 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package gitea
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/adobe/rules_gitops/gitops/git"
+)
+
+// fakeGitea is a stand-in of the gitea pull request api of owner/repo
+type fakeGitea struct {
+	pulls     []*fakePull
+	reviewers map[int][]string
+	labels    map[int][]int64
+	// autoMerge is the merge style scheduled when checks succeed
+	autoMerge map[int]string
+	// conflict fails every pull request creation with a conflict
+	conflict bool
+}
+
+type fakePull struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	State   string `json:"state"`
+	Head    branch `json:"head"`
+	Base    branch `json:"base"`
+}
+
+func (g *fakeGitea) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "token secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/repos/owner/repo")
+	var req map[string]json.RawMessage
+	json.NewDecoder(r.Body).Decode(&req)
+	str := func(key string) string {
+		var s string
+		json.Unmarshal(req[key], &s)
+		return s
+	}
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case r.Method == "POST" && path == "/pulls":
+		if g.conflict {
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprintln(w, `{"message":"conflict"}`)
+			return
+		}
+		for _, p := range g.pulls {
+			if p.State == "open" && p.Head.Ref == str("head") && p.Base.Ref == str("base") {
+				w.WriteHeader(http.StatusConflict)
+				fmt.Fprintf(w, `{"message":"pull request already exists for these targets [id: %d]"}`, p.Number)
+				return
+			}
+		}
+		p := &fakePull{Number: len(g.pulls) + 1, Title: str("title"), Body: str("body"), State: "open", Head: branch{str("head")}, Base: branch{str("base")}}
+		p.HTMLURL = fmt.Sprintf("https://gitea.example.com/owner/repo/pulls/%d", p.Number)
+		g.pulls = append(g.pulls, p)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(p)
+	case r.Method == "GET" && path == "/pulls":
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		var open []*fakePull
+		for _, p := range g.pulls {
+			if p.State == r.URL.Query().Get("state") {
+				open = append(open, p)
+			}
+		}
+		res := []*fakePull{}
+		for i := (page - 1) * limit; i < page*limit && i < len(open); i++ {
+			res = append(res, open[i])
+		}
+		json.NewEncoder(w).Encode(res)
+	case r.Method == "GET" && path == "/labels":
+		fmt.Fprintln(w, `[{"id":3,"name":"gitops"},{"id":5,"name":"prod"}]`)
+	case len(parts) >= 2 && (parts[0] == "pulls" || parts[0] == "issues"):
+		n, _ := strconv.Atoi(parts[1])
+		if n < 1 || n > len(g.pulls) {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintln(w, `{"message":"The target couldn't be found."}`)
+			return
+		}
+		p := g.pulls[n-1]
+		switch {
+		case r.Method == "GET" && len(parts) == 2:
+		case r.Method == "PATCH" && len(parts) == 2:
+			for key, field := range map[string]*string{"title": &p.Title, "body": &p.Body, "state": &p.State} {
+				if _, ok := req[key]; ok {
+					*field = str(key)
+				}
+			}
+		case r.Method == "POST" && parts[2] == "requested_reviewers":
+			var reviewers []string
+			json.Unmarshal(req["reviewers"], &reviewers)
+			g.reviewers[n] = append(g.reviewers[n], reviewers...)
+		case r.Method == "POST" && parts[2] == "merge":
+			if string(req["merge_when_checks_succeed"]) != "true" {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			g.autoMerge[n] = str("Do")
+			w.WriteHeader(http.StatusCreated)
+			return
+		case r.Method == "POST" && parts[2] == "labels":
+			var labels []int64
+			json.Unmarshal(req["labels"], &labels)
+			g.labels[n] = append(g.labels[n], labels...)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		json.NewEncoder(w).Encode(p)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newFakeGitea(t *testing.T) *fakeGitea {
+	g := &fakeGitea{reviewers: map[int][]string{}, labels: map[int][]int64{}, autoMerge: map[int]string{}}
+	ts := httptest.NewServer(g)
+	t.Cleanup(ts.Close)
+	oldHost, oldOwner, oldRepo, oldToken := *giteaHost, *repoOwner, *repo, *accessToken
+	t.Cleanup(func() { *giteaHost, *repoOwner, *repo, *accessToken = oldHost, oldOwner, oldRepo, oldToken })
+	*giteaHost, *repoOwner, *repo, *accessToken = ts.URL+"/", "owner", "repo", "secret"
+	return g
+}
+
+func TestCreatePR(t *testing.T) {
+	g := newFakeGitea(t)
+	s := Server{}
+	pr, err := s.CreatePR("deploy/dev", "master", "GitOps deployment deploy/dev", "body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &git.PullRequest{Number: 1, URL: "https://gitea.example.com/owner/repo/pulls/1", Created: true}
+	if !reflect.DeepEqual(pr, expected) {
+		t.Errorf("Unexpected PR: %+v", pr)
+	}
+
+	pr, err = s.CreatePR("deploy/dev", "master", "GitOps deployment deploy/dev", "body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected.Created = false
+	if !reflect.DeepEqual(pr, expected) {
+		t.Errorf("Existing PR should be reused: %+v", pr)
+	}
+	if len(g.pulls) != 1 {
+		t.Errorf("Unexpected pull requests: %d", len(g.pulls))
+	}
+
+	// a conflict without an open PR is returned
+	g.conflict = true
+	if pr, err := s.CreatePR("deploy/prod", "master", "title", "body"); err == nil || !strings.Contains(err.Error(), "no open PR from deploy/prod into master") {
+		t.Errorf("Unexpected PR: %+v %v", pr, err)
+	}
+}
+
+func TestFindPR(t *testing.T) {
+	g := newFakeGitea(t)
+	s := Server{}
+	// more than one page of pull requests
+	for i := 0; i <= pageSize; i++ {
+		if _, err := s.CreatePR(fmt.Sprintf("deploy/%d", i), "master", "title", "body"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g.pulls[0].State = "closed"
+	pr, err := s.FindPR(fmt.Sprintf("deploy/%d", pageSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr == nil || pr.Number != pageSize+1 {
+		t.Errorf("Unexpected PR: %+v", pr)
+	}
+	for _, head := range []string{"deploy/0", "deploy/missing"} {
+		pr, err = s.FindPR(head)
+		if err != nil || pr != nil {
+			t.Errorf("Unexpected PR from %s: %+v %v", head, pr, err)
+		}
+	}
+}
+
+func TestUpdatePR(t *testing.T) {
+	g := newFakeGitea(t)
+	s := Server{}
+	if _, err := s.CreatePR("deploy/dev", "master", "old", "old body"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdatePR(1, "new", "new body"); err != nil {
+		t.Fatal(err)
+	}
+	if p := g.pulls[0]; p.Title != "new" || p.Body != "new body" {
+		t.Errorf("Unexpected PR after update: %+v", p)
+	}
+	if err := s.SetDraft(1, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetDraft(1, true); err != nil {
+		t.Fatal(err)
+	}
+	if g.pulls[0].Title != "WIP: new" {
+		t.Errorf("Unexpected draft title %q", g.pulls[0].Title)
+	}
+	if err := s.SetDraft(1, false); err != nil {
+		t.Fatal(err)
+	}
+	if g.pulls[0].Title != "new" {
+		t.Errorf("Unexpected ready title %q", g.pulls[0].Title)
+	}
+	if err := s.AddLabels(1, []string{"prod", "gitops"}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(g.labels[1], []int64{5, 3}) {
+		t.Errorf("Unexpected labels: %v", g.labels[1])
+	}
+	if err := s.AddLabels(1, []string{"missing"}); err == nil {
+		t.Error("Unknown label should fail")
+	}
+	if err := s.RequestReviewers(1, []string{"alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(g.reviewers[1], []string{"alice"}) {
+		t.Errorf("Unexpected reviewers: %v", g.reviewers[1])
+	}
+	if err := s.EnableAutoMerge(1, git.MergeMethodDefault); err != nil {
+		t.Fatal(err)
+	}
+	if g.autoMerge[1] != "merge" {
+		t.Errorf("Unexpected auto-merge: %v", g.autoMerge)
+	}
+	if err := s.EnableAutoMerge(1, git.MergeMethodSquash); err != nil {
+		t.Fatal(err)
+	}
+	if g.autoMerge[1] != "squash" {
+		t.Errorf("Unexpected auto-merge: %v", g.autoMerge)
+	}
+	if err := s.ClosePR(1); err != nil {
+		t.Fatal(err)
+	}
+	if g.pulls[0].State != "closed" {
+		t.Errorf("PR should be closed: %+v", g.pulls[0])
+	}
+	err := s.UpdatePR(7, "new", "new body")
+	if err == nil || err.Error() != "unable to update PR 7: gitea response 404 Not Found: The target couldn't be found." {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestUnauthorized(t *testing.T) {
+	newFakeGitea(t)
+	*accessToken = "wrong"
+	if _, err := (Server{}).CreatePR("deploy/dev", "master", "title", "body"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
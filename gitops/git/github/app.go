@@ -0,0 +1,232 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/adobe/rules_gitops/gitops/git"
+)
+
+var (
+	appID             = flag.Int64("github_app_id", 0, "the GitHub App id. The App installation token is used instead of github_access_token if set")
+	appInstallationID = flag.Int64("github_app_installation_id", 0, "the GitHub App installation id. Looked up for github_repo_owner/github_repo if not set")
+	appPrivateKeyFile = flag.String("github_app_private_key_file", "", "the PEM file with the GitHub App private key. $GITHUB_APP_PRIVATE_KEY content is used if not set")
+)
+
+// installationTokenUser is the user name of the HTTPS git access with the installation token
+const installationTokenUser = "x-access-token"
+
+// tokenExpiryMargin is how long before the expiration the installation token is refreshed
+const tokenExpiryMargin = 5 * time.Minute
+
+// appTokenSource returns the installation access tokens of the GitHub App.
+// The tokens are valid for an hour, wrap with oauth2.ReuseTokenSource to refresh only expired tokens.
+type appTokenSource struct {
+	// baseURL is the REST API URL with the trailing slash
+	baseURL        string
+	appID          int64
+	installationID int64
+	owner, repo    string
+	key            *rsa.PrivateKey
+	client         *http.Client
+	now            func() time.Time
+}
+
+// Token exchanges the App JWT for an installation access token
+func (s *appTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := s.jwt()
+	if err != nil {
+		return nil, err
+	}
+	if s.installationID == 0 {
+		var installation struct {
+			ID int64 `json:"id"`
+		}
+		if err := s.request("GET", fmt.Sprintf("repos/%s/%s/installation", url.PathEscape(s.owner), url.PathEscape(s.repo)), jwt, &installation); err != nil {
+			return nil, fmt.Errorf("unable to find GitHub App installation of %s/%s: %w", s.owner, s.repo, err)
+		}
+		s.installationID = installation.ID
+	}
+	var token struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := s.request("POST", fmt.Sprintf("app/installations/%d/access_tokens", s.installationID), jwt, &token); err != nil {
+		return nil, fmt.Errorf("unable to create GitHub App installation token: %w", err)
+	}
+	return &oauth2.Token{
+		AccessToken: token.Token,
+		TokenType:   "Bearer",
+		// refresh early, so the token does not expire during a long push
+		Expiry: token.ExpiresAt.Add(-tokenExpiryMargin),
+	}, nil
+}
+
+// jwt returns the RS256 signed JSON Web Token authenticating as the App
+func (s *appTokenSource) jwt() (string, error) {
+	now := s.now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		// allow for the clock drift
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": strconv.FormatInt(s.appID, 10),
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	h := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, h[:])
+	if err != nil {
+		return "", fmt.Errorf("unable to sign GitHub App JWT: %w", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+func (s *appTokenSource) request(method, path, jwt string, out interface{}) error {
+	req, err := http.NewRequest(method, s.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &e) == nil && e.Message != "" {
+			return fmt.Errorf("github response %s: %s", resp.Status, e.Message)
+		}
+		return fmt.Errorf("github response %s", resp.Status)
+	}
+	return json.Unmarshal(body, out)
+}
+
+// parsePrivateKey parses the PKCS #1 or PKCS #8 PEM encoded RSA key
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("not an RSA private key")
+	}
+	return rsaKey, nil
+}
+
+// apiBaseURL returns the REST API URL of github.com or github_enterprise_host
+func apiBaseURL() string {
+	if *githubEnterpriseHost != "" {
+		return "https://" + *githubEnterpriseHost + "/api/v3/"
+	}
+	return "https://api.github.com/"
+}
+
+var appTokens struct {
+	once sync.Once
+	ts   oauth2.TokenSource
+	err  error
+}
+
+// tokenSource returns the token source of the API and git access. The App installation tokens
+// are shared by all the clients and refreshed when expired. Returns nil if github_app_id is not set.
+func tokenSource() (oauth2.TokenSource, error) {
+	if *appID == 0 {
+		return nil, nil
+	}
+	appTokens.once.Do(func() {
+		data := []byte(os.Getenv("GITHUB_APP_PRIVATE_KEY"))
+		if *appPrivateKeyFile != "" {
+			var err error
+			if data, err = ioutil.ReadFile(*appPrivateKeyFile); err != nil {
+				appTokens.err = fmt.Errorf("unable to read GitHub App private key: %w", err)
+				return
+			}
+		}
+		if len(strings.TrimSpace(string(data))) == 0 {
+			appTokens.err = errors.New("github_app_private_key_file or $GITHUB_APP_PRIVATE_KEY must be set with github_app_id")
+			return
+		}
+		key, err := parsePrivateKey(data)
+		if err != nil {
+			appTokens.err = fmt.Errorf("unable to parse GitHub App private key: %w", err)
+			return
+		}
+		if *appInstallationID == 0 && (*repoOwner == "" || *repo == "") {
+			appTokens.err = errors.New("github_repo_owner and github_repo must be set to find the GitHub App installation")
+			return
+		}
+		appTokens.ts = oauth2.ReuseTokenSource(nil, &appTokenSource{
+			baseURL:        apiBaseURL(),
+			appID:          *appID,
+			installationID: *appInstallationID,
+			owner:          *repoOwner,
+			repo:           *repo,
+			key:            key,
+			client:         http.DefaultClient,
+			now:            time.Now,
+		})
+	})
+	return appTokens.ts, appTokens.err
+}
+
+// GitCredentials returns the credentials of the HTTPS git access with the GitHub App installation token.
+// Returns nil if github_app_id is not set, the repository URL credentials are used then.
+func GitCredentials() (git.Credentials, error) {
+	ts, err := tokenSource()
+	if err != nil || ts == nil {
+		return nil, err
+	}
+	return func() (string, string, error) {
+		t, err := ts.Token()
+		if err != nil {
+			return "", "", err
+		}
+		return installationTokenUser, t.AccessToken, nil
+	}, nil
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestAppTokenSource(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	var issued int
+	var expiresAt time.Time
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := verifyJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), &key.PublicKey)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintf(w, `{"message":%q}`, err.Error())
+			return
+		}
+		if claims["iss"] != "42" || claims["iat"] != float64(now.Unix()-60) || claims["exp"] != float64(now.Unix()+540) {
+			t.Errorf("Unexpected claims: %v", claims)
+		}
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v3/repos/owner/repo/installation":
+			fmt.Fprint(w, `{"id":7}`)
+		case r.Method == "POST" && r.URL.Path == "/api/v3/app/installations/7/access_tokens":
+			issued++
+			fmt.Fprintf(w, `{"token":"ghs_%d","expires_at":%q}`, issued, expiresAt.Format(time.RFC3339))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not Found"}`)
+		}
+	}))
+	defer ts.Close()
+	base := &appTokenSource{
+		baseURL: ts.URL + "/api/v3/",
+		appID:   42,
+		owner:   "owner",
+		repo:    "repo",
+		key:     key,
+		client:  http.DefaultClient,
+		now:     func() time.Time { return now },
+	}
+	token := func(src oauth2.TokenSource) string {
+		t.Helper()
+		tok, err := src.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok.AccessToken
+	}
+
+	expiresAt = time.Now().Add(time.Hour)
+	src := oauth2.ReuseTokenSource(nil, base)
+	if got := []string{token(src), token(src)}; got[0] != "ghs_1" || got[1] != "ghs_1" {
+		t.Errorf("The token should be reused: %v", got)
+	}
+	// the tokens expire within the refresh margin
+	expiresAt = time.Now().Add(tokenExpiryMargin - time.Minute)
+	src = oauth2.ReuseTokenSource(nil, base)
+	if got := []string{token(src), token(src)}; got[0] != "ghs_2" || got[1] != "ghs_3" {
+		t.Errorf("The expiring token should be refreshed: %v", got)
+	}
+
+	other := *base
+	other.installationID, other.repo = 0, "other"
+	if _, err := other.Token(); err == nil || !strings.Contains(err.Error(), "404 Not Found: Not Found") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+// verifyJWT returns the claims of the RS256 token signed by the key
+func verifyJWT(token string, key *rsa.PublicKey) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token %q", token)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, h[:], sig); err != nil {
+		return nil, err
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	var claims map[string]interface{}
+	return claims, json.Unmarshal(b, &claims)
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, block := range map[string]*pem.Block{
+		"PKCS1": {Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)},
+		"PKCS8": {Type: "PRIVATE KEY", Bytes: pkcs8},
+	} {
+		parsed, err := parsePrivateKey(pem.EncodeToMemory(block))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if !parsed.Equal(key) {
+			t.Errorf("%s: unexpected key", name)
+		}
+	}
+	if _, err := parsePrivateKey([]byte("not a key")); err == nil {
+		t.Error("Invalid key should fail")
+	}
+}
+
+func TestGitCredentials(t *testing.T) {
+	oldID, oldFile := *appID, *appPrivateKeyFile
+	t.Cleanup(func() {
+		*appID, *appPrivateKeyFile = oldID, oldFile
+		appTokens.once, appTokens.ts, appTokens.err = sync.Once{}, nil, nil
+	})
+	*appID = 0
+	creds, err := GitCredentials()
+	if creds != nil || err != nil {
+		t.Errorf("No credentials are expected without the App: %v", err)
+	}
+	*appID = 42
+	*appPrivateKeyFile = ""
+	t.Setenv("GITHUB_APP_PRIVATE_KEY", "")
+	if _, err := GitCredentials(); err == nil || !strings.Contains(err.Error(), "GITHUB_APP_PRIVATE_KEY must be set") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
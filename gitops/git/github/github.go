@@ -4,11 +4,14 @@ import (
 	"context"
 	"errors"
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 
+	"github.com/adobe/rules_gitops/gitops/git"
 	"github.com/google/go-github/v32/github"
 	"golang.org/x/oauth2"
 )
@@ -20,35 +23,59 @@ var (
 	githubEnterpriseHost = flag.String("github_enterprise_host", "", "The host name of the private enterprise github, e.g. git.corp.adobe.com")
 )
 
-func CreatePR(from, to, title, body string) error {
-	if *repoOwner == "" {
-		return errors.New("github_repo_owner must be set")
-	}
-	if *repo == "" {
-		return errors.New("github_repo must be set")
+// Server implements git.Server using the GitHub API of the github_repo_owner/github_repo repository
+type Server struct{}
+
+var _ git.Server = Server{}
+
+// newClient returns the API client authenticated with the GitHub App installation token or github_access_token
+var newClient = func(ctx context.Context) (*github.Client, error) {
+	ts, err := tokenSource()
+	if err != nil {
+		return nil, err
 	}
-	if *pat == "" {
-		return errors.New("github_access_token must be set")
+	if ts == nil {
+		if *pat == "" {
+			return nil, errors.New("github_access_token or github_app_id must be set")
+		}
+		ts = oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: *pat},
+		)
 	}
-
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: *pat},
-	)
 	tc := oauth2.NewClient(ctx, ts)
-
-	var gh *github.Client
 	if *githubEnterpriseHost != "" {
 		baseUrl := "https://" + *githubEnterpriseHost + "/api/v3/"
 		uploadUrl := "https://" + *githubEnterpriseHost + "/api/uploads/"
-		var err error
-		gh, err = github.NewEnterpriseClient(baseUrl, uploadUrl, tc)
+		gh, err := github.NewEnterpriseClient(baseUrl, uploadUrl, tc)
 		if err != nil {
-			log.Println("Error in creating github client", err)
-			return nil
+			return nil, fmt.Errorf("Error in creating github client: %w", err)
 		}
-	} else {
-		gh = github.NewClient(tc)
+		return gh, nil
+	}
+	return github.NewClient(tc), nil
+}
+
+func client(ctx context.Context) (*github.Client, error) {
+	if *repoOwner == "" {
+		return nil, errors.New("github_repo_owner must be set")
+	}
+	if *repo == "" {
+		return nil, errors.New("github_repo must be set")
+	}
+	return newClient(ctx)
+}
+
+// CreatePR creates a pull request using branch names from and to
+func CreatePR(from, to, title, body string) (*git.PullRequest, error) {
+	return Server{}.CreatePR(from, to, title, body)
+}
+
+// CreatePR creates a pull request using branch names from and to. The open pull request is reused if it already exists.
+func (Server) CreatePR(from, to, title, body string) (*git.PullRequest, error) {
+	ctx := context.Background()
+	gh, err := client(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	pr := &github.NewPullRequest{
@@ -62,14 +89,27 @@ func CreatePR(from, to, title, body string) error {
 	}
 	createdPr, resp, err := gh.PullRequests.Create(ctx, *repoOwner, *repo, pr)
 	if err == nil {
-		log.Println("Created PR: ", *createdPr.URL)
-		return err
+		log.Println("Created PR: ", createdPr.GetHTMLURL())
+		return &git.PullRequest{
+			Number:  createdPr.GetNumber(),
+			URL:     createdPr.GetHTMLURL(),
+			Created: true,
+		}, nil
+	}
+
+	if resp == nil {
+		return nil, err
 	}
 
 	if resp.StatusCode == http.StatusUnprocessableEntity {
-		// Handle the case: "Create PR" request fails because it already exists
-		log.Println("Reusing existing PR")
-		return nil
+		// Handle the case: "Create PR" request fails because it already exists.
+		// The other validation errors, like no commits between the branches, have no open PR.
+		existing, ferr := findPR(ctx, gh, from, to)
+		if ferr != nil {
+			return nil, fmt.Errorf("%w: %v", err, ferr)
+		}
+		log.Println("Reusing existing PR: ", existing.URL)
+		return existing, nil
 	}
 
 	// All other github responses
@@ -81,5 +121,179 @@ func CreatePR(from, to, title, body string) error {
 		log.Println("github response: ", string(responseBody))
 	}
 
-	return err
+	return nil, err
+}
+
+// FindPR returns the open pull request from the head branch. Returns nil if there is none.
+func (Server) FindPR(from string) (*git.PullRequest, error) {
+	ctx := context.Background()
+	gh, err := client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prs, _, err := gh.PullRequests.List(ctx, *repoOwner, *repo, &github.PullRequestListOptions{
+		State: "open",
+		Head:  *repoOwner + ":" + from,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to find PR from %s: %w", from, err)
+	}
+	if len(prs) == 0 {
+		return nil, nil
+	}
+	return &git.PullRequest{
+		Number: prs[0].GetNumber(),
+		URL:    prs[0].GetHTMLURL(),
+	}, nil
+}
+
+// UpdatePR replaces the title and the description of the pull request
+func (Server) UpdatePR(number int, title, body string) error {
+	return editPR(number, &github.PullRequest{Title: &title, Body: &body})
+}
+
+// ClosePR closes the pull request without merging
+func (Server) ClosePR(number int) error {
+	return editPR(number, &github.PullRequest{State: github.String("closed")})
+}
+
+func editPR(number int, pull *github.PullRequest) error {
+	ctx := context.Background()
+	gh, err := client(ctx)
+	if err != nil {
+		return err
+	}
+	if _, _, err := gh.PullRequests.Edit(ctx, *repoOwner, *repo, number, pull); err != nil {
+		return fmt.Errorf("unable to update PR %d: %w", number, err)
+	}
+	return nil
+}
+
+// AddLabels adds the labels to the pull request
+func (Server) AddLabels(number int, labels []string) error {
+	ctx := context.Background()
+	gh, err := client(ctx)
+	if err != nil {
+		return err
+	}
+	if _, _, err := gh.Issues.AddLabelsToIssue(ctx, *repoOwner, *repo, number, labels); err != nil {
+		return fmt.Errorf("unable to add labels to PR %d: %w", number, err)
+	}
+	return nil
+}
+
+// RequestReviewers requests the review of the pull request.
+// Reviewers in org/team format are requested as teams.
+func (Server) RequestReviewers(number int, reviewers []string) error {
+	ctx := context.Background()
+	gh, err := client(ctx)
+	if err != nil {
+		return err
+	}
+	var req github.ReviewersRequest
+	for _, r := range reviewers {
+		if _, team, found := strings.Cut(r, "/"); found {
+			req.TeamReviewers = append(req.TeamReviewers, team)
+		} else {
+			req.Reviewers = append(req.Reviewers, r)
+		}
+	}
+	if _, _, err := gh.PullRequests.RequestReviewers(ctx, *repoOwner, *repo, number, req); err != nil {
+		return fmt.Errorf("unable to request reviewers of PR %d: %w", number, err)
+	}
+	return nil
+}
+
+// SetDraft marks the pull request as a draft or as ready for review.
+// The REST API can't change the draft state, GraphQL API mutation is used.
+func (Server) SetDraft(number int, draft bool) error {
+	ctx := context.Background()
+	gh, err := client(ctx)
+	if err != nil {
+		return err
+	}
+	pr, _, err := gh.PullRequests.Get(ctx, *repoOwner, *repo, number)
+	if err != nil {
+		return fmt.Errorf("unable to get PR %d: %w", number, err)
+	}
+	if pr.GetDraft() == draft {
+		return nil
+	}
+	mutation := "markPullRequestReadyForReview"
+	if draft {
+		mutation = "convertPullRequestToDraft"
+	}
+	query := fmt.Sprintf("mutation($id: ID!) { %s(input: {pullRequestId: $id}) { clientMutationId } }", mutation)
+	if err := graphql(ctx, gh, query, map[string]interface{}{"id": pr.GetNodeID()}); err != nil {
+		return fmt.Errorf("unable to set draft of PR %d: %w", number, err)
+	}
+	return nil
+}
+
+// EnableAutoMerge enables the auto-merge of the pull request.
+// Auto-merge must be allowed in the repository settings and the base branch must require checks.
+func (Server) EnableAutoMerge(number int, method string) error {
+	if err := git.ValidMergeMethod(method); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	gh, err := client(ctx)
+	if err != nil {
+		return err
+	}
+	pr, _, err := gh.PullRequests.Get(ctx, *repoOwner, *repo, number)
+	if err != nil {
+		return fmt.Errorf("unable to get PR %d: %w", number, err)
+	}
+	query := "mutation($id: ID!) { enablePullRequestAutoMerge(input: {pullRequestId: $id}) { clientMutationId } }"
+	variables := map[string]interface{}{"id": pr.GetNodeID()}
+	if method != git.MergeMethodDefault {
+		query = "mutation($id: ID!, $method: PullRequestMergeMethod!) { enablePullRequestAutoMerge(input: {pullRequestId: $id, mergeMethod: $method}) { clientMutationId } }"
+		variables["method"] = strings.ToUpper(method)
+	}
+	if err := graphql(ctx, gh, query, variables); err != nil {
+		return fmt.Errorf("unable to enable auto-merge of PR %d: %w", number, err)
+	}
+	return nil
+}
+
+// graphql runs the GraphQL API query. The REST API does not provide some of the pull request operations.
+func graphql(ctx context.Context, gh *github.Client, query string, variables map[string]interface{}) error {
+	// GraphQL endpoint is /graphql for github.com and /api/graphql for enterprise
+	req, err := gh.NewRequest("POST", "../graphql", map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := gh.Do(ctx, req, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return errors.New(resp.Errors[0].Message)
+	}
+	return nil
+}
+
+// findPR looks up the open pull request from head branch into base branch.
+// Returns an error if the lookup fails or there is no such pull request.
+func findPR(ctx context.Context, gh *github.Client, head, base string) (*git.PullRequest, error) {
+	prs, _, err := gh.PullRequests.List(ctx, *repoOwner, *repo, &github.PullRequestListOptions{
+		State: "open",
+		Head:  *repoOwner + ":" + head,
+		Base:  base,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to find existing PR: %w", err)
+	}
+	if len(prs) == 0 {
+		return nil, fmt.Errorf("no open PR from %s into %s", head, base)
+	}
+	return &git.PullRequest{
+		Number: prs[0].GetNumber(),
+		URL:    prs[0].GetHTMLURL(),
+	}, nil
 }
@@ -0,0 +1,143 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package github
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/adobe/rules_gitops/gitops/git"
+	"github.com/google/go-github/v32/github"
+)
+
+// fakeAPI serves pull request 7 of an enterprise server and records the other requests.
+// Creating a pull request fails with a validation error.
+func fakeAPI(t *testing.T) *[]string {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v3/repos/owner/repo/pulls":
+			if r.URL.Query().Get("head") == "owner:deploy/test" && r.URL.Query().Get("state") == "open" {
+				fmt.Fprintln(w, `[{"number":7,"html_url":"https://github.example.com/owner/repo/pull/7"}]`)
+			} else {
+				fmt.Fprintln(w, `[]`)
+			}
+		case r.Method == "POST" && r.URL.Path == "/api/v3/repos/owner/repo/pulls":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			fmt.Fprintln(w, `{"message":"Validation Failed","errors":[{"resource":"PullRequest","code":"custom","message":"A pull request already exists for owner:deploy/test."}]}`)
+		case r.Method == "GET" && r.URL.Path == "/api/v3/repos/owner/repo/pulls/7":
+			fmt.Fprintln(w, `{"number":7,"node_id":"PR_7","draft":false}`)
+		default:
+			requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.Path, body))
+			if r.URL.Path == "/api/v3/repos/owner/repo/issues/7/labels" {
+				fmt.Fprintln(w, `[]`)
+			} else {
+				fmt.Fprintln(w, `{}`)
+			}
+		}
+	}))
+	t.Cleanup(ts.Close)
+	oldOwner, oldRepo, oldClient := *repoOwner, *repo, newClient
+	t.Cleanup(func() { *repoOwner, *repo, newClient = oldOwner, oldRepo, oldClient })
+	*repoOwner, *repo = "owner", "repo"
+	newClient = func(ctx context.Context) (*github.Client, error) {
+		gh := github.NewClient(nil)
+		gh.BaseURL, _ = url.Parse(ts.URL + "/api/v3/")
+		return gh, nil
+	}
+	return &requests
+}
+
+func TestFindPR(t *testing.T) {
+	fakeAPI(t)
+	pr, err := Server{}.FindPR("deploy/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &git.PullRequest{Number: 7, URL: "https://github.example.com/owner/repo/pull/7"}
+	if !reflect.DeepEqual(pr, expected) {
+		t.Errorf("Unexpected PR: %+v", pr)
+	}
+	pr, err = Server{}.FindPR("deploy/other")
+	if err != nil || pr != nil {
+		t.Errorf("Unexpected PR: %+v %v", pr, err)
+	}
+}
+
+func TestCreatePRExisting(t *testing.T) {
+	fakeAPI(t)
+	pr, err := Server{}.CreatePR("deploy/test", "master", "deploy", "body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &git.PullRequest{Number: 7, URL: "https://github.example.com/owner/repo/pull/7"}
+	if !reflect.DeepEqual(pr, expected) {
+		t.Errorf("Unexpected PR: %+v", pr)
+	}
+	// a validation error without an open PR, like no commits between the branches, is returned
+	pr, err = Server{}.CreatePR("deploy/other", "master", "deploy", "body")
+	if err == nil || !strings.Contains(err.Error(), "422 Validation Failed") || !strings.Contains(err.Error(), "no open PR from deploy/other into master") {
+		t.Errorf("Unexpected PR: %+v %v", pr, err)
+	}
+}
+
+func TestUpdatePR(t *testing.T) {
+	requests := fakeAPI(t)
+	s := Server{}
+	if err := s.UpdatePR(7, "deploy", "body"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddLabels(7, []string{"gitops"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RequestReviewers(7, []string{"alice", "owner/sre"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetDraft(7, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetDraft(7, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ClosePR(7); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.EnableAutoMerge(7, git.MergeMethodDefault); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.EnableAutoMerge(7, git.MergeMethodSquash); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.EnableAutoMerge(7, "fast-forward"); err == nil {
+		t.Error("Unknown merge method should fail")
+	}
+	expected := []string{
+		`PATCH /api/v3/repos/owner/repo/pulls/7 {"title":"deploy","body":"body"}` + "\n",
+		`POST /api/v3/repos/owner/repo/issues/7/labels ["gitops"]` + "\n",
+		`POST /api/v3/repos/owner/repo/pulls/7/requested_reviewers {"reviewers":["alice"],"team_reviewers":["sre"]}` + "\n",
+		`POST /api/graphql {"query":"mutation($id: ID!) { convertPullRequestToDraft(input: {pullRequestId: $id}) { clientMutationId } }","variables":{"id":"PR_7"}}` + "\n",
+		`PATCH /api/v3/repos/owner/repo/pulls/7 {"state":"closed"}` + "\n",
+		`POST /api/graphql {"query":"mutation($id: ID!) { enablePullRequestAutoMerge(input: {pullRequestId: $id}) { clientMutationId } }","variables":{"id":"PR_7"}}` + "\n",
+		`POST /api/graphql {"query":"mutation($id: ID!, $method: PullRequestMergeMethod!) { enablePullRequestAutoMerge(input: {pullRequestId: $id, mergeMethod: $method}) { clientMutationId } }","variables":{"id":"PR_7","method":"SQUASH"}}` + "\n",
+	}
+	if !reflect.DeepEqual(*requests, expected) {
+		t.Errorf("Unexpected requests: %q", *requests)
+	}
+}
@@ -3,11 +3,15 @@ package gitlab
 import (
 	"errors"
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"regexp"
+	"strings"
 
+	"github.com/adobe/rules_gitops/gitops/git"
 	"github.com/xanzy/go-gitlab"
 )
 
@@ -15,44 +19,75 @@ var (
 	gitlabHost  = flag.String("gitlab_host", "https://gitlab.com", "The host name of the gitlab instance")
 	repo        = flag.String("gitlab_repo", "", "the repo to use for gitlab api requests")
 	accessToken = flag.String("gitlab_access_token", os.Getenv("GITLAB_TOKEN"), "the access token to authenticate requests")
+
+	labels             = flag.String("gitlab_labels", "", "comma separated labels of the created merge requests")
+	assignees          = flag.String("gitlab_assignees", "", "comma separated usernames of the assignees of the created merge requests")
+	reviewers          = flag.String("gitlab_reviewers", "", "comma separated usernames of the reviewers of the created merge requests")
+	milestone          = flag.String("gitlab_milestone", "", "the title of the active project or group milestone of the created merge requests")
+	squash             = flag.Bool("gitlab_squash", false, "squash the merge request commits when merging")
+	removeSourceBranch = flag.Bool("gitlab_remove_source_branch", false, "remove the deployment branch when the merge request is merged")
 )
 
-func CreatePR(from, to, title, body string) error {
+func init() {
+	// the deprecated alias of --auto_merge, it is read by create_gitops_prs
+	flag.Bool("gitlab_merge_when_pipeline_succeeds", false, "deprecated, use --auto_merge: merge the created or reused merge request when its pipeline succeeds")
+}
+
+// Server implements git.Server using the GitLab API of the gitlab_repo project
+type Server struct{}
+
+var _ git.Server = Server{}
+
+func client() (*gitlab.Client, error) {
 	if *accessToken == "" {
-		return errors.New("gitlab_access_token must be set")
-	}
-
-	opts := gitlab.CreateMergeRequestOptions{
-		Title:              &title,
-		Description:        nil,
-		SourceBranch:       &from,
-		TargetBranch:       &to,
-		Labels:             nil,
-		AssigneeID:         nil,
-		AssigneeIDs:        nil,
-		ReviewerIDs:        nil,
-		TargetProjectID:    nil,
-		MilestoneID:        nil,
-		RemoveSourceBranch: nil,
-		Squash:             nil,
-		AllowCollaboration: nil,
-	}
-
-	gl, err := gitlab.NewClient(*accessToken, gitlab.WithBaseURL(*gitlabHost))
+		return nil, errors.New("gitlab_access_token must be set")
+	}
+	return gitlab.NewClient(*accessToken, gitlab.WithBaseURL(*gitlabHost))
+}
+
+// CreatePR creates a merge request using branch names from and to
+func CreatePR(from, to, title, body string) (*git.PullRequest, error) {
+	return Server{}.CreatePR(from, to, title, body)
+}
+
+// CreatePR creates a merge request using branch names from and to. The open merge request is reused if it already exists.
+func (Server) CreatePR(from, to, title, body string) (*git.PullRequest, error) {
+	gl, err := client()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	createdPr, resp, err := gl.MergeRequests.CreateMergeRequest(*repo, &opts)
+	opts, err := createOptions(gl)
+	if err != nil {
+		return nil, err
+	}
+	opts.Title = &title
+	opts.Description = &body
+	opts.SourceBranch = &from
+	opts.TargetBranch = &to
+
+	createdPr, resp, err := gl.MergeRequests.CreateMergeRequest(*repo, opts)
 	if err == nil {
 		log.Println("Created MR: ", createdPr.WebURL)
-		return nil
+		return &git.PullRequest{
+			Number:  createdPr.IID,
+			URL:     createdPr.WebURL,
+			Created: true,
+		}, nil
+	}
+
+	if resp == nil {
+		return nil, err
 	}
 
 	if resp.StatusCode == http.StatusConflict {
 		// Handle the case: "Create MR" request fails because it already exists for this source branch
-		log.Println("Reusing existing MR")
-		return nil
+		existing, ferr := findMR(gl, from, to)
+		if ferr != nil {
+			return nil, fmt.Errorf("%w: %v", err, ferr)
+		}
+		log.Println("Reusing existing MR: ", existing.URL)
+		return existing, nil
 	}
 
 	// All other gitlab responses
@@ -64,5 +99,260 @@ func CreatePR(from, to, title, body string) error {
 		log.Println("gitlab response: ", string(responseBody))
 	}
 
-	return err
+	return nil, err
+}
+
+// createOptions returns the merge request options set by the flags.
+// The usernames and the milestone title are resolved to ids.
+func createOptions(gl *gitlab.Client) (*gitlab.CreateMergeRequestOptions, error) {
+	opts := &gitlab.CreateMergeRequestOptions{}
+	if l := splitList(*labels); len(l) > 0 {
+		ls := gitlab.Labels(l)
+		opts.Labels = &ls
+	}
+	if names := splitList(*assignees); len(names) > 0 {
+		ids, err := userIDs(gl, names)
+		if err != nil {
+			return nil, err
+		}
+		opts.AssigneeIDs = &ids
+	}
+	if names := splitList(*reviewers); len(names) > 0 {
+		ids, err := userIDs(gl, names)
+		if err != nil {
+			return nil, err
+		}
+		opts.ReviewerIDs = &ids
+	}
+	if *milestone != "" {
+		id, err := milestoneID(gl, *milestone)
+		if err != nil {
+			return nil, err
+		}
+		opts.MilestoneID = &id
+	}
+	// unset options keep the project defaults
+	if *squash {
+		opts.Squash = squash
+	}
+	if *removeSourceBranch {
+		opts.RemoveSourceBranch = removeSourceBranch
+	}
+	return opts, nil
+}
+
+// EnableAutoMerge sets the merge request to merge when its pipeline succeeds.
+// The rebase merge method is a project setting and is not supported.
+func (Server) EnableAutoMerge(number int, method string) error {
+	if err := git.ValidMergeMethod(method); err != nil {
+		return err
+	}
+	var s *bool
+	switch method {
+	case git.MergeMethodRebase:
+		return fmt.Errorf("gitlab merge method %s: %w", method, git.ErrNotSupported)
+	case git.MergeMethodMerge:
+		s = gitlab.Bool(false)
+	case git.MergeMethodSquash:
+		s = gitlab.Bool(true)
+	}
+	gl, err := client()
+	if err != nil {
+		return err
+	}
+	return acceptWhenPipelineSucceeds(gl, number, s)
+}
+
+// acceptWhenPipelineSucceeds sets the merge request to merge when its pipeline succeeds.
+// The project squash setting applies if squashCommits is nil.
+func acceptWhenPipelineSucceeds(gl *gitlab.Client, number int, squashCommits *bool) error {
+	opts := &gitlab.AcceptMergeRequestOptions{
+		MergeWhenPipelineSucceeds: gitlab.Bool(true),
+		Squash:                    squashCommits,
+	}
+	if *removeSourceBranch {
+		opts.ShouldRemoveSourceBranch = removeSourceBranch
+	}
+	if _, _, err := gl.MergeRequests.AcceptMergeRequest(*repo, number, opts); err != nil {
+		return fmt.Errorf("unable to set MR %d to merge when pipeline succeeds: %w", number, err)
+	}
+	return nil
+}
+
+// milestoneID resolves the title of the active project or group milestone to the milestone id
+func milestoneID(gl *gitlab.Client, title string) (int, error) {
+	state := "active"
+	includeParent := true
+	milestones, _, err := gl.Milestones.ListMilestones(*repo, &gitlab.ListMilestonesOptions{
+		Title:                   &title,
+		State:                   &state,
+		IncludeParentMilestones: &includeParent,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("unable to find milestone %s: %w", title, err)
+	}
+	if len(milestones) == 0 {
+		return 0, fmt.Errorf("milestone %s not found", title)
+	}
+	return milestones[0].ID, nil
+}
+
+// splitList splits the comma separated flag value, the empty items are skipped
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// FindPR returns the open merge request from the source branch. Returns nil if there is none.
+func (Server) FindPR(from string) (*git.PullRequest, error) {
+	gl, err := client()
+	if err != nil {
+		return nil, err
+	}
+	state := "opened"
+	mrs, _, err := gl.MergeRequests.ListProjectMergeRequests(*repo, &gitlab.ListProjectMergeRequestsOptions{
+		State:        &state,
+		SourceBranch: &from,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to find MR from %s: %w", from, err)
+	}
+	if len(mrs) == 0 {
+		return nil, nil
+	}
+	return &git.PullRequest{
+		Number: mrs[0].IID,
+		URL:    mrs[0].WebURL,
+	}, nil
+}
+
+// UpdatePR replaces the title and the description of the merge request
+func (Server) UpdatePR(number int, title, body string) error {
+	return updateMR(number, &gitlab.UpdateMergeRequestOptions{Title: &title, Description: &body})
+}
+
+// AddLabels adds the labels to the merge request
+func (Server) AddLabels(number int, labels []string) error {
+	add := gitlab.Labels(labels)
+	return updateMR(number, &gitlab.UpdateMergeRequestOptions{AddLabels: &add})
+}
+
+// RequestReviewers adds the users to the reviewers of the merge request
+func (Server) RequestReviewers(number int, reviewers []string) error {
+	gl, err := client()
+	if err != nil {
+		return err
+	}
+	mr, _, err := gl.MergeRequests.GetMergeRequest(*repo, number, nil)
+	if err != nil {
+		return fmt.Errorf("unable to get MR %d: %w", number, err)
+	}
+	var ids []int
+	known := make(map[int]bool)
+	for _, u := range mr.Reviewers {
+		ids = append(ids, u.ID)
+		known[u.ID] = true
+	}
+	for _, name := range reviewers {
+		id, err := userID(gl, name)
+		if err != nil {
+			return err
+		}
+		if !known[id] {
+			ids = append(ids, id)
+			known[id] = true
+		}
+	}
+	return updateMR(number, &gitlab.UpdateMergeRequestOptions{ReviewerIDs: &ids})
+}
+
+// userIDs resolves the usernames to the user ids
+func userIDs(gl *gitlab.Client, usernames []string) ([]int, error) {
+	ids := make([]int, 0, len(usernames))
+	for _, name := range usernames {
+		id, err := userID(gl, name)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// userID resolves the username to the user id
+func userID(gl *gitlab.Client, username string) (int, error) {
+	users, _, err := gl.Users.ListUsers(&gitlab.ListUsersOptions{Username: &username})
+	if err != nil {
+		return 0, fmt.Errorf("unable to find user %s: %w", username, err)
+	}
+	if len(users) == 0 {
+		return 0, fmt.Errorf("user %s not found", username)
+	}
+	return users[0].ID, nil
+}
+
+// SetDraft marks the merge request as a draft or as ready using the "Draft:" title prefix
+func (Server) SetDraft(number int, draft bool) error {
+	gl, err := client()
+	if err != nil {
+		return err
+	}
+	mr, _, err := gl.MergeRequests.GetMergeRequest(*repo, number, nil)
+	if err != nil {
+		return fmt.Errorf("unable to get MR %d: %w", number, err)
+	}
+	if mr.Draft == draft {
+		return nil
+	}
+	title := draftPrefix.ReplaceAllString(mr.Title, "")
+	if draft {
+		title = "Draft: " + title
+	}
+	return updateMR(number, &gitlab.UpdateMergeRequestOptions{Title: &title})
+}
+
+// draftPrefix matches the title prefixes marking the merge request as a draft
+var draftPrefix = regexp.MustCompile(`^(?i)(\[draft\]|\(draft\)|draft:|\[wip\]|wip:)\s*`)
+
+// ClosePR closes the merge request without merging
+func (Server) ClosePR(number int) error {
+	state := "close"
+	return updateMR(number, &gitlab.UpdateMergeRequestOptions{StateEvent: &state})
+}
+
+func updateMR(number int, opts *gitlab.UpdateMergeRequestOptions) error {
+	gl, err := client()
+	if err != nil {
+		return err
+	}
+	if _, _, err := gl.MergeRequests.UpdateMergeRequest(*repo, number, opts); err != nil {
+		return fmt.Errorf("unable to update MR %d: %w", number, err)
+	}
+	return nil
+}
+
+// findMR looks up the opened merge request from source branch into target branch.
+// Returns an error if the lookup fails or there is no such merge request.
+func findMR(gl *gitlab.Client, from, to string) (*git.PullRequest, error) {
+	state := "opened"
+	mrs, _, err := gl.MergeRequests.ListProjectMergeRequests(*repo, &gitlab.ListProjectMergeRequestsOptions{
+		State:        &state,
+		SourceBranch: &from,
+		TargetBranch: &to,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to find existing MR: %w", err)
+	}
+	if len(mrs) == 0 {
+		return nil, fmt.Errorf("no opened MR from %s into %s", from, to)
+	}
+	return &git.PullRequest{
+		Number: mrs[0].IID,
+		URL:    mrs[0].WebURL,
+	}, nil
 }
@@ -1,6 +1,18 @@
 package gitlab
 
-import "testing"
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/adobe/rules_gitops/gitops/git"
+)
 
 func TestCreatePRRemote(t *testing.T) {
 	t.Skip("Manual")
@@ -52,9 +64,168 @@ func TestCreatePRRemote(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.repo, func(t *testing.T) {
 			repo = &tt.repo
-			if err := CreatePR(tt.args.from, tt.args.to, tt.args.title, tt.args.body); (err != nil) != tt.wantErr {
+			if _, err := CreatePR(tt.args.from, tt.args.to, tt.args.title, tt.args.body); (err != nil) != tt.wantErr {
 				t.Errorf("CreatePR() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+// fakeAPI serves merge request 7 of group/project and records the updates
+func fakeAPI(t *testing.T) *[]string {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == "GET" && r.URL.EscapedPath() == "/api/v4/projects/group%2Fproject/merge_requests":
+			if r.URL.Query().Get("source_branch") == "deploy/test" && r.URL.Query().Get("state") == "opened" {
+				fmt.Fprintln(w, `[{"iid":7,"web_url":"https://gitlab.example.com/group/project/-/merge_requests/7"}]`)
+			} else {
+				fmt.Fprintln(w, `[]`)
+			}
+		case r.Method == "GET" && r.URL.EscapedPath() == "/api/v4/projects/group%2Fproject/merge_requests/7":
+			fmt.Fprintln(w, `{"iid":7,"title":"Draft: deploy","draft":true,"reviewers":[{"id":1}]}`)
+		case r.Method == "GET" && r.URL.Path == "/api/v4/users":
+			fmt.Fprintf(w, `[{"id":%d}]`, len(r.URL.Query().Get("username")))
+		case r.Method == "GET" && r.URL.EscapedPath() == "/api/v4/projects/group%2Fproject/milestones":
+			if r.URL.Query().Get("title") == "v1" && r.URL.Query().Get("include_parent_milestones") == "true" {
+				fmt.Fprintln(w, `[{"id":11,"title":"v1"}]`)
+			} else {
+				fmt.Fprintln(w, `[]`)
+			}
+		case r.Method == "POST" && (strings.Contains(string(body), `"source_branch":"deploy/test"`) || strings.Contains(string(body), `"source_branch":"deploy/merged"`)):
+			// the merge request already exists
+			requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.EscapedPath(), body))
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprintln(w, `{"message":["Another open merge request already exists for this source branch: !7"]}`)
+		case r.Method != "GET":
+			requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.EscapedPath(), body))
+			fmt.Fprintln(w, `{"iid":7}`)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	oldHost, oldRepo, oldToken := *gitlabHost, *repo, *accessToken
+	t.Cleanup(func() { *gitlabHost, *repo, *accessToken = oldHost, oldRepo, oldToken })
+	*gitlabHost, *repo, *accessToken = ts.URL, "group/project", "token"
+	return &requests
+}
+
+func TestFindPR(t *testing.T) {
+	fakeAPI(t)
+	pr, err := Server{}.FindPR("deploy/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &git.PullRequest{Number: 7, URL: "https://gitlab.example.com/group/project/-/merge_requests/7"}
+	if !reflect.DeepEqual(pr, expected) {
+		t.Errorf("Unexpected PR: %+v", pr)
+	}
+	pr, err = Server{}.FindPR("deploy/other")
+	if err != nil || pr != nil {
+		t.Errorf("Unexpected PR: %+v %v", pr, err)
+	}
+}
+
+func TestUpdatePR(t *testing.T) {
+	requests := fakeAPI(t)
+	s := Server{}
+	if err := s.UpdatePR(7, "deploy", "body"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddLabels(7, []string{"gitops", "prod"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RequestReviewers(7, []string{"bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetDraft(7, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetDraft(7, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ClosePR(7); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.EnableAutoMerge(7, git.MergeMethodSquash); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.EnableAutoMerge(7, git.MergeMethodRebase); !errors.Is(err, git.ErrNotSupported) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	mr := "/api/v4/projects/group%2Fproject/merge_requests/7"
+	expected := []string{
+		"PUT " + mr + ` {"title":"deploy","description":"body"}`,
+		"PUT " + mr + ` {"add_labels":"gitops,prod"}`,
+		"PUT " + mr + ` {"reviewer_ids":[1,3]}`,
+		"PUT " + mr + ` {"title":"deploy"}`,
+		"PUT " + mr + ` {"state_event":"close"}`,
+		"PUT " + mr + `/merge {"squash":true,"merge_when_pipeline_succeeds":true}`,
+	}
+	if !reflect.DeepEqual(*requests, expected) {
+		t.Errorf("Unexpected requests: %q", *requests)
+	}
+}
+
+func TestCreatePR(t *testing.T) {
+	requests := fakeAPI(t)
+	flags := []*string{labels, assignees, reviewers, milestone}
+	bools := []*bool{squash, removeSourceBranch}
+	oldFlags := []string{*labels, *assignees, *reviewers, *milestone}
+	oldBools := []bool{*squash, *removeSourceBranch}
+	t.Cleanup(func() {
+		for i, f := range flags {
+			*f = oldFlags[i]
+		}
+		for i, f := range bools {
+			*f = oldBools[i]
+		}
+	})
+
+	pr, err := Server{}.CreatePR("deploy/new", "master", "deploy", "body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pr, &git.PullRequest{Number: 7, Created: true}) {
+		t.Errorf("Unexpected PR: %+v", pr)
+	}
+
+	*labels, *assignees, *reviewers, *milestone = "gitops, prod", "al", "bob,carol", "v1"
+	*squash, *removeSourceBranch = true, true
+	// the deprecated flag enables --auto_merge, CreatePR does not set the merge when pipeline succeeds
+	mwps := flag.Lookup("gitlab_merge_when_pipeline_succeeds")
+	t.Cleanup(func() { mwps.Value.Set(mwps.DefValue) })
+	if err := mwps.Value.Set("true"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (Server{}).CreatePR("deploy/new", "master", "deploy", "body"); err != nil {
+		t.Fatal(err)
+	}
+	pr, err = Server{}.CreatePR("deploy/test", "master", "deploy", "body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pr, &git.PullRequest{Number: 7, URL: "https://gitlab.example.com/group/project/-/merge_requests/7"}) {
+		t.Errorf("Unexpected PR: %+v", pr)
+	}
+	mrs := "/api/v4/projects/group%2Fproject/merge_requests"
+	expected := []string{
+		"POST " + mrs + ` {"title":"deploy","description":"body","source_branch":"deploy/new","target_branch":"master"}`,
+		"POST " + mrs + ` {"title":"deploy","description":"body","source_branch":"deploy/new","target_branch":"master","labels":"gitops,prod","assignee_ids":[2],"reviewer_ids":[3,5],"milestone_id":11,"remove_source_branch":true,"squash":true}`,
+		"POST " + mrs + ` {"title":"deploy","description":"body","source_branch":"deploy/test","target_branch":"master","labels":"gitops,prod","assignee_ids":[2],"reviewer_ids":[3,5],"milestone_id":11,"remove_source_branch":true,"squash":true}`,
+	}
+	if !reflect.DeepEqual(*requests, expected) {
+		t.Errorf("Unexpected requests:\n%s", strings.Join(*requests, "\n"))
+	}
+
+	// a conflict without an opened MR is returned
+	if pr, err := (Server{}).CreatePR("deploy/merged", "master", "deploy", "body"); err == nil || !strings.Contains(err.Error(), "no opened MR from deploy/merged into master") {
+		t.Errorf("Unexpected PR: %+v %v", pr, err)
+	}
+
+	*milestone = "v2"
+	if _, err := (Server{}).CreatePR("deploy/new", "master", "deploy", "body"); err == nil || err.Error() != "milestone v2 not found" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
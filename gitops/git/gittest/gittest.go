@@ -0,0 +1,883 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+// Package gittest provides the conformance test suite for git.Repo implementations.
+package gittest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	oe "os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/adobe/rules_gitops/gitops/commitmsg"
+	"github.com/adobe/rules_gitops/gitops/git"
+)
+
+// CloneFunc clones repo into dir, see git.Clone
+type CloneFunc func(repo, dir, mirrorDir, primaryBranch, gitopsPath string) (git.Repo, error)
+
+// Origin is a local bare repository with master and deploy/existing branches.
+// master contains cloud/app.yaml and other/readme.txt,
+// deploy/existing has an additional commit of cloud/existing.yaml with the //app:existing target.
+type Origin struct {
+	Dir string
+}
+
+// NewOrigin creates the Origin repository in a temporary directory
+func NewOrigin(t *testing.T) *Origin {
+	t.Helper()
+	dir := t.TempDir()
+	work := filepath.Join(dir, "seed")
+	r, err := gogit.PlainInit(work, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := func(msg string, files map[string]string) {
+		for name, content := range files {
+			writeFile(t, filepath.Join(work, name), content)
+			if _, err := w.Add(name); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := w.Commit(msg, &gogit.CommitOptions{Author: signature()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commit("initial", map[string]string{"cloud/app.yaml": "app: v1\n", "other/readme.txt": "readme\n"})
+	head, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("master"), head.Hash())); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Checkout(&gogit.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("deploy/existing"), Create: true}); err != nil {
+		t.Fatal(err)
+	}
+	commit("GitOps\n"+commitmsg.Generate([]string{"//app:existing"}), map[string]string{"cloud/existing.yaml": "existing: v1\n"})
+
+	// copy objects and branches without transport, which may require git binary
+	bare := filepath.Join(dir, "origin.git")
+	o, err := gogit.PlainInit(bare, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects, err := r.Storer.IterEncodedObjects(plumbing.AnyObject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = objects.ForEach(func(obj plumbing.EncodedObject) error {
+		_, err := o.Storer.SetEncodedObject(obj)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range []string{"master", "deploy/existing"} {
+		ref, err := r.Reference(plumbing.NewBranchReferenceName(b), true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.Storer.SetReference(ref); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &Origin{Dir: bare}
+}
+
+// Branch returns the commit of the origin branch, or nil if branch does not exist
+func (o *Origin) Branch(t *testing.T, branch string) *object.Commit {
+	t.Helper()
+	// open every time to see the objects pushed since
+	repo := o.open(t)
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if err == plumbing.ErrReferenceNotFound {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// DeleteBranch deletes the origin branch
+func (o *Origin) DeleteBranch(t *testing.T, branch string) {
+	t.Helper()
+	if err := o.open(t).Storer.RemoveReference(plumbing.NewBranchReferenceName(branch)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// ServeHTTP serves the origin repository over the smart HTTP protocol using git http-backend.
+// Requests must use basic authentication accepted by the auth function. Returns the repository URL.
+func (o *Origin) ServeHTTP(t *testing.T, auth func(username, password string) bool) string {
+	t.Helper()
+	backend := &cgi.Handler{
+		Path: "git",
+		Args: []string{"http-backend"},
+		Env:  []string{"GIT_PROJECT_ROOT=" + filepath.Dir(o.Dir), "GIT_HTTP_EXPORT_ALL=1"},
+	}
+	if p, err := oe.LookPath("git"); err == nil {
+		backend.Path = p
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || !auth(username, password) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="origin"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		// http-backend allows push for authenticated users only
+		h := *backend
+		h.Env = append(h.Env, "REMOTE_USER="+username)
+		h.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	return ts.URL + "/" + filepath.Base(o.Dir)
+}
+
+func (o *Origin) open(t *testing.T) *gogit.Repository {
+	t.Helper()
+	r, err := gogit.PlainOpen(o.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// TestRepo runs the conformance tests for the git.Repo implementation created by clone
+func TestRepo(t *testing.T, clone CloneFunc) {
+	t.Setenv("GIT_AUTHOR_NAME", "gittest")
+	t.Setenv("GIT_AUTHOR_EMAIL", "gittest@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "gittest")
+	t.Setenv("GIT_COMMITTER_EMAIL", "gittest@example.com")
+
+	t.Run("Clone", func(t *testing.T) {
+		origin := NewOrigin(t)
+		dir := filepath.Join(t.TempDir(), "repo")
+		r, err := clone(origin.Dir, dir, "", "master", "cloud")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.WorkDir() != dir {
+			t.Errorf("unexpected WorkDir %s", r.WorkDir())
+		}
+		if r.Remote() != "origin" {
+			t.Errorf("unexpected Remote %s", r.Remote())
+		}
+		if got := readFile(t, filepath.Join(dir, "cloud/app.yaml")); got != "app: v1\n" {
+			t.Errorf("unexpected cloud/app.yaml content %q", got)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "other/readme.txt")); !os.IsNotExist(err) {
+			t.Errorf("other/readme.txt should not be checked out: %v", err)
+		}
+		assertClean(t, r, true)
+		if err := r.Clean(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("repo dir should be removed: %v", err)
+		}
+	})
+
+	t.Run("CloneMirror", func(t *testing.T) {
+		origin := NewOrigin(t)
+		mirror := NewOrigin(t)
+		dir := filepath.Join(t.TempDir(), "repo")
+		r, err := clone(origin.Dir, dir, mirror.Dir, "master", "cloud")
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(dir, "cloud/app.yaml"), "app: v2\n")
+		commit(t, r, "update")
+		push(t, r, git.PushForce, map[string]string{"master": git.PushOK})
+		if c := origin.Branch(t, "master"); c == nil || strings.TrimSpace(c.Message) != "update" {
+			t.Errorf("push should go to the repo, not the mirror: %v", c)
+		}
+		if c := mirror.Branch(t, "master"); c == nil || strings.TrimSpace(c.Message) != "initial" {
+			t.Errorf("mirror should not be modified: %v", c)
+		}
+	})
+
+	t.Run("CloneError", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "repo")
+		if _, err := clone(filepath.Join(t.TempDir(), "missing.git"), dir, "", "master", "cloud"); err == nil {
+			t.Error("clone of missing repo should fail")
+		}
+	})
+
+	t.Run("Branches", func(t *testing.T) {
+		origin := NewOrigin(t)
+		dir := filepath.Join(t.TempDir(), "repo")
+		r, err := clone(origin.Dir, dir, "", "master", "cloud")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Fetch("deploy/*"); err != nil {
+			t.Fatal(err)
+		}
+
+		isNew, err := r.SwitchToBranch("deploy/existing", "master")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isNew {
+			t.Error("deploy/existing should not be new")
+		}
+		msg, err := r.GetLastCommitMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if targets := commitmsg.ExtractTargets(msg); !reflect.DeepEqual(targets, []string{"//app:existing"}) {
+			t.Errorf("unexpected targets %v in %q", targets, msg)
+		}
+		files, err := r.GetLastCommitFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(files, []string{"cloud/existing.yaml"}) {
+			t.Errorf("unexpected last commit files %v", files)
+		}
+
+		if err := r.RecreateBranch("deploy/existing", "master"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "cloud/existing.yaml")); !os.IsNotExist(err) {
+			t.Errorf("cloud/existing.yaml should be removed by RecreateBranch: %v", err)
+		}
+
+		isNew, err = r.SwitchToBranch("deploy/new", "master")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !isNew {
+			t.Error("deploy/new should be new")
+		}
+		if got := readFile(t, filepath.Join(dir, "cloud/app.yaml")); got != "app: v1\n" {
+			t.Errorf("unexpected cloud/app.yaml content %q", got)
+		}
+	})
+
+	t.Run("CommitAndPush", func(t *testing.T) {
+		origin := NewOrigin(t)
+		dir := filepath.Join(t.TempDir(), "repo")
+		r, err := clone(origin.Dir, dir, "", "master", "cloud")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Fetch("deploy/*"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.SwitchToBranch("deploy/new", "master"); err != nil {
+			t.Fatal(err)
+		}
+
+		// restore a modified file
+		writeFile(t, filepath.Join(dir, "cloud/app.yaml"), "app: v2\n")
+		files, err := r.GetChangedFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(files, []string{"cloud/app.yaml"}) {
+			t.Errorf("unexpected changed files %v", files)
+		}
+		if err := r.RestoreFile(filepath.Join(dir, "cloud/app.yaml")); err != nil {
+			t.Fatal(err)
+		}
+		assertClean(t, r, true)
+
+		changed, err := r.Commit("nothing", "cloud")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed {
+			t.Error("Commit without changes should return false")
+		}
+
+		writeFile(t, filepath.Join(dir, "cloud/app.yaml"), "app: v2\n")
+		writeFile(t, filepath.Join(dir, "cloud/new/new.yaml"), "new: v1\n")
+		assertClean(t, r, false)
+		commit(t, r, "deploy")
+		assertClean(t, r, true)
+		files, err = r.GetLastCommitFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(files, []string{"cloud/app.yaml", "cloud/new/new.yaml"}) {
+			t.Errorf("unexpected last commit files %v", files)
+		}
+
+		if err := os.Remove(filepath.Join(dir, "cloud/new/new.yaml")); err != nil {
+			t.Fatal(err)
+		}
+		commit(t, r, "remove")
+
+		push(t, r, git.PushForce, map[string]string{"deploy/new": git.PushOK})
+		push(t, r, git.PushForce, map[string]string{"deploy/new": git.PushUpToDate})
+		c := origin.Branch(t, "deploy/new")
+		if c == nil {
+			t.Fatal("deploy/new was not pushed")
+		}
+		if strings.TrimSpace(c.Message) != "remove" {
+			t.Errorf("unexpected pushed commit %q", c.Message)
+		}
+		assertTree(t, c, map[string]string{"cloud/app.yaml": "app: v2\n", "other/readme.txt": "readme\n"})
+
+		// force push over the remote branch
+		if err := r.RecreateBranch("deploy/existing", "master"); err != nil {
+			t.Fatal(err)
+		}
+		push(t, r, git.PushForce, map[string]string{"deploy/existing": git.PushOK})
+		if c := origin.Branch(t, "deploy/existing"); c == nil || c.Hash != origin.Branch(t, "master").Hash {
+			t.Errorf("deploy/existing should be reset to master: %v", c)
+		}
+	})
+
+	t.Run("PushError", func(t *testing.T) {
+		origin := NewOrigin(t)
+		dir := filepath.Join(t.TempDir(), "repo")
+		r, err := clone(origin.Dir, dir, "", "master", "cloud")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.RemoveAll(origin.Dir); err != nil {
+			t.Fatal(err)
+		}
+		results, err := r.Push([]string{"master"}, git.PushForce)
+		if err == nil || errors.Is(err, git.ErrStaleLease) {
+			t.Errorf("unexpected error pushing to missing remote: %v", err)
+		}
+		if len(results) != 1 || results[0].Status != git.PushFailed {
+			t.Errorf("unexpected push results %+v", results)
+		}
+	})
+
+	t.Run("PushWithLease", func(t *testing.T) {
+		origin := NewOrigin(t)
+		clones := make([]git.Repo, 2)
+		for i := range clones {
+			r, err := clone(origin.Dir, filepath.Join(t.TempDir(), "repo"), "", "master", "cloud")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := r.Fetch("deploy/*"); err != nil {
+				t.Fatal(err)
+			}
+			for _, b := range []string{"deploy/existing", "deploy/new"} {
+				if _, err := r.SwitchToBranch(b, "master"); err != nil {
+					t.Fatal(err)
+				}
+				writeFile(t, filepath.Join(r.WorkDir(), "cloud/app.yaml"), fmt.Sprintf("app: clone%d\n", i))
+				commit(t, r, fmt.Sprintf("clone%d", i))
+			}
+			clones[i] = r
+		}
+		first, second := clones[0], clones[1]
+		push(t, first, git.PushForceWithLease, map[string]string{"deploy/existing": git.PushOK, "deploy/new": git.PushOK})
+
+		results, err := second.Push([]string{"deploy/existing", "deploy/new"}, git.PushForceWithLease)
+		if !errors.Is(err, git.ErrStaleLease) {
+			t.Errorf("expected ErrStaleLease, got %v", err)
+		}
+		assertPushResults(t, results, map[string]string{"deploy/existing": git.PushRejected, "deploy/new": git.PushRejected})
+		for _, b := range []string{"deploy/existing", "deploy/new"} {
+			if c := origin.Branch(t, b); c == nil || strings.TrimSpace(c.Message) != "clone0" {
+				t.Errorf("%s should not be overwritten: %v", b, c)
+			}
+		}
+
+		// retry on top of the fetched remote branch
+		if err := second.Fetch("deploy/*"); err != nil {
+			t.Fatal(err)
+		}
+		if err := second.DeleteBranch("deploy/existing"); err != nil {
+			t.Fatal(err)
+		}
+		isNew, err := second.SwitchToBranch("deploy/existing", "master")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isNew {
+			t.Error("deploy/existing should be checked out from the remote branch")
+		}
+		if got := readFile(t, filepath.Join(second.WorkDir(), "cloud/app.yaml")); got != "app: clone0\n" {
+			t.Errorf("unexpected cloud/app.yaml content %q", got)
+		}
+		writeFile(t, filepath.Join(second.WorkDir(), "cloud/app.yaml"), "app: clone1\n")
+		commit(t, second, "clone1 retry")
+		push(t, second, git.PushForceWithLease, map[string]string{"deploy/existing": git.PushOK})
+		if c := origin.Branch(t, "deploy/existing"); c == nil || strings.TrimSpace(c.Message) != "clone1 retry" {
+			t.Errorf("deploy/existing should be updated: %v", c)
+		}
+	})
+
+	t.Run("Worktrees", func(t *testing.T) {
+		origin := NewOrigin(t)
+		dir := filepath.Join(t.TempDir(), "repo")
+		r, err := clone(origin.Dir, dir, "", "master", "cloud")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Fetch("deploy/*"); err != nil {
+			t.Fatal(err)
+		}
+		branches := []string{"deploy/existing", "deploy/new"}
+		worktrees := make([]git.Repo, len(branches))
+		for i := range worktrees {
+			wt, err := r.Worktree(filepath.Join(t.TempDir(), "worktree"), "master")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readFile(t, filepath.Join(wt.WorkDir(), "cloud/app.yaml")); got != "app: v1\n" {
+				t.Errorf("unexpected worktree cloud/app.yaml content %q", got)
+			}
+			if _, err := os.Stat(filepath.Join(wt.WorkDir(), "other/readme.txt")); !os.IsNotExist(err) {
+				t.Errorf("other/readme.txt should not be checked out in the worktree: %v", err)
+			}
+			worktrees[i] = wt
+		}
+
+		// worktrees update different branches concurrently
+		errs := make(chan error, len(worktrees))
+		for i := range worktrees {
+			go func(wt git.Repo, branch string) {
+				errs <- func() error {
+					if _, err := wt.SwitchToBranch(branch, "master"); err != nil {
+						return err
+					}
+					// the primary branch is checked out in the repository
+					if err := wt.RecreateBranch(branch, "master"); err != nil {
+						return err
+					}
+					if err := os.WriteFile(filepath.Join(wt.WorkDir(), "cloud/app.yaml"), []byte(branch+"\n"), 0644); err != nil {
+						return err
+					}
+					changed, err := wt.Commit(branch, "cloud")
+					if err == nil && !changed {
+						err = fmt.Errorf("%s: nothing committed", branch)
+					}
+					return err
+				}()
+			}(worktrees[i], branches[i])
+		}
+		for range worktrees {
+			if err := <-errs; err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, wt := range worktrees {
+			if err := wt.Clean(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(wt.WorkDir()); !os.IsNotExist(err) {
+				t.Errorf("worktree %s should be removed: %v", wt.WorkDir(), err)
+			}
+		}
+		assertClean(t, r, true)
+		if got := readFile(t, filepath.Join(dir, "cloud/app.yaml")); got != "app: v1\n" {
+			t.Errorf("unexpected cloud/app.yaml content %q", got)
+		}
+
+		push(t, r, git.PushForce, map[string]string{"deploy/existing": git.PushOK, "deploy/new": git.PushOK})
+		for _, b := range branches {
+			c := origin.Branch(t, b)
+			if c == nil {
+				t.Fatalf("%s was not pushed", b)
+			}
+			assertTree(t, c, map[string]string{"cloud/app.yaml": b + "\n", "other/readme.txt": "readme\n"})
+		}
+	})
+
+	t.Run("FetchPrune", func(t *testing.T) {
+		origin := NewOrigin(t)
+		r, err := clone(origin.Dir, filepath.Join(t.TempDir(), "repo"), "", "master", "cloud")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Fetch("deploy/*"); err != nil {
+			t.Fatal(err)
+		}
+		origin.DeleteBranch(t, "deploy/existing")
+		if err := r.Fetch("deploy/*"); err != nil {
+			t.Fatal(err)
+		}
+		isNew, err := r.SwitchToBranch("deploy/existing", "master")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !isNew {
+			t.Error("deploy/existing deleted in the remote repository should be created")
+		}
+	})
+
+	t.Run("RemoteBranches", func(t *testing.T) {
+		origin := NewOrigin(t)
+		dir := filepath.Join(t.TempDir(), "repo")
+		r, err := clone(origin.Dir, dir, "", "master", "cloud")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Fetch("deploy/*"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.SwitchToBranch("deploy/new", "master"); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(dir, "cloud/app.yaml"), "app: v2\n")
+		commit(t, r, "update")
+		push(t, r, git.PushForce, map[string]string{"deploy/new": git.PushOK})
+
+		branches, err := r.RemoteBranches("deploy/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, b := range branches {
+			names = append(names, b.Name)
+			if time.Since(b.Committed) > time.Hour || time.Until(b.Committed) > time.Minute {
+				t.Errorf("unexpected commit time of %s: %v", b.Name, b.Committed)
+			}
+		}
+		if !reflect.DeepEqual(names, []string{"deploy/existing", "deploy/new"}) {
+			t.Errorf("unexpected remote branches %v", names)
+		}
+		if branches, err := r.RemoteBranches("deploy/n"); err != nil || len(branches) != 1 || branches[0].Name != "deploy/new" {
+			t.Errorf("unexpected remote branches with deploy/n prefix %+v: %v", branches, err)
+		}
+
+		if err := r.DeleteRemoteBranch("deploy/existing"); err != nil {
+			t.Fatal(err)
+		}
+		if origin.Branch(t, "deploy/existing") != nil {
+			t.Error("deploy/existing should be deleted in the remote repository")
+		}
+		if branches, err := r.RemoteBranches("deploy/"); err != nil || len(branches) != 1 || branches[0].Name != "deploy/new" {
+			t.Errorf("unexpected remote branches after delete %+v: %v", branches, err)
+		}
+		if err := r.DeleteRemoteBranch("deploy/missing"); err == nil {
+			t.Error("delete of missing branch should fail")
+		}
+	})
+
+	t.Run("Log", func(t *testing.T) {
+		origin := NewOrigin(t)
+		dir := filepath.Join(t.TempDir(), "repo")
+		r, err := clone(origin.Dir, dir, "", "master", "cloud")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Fetch("deploy/*"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.SwitchToBranch("deploy/new", "master"); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(dir, "cloud/app.yaml"), "app: v2\n")
+		commit(t, r, "update app")
+		writeFile(t, filepath.Join(dir, "cloud/new/new.yaml"), "new: v1\n")
+		commit(t, r, "add new\n\ndetails")
+
+		for _, tc := range []struct {
+			revision, path string
+			max            int
+			expected       []string
+		}{
+			{"deploy/new", "cloud", 0, []string{"add new\n\ndetails", "update app", "initial"}},
+			{"deploy/new", "cloud", 2, []string{"add new\n\ndetails", "update app"}},
+			{"deploy/new", "cloud/new", 0, []string{"add new\n\ndetails"}},
+			{"deploy/new", "other", 0, []string{"initial"}},
+			{"master", "", 0, []string{"initial"}},
+			{"deploy/new", "missing", 0, nil},
+		} {
+			commits, err := r.Log(tc.revision, tc.path, tc.max)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var messages []string
+			for _, c := range commits {
+				messages = append(messages, c.Message)
+				if time.Since(c.Committed) > time.Hour {
+					t.Errorf("unexpected commit time of %s: %v", c.Hash, c.Committed)
+				}
+			}
+			if !reflect.DeepEqual(messages, tc.expected) {
+				t.Errorf("unexpected log of %s in %s: %q", tc.path, tc.revision, messages)
+			}
+		}
+		commits, err := r.Log(r.Remote()+"/deploy/existing", "cloud", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(commits) != 2 || commits[0].Hash != origin.Branch(t, "deploy/existing").Hash.String() {
+			t.Errorf("unexpected log of deploy/existing: %+v", commits)
+		} else if !reflect.DeepEqual(commits[0].Parents, []string{commits[1].Hash}) || len(commits[1].Parents) != 0 {
+			t.Errorf("unexpected parents of deploy/existing commits: %+v", commits)
+		} else if commits[1].Author != "gittest <gittest@example.com>" {
+			t.Errorf("unexpected author of deploy/existing commit: %q", commits[1].Author)
+		}
+		if _, err := r.Log("deploy/missing", "cloud", 0); err == nil {
+			t.Error("log of missing branch should fail")
+		}
+	})
+
+	t.Run("DiffAndReadFile", func(t *testing.T) {
+		origin := NewOrigin(t)
+		dir := filepath.Join(t.TempDir(), "repo")
+		r, err := clone(origin.Dir, dir, "", "master", "cloud")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Fetch("deploy/*"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.SwitchToBranch("deploy/new", "master"); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(dir, "cloud/app.yaml"), "app: v2\n")
+		writeFile(t, filepath.Join(dir, "cloud/new/new.yaml"), "new: v1\n")
+		commit(t, r, "update")
+
+		remote := r.Remote() + "/deploy/existing"
+		for _, tc := range []struct {
+			from, to, path string
+			expected       []git.FileChange
+		}{
+			{"master", "deploy/new", "cloud", []git.FileChange{
+				{Path: "cloud/app.yaml", Status: git.FileModified},
+				{Path: "cloud/new/new.yaml", Status: git.FileAdded},
+			}},
+			{"master", "deploy/new", "cloud/new", []git.FileChange{{Path: "cloud/new/new.yaml", Status: git.FileAdded}}},
+			{"master", "deploy/new", "other", nil},
+			{remote, "master", "", []git.FileChange{{Path: "cloud/existing.yaml", Status: git.FileDeleted}}},
+		} {
+			changes, err := r.Diff(tc.from, tc.to, tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(changes, tc.expected) {
+				t.Errorf("unexpected diff of %s from %s to %s: %+v", tc.path, tc.from, tc.to, changes)
+			}
+		}
+		if _, err := r.Diff("master", "deploy/missing", "cloud"); err == nil {
+			t.Error("diff of missing branch should fail")
+		}
+
+		for rev, expected := range map[string]string{"master": "app: v1\n", "deploy/new": "app: v2\n"} {
+			b, err := r.ReadFile(rev, "cloud/app.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != expected {
+				t.Errorf("unexpected cloud/app.yaml content %q in %s", b, rev)
+			}
+		}
+		if b, err := r.ReadFile(remote, "cloud/existing.yaml"); err != nil || string(b) != "existing: v1\n" {
+			t.Errorf("unexpected cloud/existing.yaml content %q in %s: %v", b, remote, err)
+		}
+		if _, err := r.ReadFile("master", "cloud/existing.yaml"); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("missing file should fail with os.ErrNotExist: %v", err)
+		}
+		if _, err := r.ReadFile("deploy/missing", "cloud/app.yaml"); err == nil || errors.Is(err, os.ErrNotExist) {
+			t.Errorf("missing revision should fail: %v", err)
+		}
+	})
+}
+
+// CredentialsCloneFunc clones repo into dir authenticating with creds
+type CredentialsCloneFunc func(repo, dir string, creds git.Credentials) (git.Repo, error)
+
+// TestCredentials tests the git.Repo implementation authenticating to the HTTP remote with git.Credentials.
+// The credentials must be requested for every remote access. Requires git http-backend.
+func TestCredentials(t *testing.T, clone CredentialsCloneFunc) {
+	t.Setenv("GIT_AUTHOR_NAME", "gittest")
+	t.Setenv("GIT_AUTHOR_EMAIL", "gittest@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "gittest")
+	t.Setenv("GIT_COMMITTER_EMAIL", "gittest@example.com")
+	// the helpers of the user must not answer instead of the credentials
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_TERMINAL_PROMPT", "0")
+
+	origin := NewOrigin(t)
+	// every token is valid once, like the expiring tokens refreshed by the credentials
+	var mu sync.Mutex
+	issued, used := 0, map[string]bool{}
+	url := origin.ServeHTTP(t, func(username, password string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		var n int
+		if _, err := fmt.Sscanf(password, "token%d", &n); err != nil || n < 1 || n > issued {
+			return false
+		}
+		// go-git and git could retry the request with the same token
+		used[password] = true
+		return username == "x-access-token"
+	})
+	creds := func() (string, string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		issued++
+		return "x-access-token", fmt.Sprintf("token%d", issued), nil
+	}
+
+	_, err := clone(url, filepath.Join(t.TempDir(), "denied"), func() (string, string, error) {
+		return "x-access-token", "invalid", nil
+	})
+	if err == nil {
+		t.Error("clone with invalid credentials should fail")
+	}
+	_, err = clone(url, filepath.Join(t.TempDir(), "failed"), func() (string, string, error) {
+		return "", "", errors.New("no token")
+	})
+	if err == nil {
+		t.Error("clone should fail if the credentials are not available")
+	}
+
+	dir := filepath.Join(t.TempDir(), "repo")
+	r, err := clone(url, dir, creds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Clean()
+	if err := r.Fetch("deploy/*"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.SwitchToBranch("deploy/new", "master"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "cloud/new.yaml"), "new: v1\n")
+	commit(t, r, "new")
+	push(t, r, git.PushForce, map[string]string{"deploy/new": git.PushOK})
+	if c := origin.Branch(t, "deploy/new"); c == nil || strings.TrimSpace(c.Message) != "new" {
+		t.Errorf("unexpected deploy/new commit: %v", c)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if issued < 3 {
+		t.Errorf("credentials should be requested for clone, fetch and push, got %d requests", issued)
+	}
+	if len(used) < 3 {
+		t.Errorf("refreshed credentials should be used, got %d tokens", len(used))
+	}
+}
+
+func push(t *testing.T, r git.Repo, mode git.PushMode, expected map[string]string) {
+	t.Helper()
+	var branches []string
+	for b := range expected {
+		branches = append(branches, b)
+	}
+	sort.Strings(branches)
+	results, err := r.Push(branches, mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertPushResults(t, results, expected)
+}
+
+func assertPushResults(t *testing.T, results []git.PushResult, expected map[string]string) {
+	t.Helper()
+	statuses := make(map[string]string)
+	for _, r := range results {
+		statuses[r.Branch] = r.Status
+	}
+	if !reflect.DeepEqual(statuses, expected) {
+		t.Errorf("unexpected push results %+v, expected %v", results, expected)
+	}
+}
+
+func commit(t *testing.T, r git.Repo, msg string) {
+	t.Helper()
+	changed, err := r.Commit(msg, "cloud")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Fatalf("commit %q: no changes", msg)
+	}
+}
+
+func assertClean(t *testing.T, r git.Repo, expected bool) {
+	t.Helper()
+	clean, err := r.IsClean()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clean != expected {
+		t.Errorf("IsClean = %v, expected %v", clean, expected)
+	}
+}
+
+func assertTree(t *testing.T, c *object.Commit, expected map[string]string) {
+	t.Helper()
+	tree, err := c.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	err = tree.Files().ForEach(func(f *object.File) error {
+		s, err := f.Contents()
+		files[f.Name] = s
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("unexpected tree %v, expected %v", files, expected)
+	}
+}
+
+func signature() *object.Signature {
+	return &object.Signature{Name: "gittest", Email: "gittest@example.com", When: time.Now()}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
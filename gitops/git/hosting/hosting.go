@@ -0,0 +1,77 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+// Package hosting selects the git server and the git implementation of the command line tools by name.
+// The git servers are configured with their own command line flags.
+package hosting
+
+import (
+	"fmt"
+
+	"github.com/adobe/rules_gitops/gitops/git"
+	"github.com/adobe/rules_gitops/gitops/git/azure"
+	"github.com/adobe/rules_gitops/gitops/git/bitbucket"
+	"github.com/adobe/rules_gitops/gitops/git/gitea"
+	"github.com/adobe/rules_gitops/gitops/git/github"
+	"github.com/adobe/rules_gitops/gitops/git/gitlab"
+	"github.com/adobe/rules_gitops/gitops/git/native"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// Server returns the git server api: 'bitbucket', 'bitbucket_cloud', 'github', 'gitlab', 'gitea' or 'azure'
+func Server(name string) (git.Server, error) {
+	switch name {
+	case "github":
+		return github.Server{}, nil
+	case "gitlab":
+		return gitlab.Server{}, nil
+	case "bitbucket":
+		return bitbucket.Server{}, nil
+	case "bitbucket_cloud":
+		return bitbucket.CloudServer{}, nil
+	case "gitea":
+		return gitea.Server{}, nil
+	case "azure":
+		return azure.Server{}, nil
+	}
+	return nil, fmt.Errorf("unknown vcs host: %s", name)
+}
+
+// CloneFunc clones repo into dir, see git.Clone
+type CloneFunc func(repo, dir, mirrorDir, primaryBranch, gitopsPath string) (git.Repo, error)
+
+// Cloner returns the clone function of the git implementation: 'exec' runs git binary, 'native' does not require git to be installed.
+// The GitHub App installation token authenticates the HTTPS git access of the 'github' server.
+func Cloner(backend, server string) (CloneFunc, error) {
+	var creds git.Credentials
+	if server == "github" {
+		var err error
+		if creds, err = github.GitCredentials(); err != nil {
+			return nil, err
+		}
+	}
+	switch backend {
+	case "exec":
+		return func(repo, dir, mirrorDir, primaryBranch, gitopsPath string) (git.Repo, error) {
+			return git.CloneWithCredentials(repo, dir, mirrorDir, primaryBranch, gitopsPath, creds)
+		}, nil
+	case "native":
+		return func(repo, dir, mirrorDir, primaryBranch, gitopsPath string) (git.Repo, error) {
+			var auth transport.AuthMethod
+			if creds != nil {
+				auth = native.CredentialsAuth(creds)
+			}
+			return native.Clone(repo, dir, mirrorDir, primaryBranch, gitopsPath, auth)
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown git backend: %s", backend)
+}
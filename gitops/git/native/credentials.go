@@ -0,0 +1,52 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package native
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+
+	"github.com/adobe/rules_gitops/gitops/git"
+)
+
+// CredentialsAuth returns the HTTP basic authentication with the credentials requested for every request
+func CredentialsAuth(creds git.Credentials) transport.AuthMethod {
+	return credentialsAuth{creds}
+}
+
+type credentialsAuth struct {
+	creds git.Credentials
+}
+
+var _ githttp.AuthMethod = credentialsAuth{}
+
+func (a credentialsAuth) Name() string {
+	return "http-basic-auth"
+}
+
+func (a credentialsAuth) String() string {
+	return "http-basic-auth - credentials"
+}
+
+// SetAuth sets the basic authentication of the request. The request is sent unauthenticated
+// if the credentials are not available, the error is logged.
+func (a credentialsAuth) SetAuth(r *http.Request) {
+	username, password, err := a.creds()
+	if err != nil {
+		log.Print("unable to get git credentials: ", err)
+		return
+	}
+	r.SetBasicAuth(username, password)
+}
@@ -0,0 +1,908 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+// Package native implements git.Repo in pure Go, without the git binary.
+package native
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+
+	"github.com/adobe/rules_gitops/gitops/git"
+)
+
+func init() {
+	// the default file transport runs git-upload-pack and git-receive-pack binaries
+	client.InstallProtocol("file", fileTransport{server.DefaultServer})
+}
+
+// fileTransport serves local repositories in process.
+// Unlike go-git server it ignores the haves missing in the served repository, same as git upload-pack.
+type fileTransport struct {
+	transport.Transport
+}
+
+func (t fileTransport) NewUploadPackSession(ep *transport.Endpoint, auth transport.AuthMethod) (transport.UploadPackSession, error) {
+	s, err := t.Transport.NewUploadPackSession(ep, auth)
+	if err != nil {
+		return nil, err
+	}
+	st, err := server.DefaultLoader.Load(ep)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	return &uploadPackSession{UploadPackSession: s, storer: st}, nil
+}
+
+type uploadPackSession struct {
+	transport.UploadPackSession
+	storer storer.Storer
+}
+
+func (s *uploadPackSession) UploadPack(ctx context.Context, req *packp.UploadPackRequest) (*packp.UploadPackResponse, error) {
+	var haves []plumbing.Hash
+	for _, h := range req.Haves {
+		if s.storer.HasEncodedObject(h) == nil {
+			haves = append(haves, h)
+		}
+	}
+	req.Haves = haves
+	return s.UploadPackSession.UploadPack(ctx, req)
+}
+
+// Clone clones a repository. Pass the full repository URL or a local path as the repo.
+// Cloned directory will be clean of local changes with primaryBranch branch checked out.
+// mirrorDir: optional (if not empty) local mirror of the repository; objects are fetched from the mirror first.
+// auth: optional authentication for the remote, URL credentials are used if nil.
+func Clone(repo, dir, mirrorDir, primaryBranch, gitopsPath string, auth transport.AuthMethod) (*Repo, error) {
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("Unable to clone repo: %w", err)
+	}
+	remoteName := "origin"
+	url := repo
+	if mirrorDir != "" {
+		url = mirrorDir
+	}
+	r, err := gogit.PlainClone(dir, false, &gogit.CloneOptions{
+		URL:           url,
+		Auth:          auth,
+		RemoteName:    remoteName,
+		ReferenceName: plumbing.NewBranchReferenceName(primaryBranch),
+		SingleBranch:  true,
+		NoCheckout:    true,
+		Tags:          gogit.NoTags,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Unable to clone repo: %w", err)
+	}
+	nr := &Repo{
+		Dir:        dir,
+		RemoteName: remoteName,
+		Auth:       auth,
+		repo:       r,
+		gitDir:     filepath.Join(dir, gogit.GitDirName),
+	}
+	if !isRootPath(gitopsPath) {
+		nr.sparse = []string{strings.Trim(filepath.ToSlash(gitopsPath), "/")}
+	}
+	if mirrorDir != "" {
+		// point the remote to the upstream repository and bring the primary branch up to date
+		cfg, err := r.Config()
+		if err != nil {
+			return nil, err
+		}
+		cfg.Remotes[remoteName].URLs = []string{repo}
+		if err := r.SetConfig(cfg); err != nil {
+			return nil, err
+		}
+		if err := nr.Fetch(primaryBranch); err != nil {
+			return nil, fmt.Errorf("Unable to clone repo: %w", err)
+		}
+		ref, err := r.Reference(plumbing.NewRemoteReferenceName(remoteName, primaryBranch), true)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(primaryBranch), ref.Hash())); err != nil {
+			return nil, err
+		}
+	}
+	if err := nr.checkout(plumbing.NewBranchReferenceName(primaryBranch)); err != nil {
+		return nil, err
+	}
+	return nr, nil
+}
+
+// Repo is a git.Repo implementation based on go-git.
+type Repo struct {
+	// Dir is the location of the git repo.
+	Dir string
+	// RemoteName is the name of the remote that tracks upstream repository.
+	RemoteName string
+	// Auth is the authentication for fetch and push, URL credentials are used if nil.
+	Auth transport.AuthMethod
+
+	repo *gogit.Repository
+	// gitDir is the location of the repository storage shared with worktrees
+	gitDir string
+	sparse []string
+}
+
+var _ git.Repo = &Repo{}
+
+// WorkDir returns the location of the git repo
+func (r *Repo) WorkDir() string {
+	return r.Dir
+}
+
+// Remote returns the name of the remote that tracks upstream repository
+func (r *Repo) Remote() string {
+	return r.RemoteName
+}
+
+// Clean cleans up the repo
+func (r *Repo) Clean() error {
+	return os.RemoveAll(r.Dir)
+}
+
+// Fetch branches from the remote repository based on a specified pattern.
+// The branches will be be added to the list tracked remote branches ready to be pushed.
+func (r *Repo) Fetch(pattern string) error {
+	cfg, err := r.repo.Config()
+	if err != nil {
+		return err
+	}
+	remote, ok := cfg.Remotes[r.RemoteName]
+	if !ok {
+		return fmt.Errorf("remote %s: %w", r.RemoteName, gogit.ErrRemoteNotFound)
+	}
+	spec := config.RefSpec(fmt.Sprintf("+refs/heads/%s:refs/remotes/%s/%s", pattern, r.RemoteName, pattern))
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+	found := false
+	for _, s := range remote.Fetch {
+		found = found || s == spec
+	}
+	if !found {
+		remote.Fetch = append(remote.Fetch, spec)
+		if err := r.repo.SetConfig(cfg); err != nil {
+			return err
+		}
+	}
+	err = r.repo.Fetch(&gogit.FetchOptions{
+		RemoteName: r.RemoteName,
+		Auth:       r.Auth,
+		Tags:       gogit.NoTags,
+		Force:      true,
+	})
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("fetch %s: %w", pattern, err)
+	}
+	return r.prune(spec)
+}
+
+// prune removes the remote tracking branches matching spec which were deleted in the remote repository
+func (r *Repo) prune(spec config.RefSpec) error {
+	advertised, err := r.remoteRefs()
+	if err != nil {
+		return err
+	}
+	refs, err := r.repo.References()
+	if err != nil {
+		return err
+	}
+	prefix := "refs/remotes/" + r.RemoteName + "/"
+	var stale []plumbing.ReferenceName
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name().String()
+		if !strings.HasPrefix(name, prefix) {
+			return nil
+		}
+		src := plumbing.NewBranchReferenceName(strings.TrimPrefix(name, prefix))
+		if _, ok := advertised[src]; !ok && spec.Match(src) {
+			stale = append(stale, ref.Name())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range stale {
+		if err := r.repo.Storer.RemoveReference(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// remoteRefs lists the references of the remote repository
+func (r *Repo) remoteRefs() (map[plumbing.ReferenceName]plumbing.Hash, error) {
+	remote, err := r.repo.Remote(r.RemoteName)
+	if err != nil {
+		return nil, err
+	}
+	list, err := remote.List(&gogit.ListOptions{Auth: r.Auth})
+	if err != nil {
+		return nil, fmt.Errorf("list %s: %w", r.RemoteName, err)
+	}
+	refs := make(map[plumbing.ReferenceName]plumbing.Hash, len(list))
+	for _, ref := range list {
+		if ref.Type() == plumbing.HashReference {
+			refs[ref.Name()] = ref.Hash()
+		}
+	}
+	return refs, nil
+}
+
+// SwitchToBranch switch the repo to specified branch and checkout primaryBranch files over it.
+// if branch does not exist it will be created
+func (r *Repo) SwitchToBranch(branch, primaryBranch string) (new bool, err error) {
+	local := plumbing.NewBranchReferenceName(branch)
+	if _, err := r.repo.Reference(local, true); err == nil {
+		return false, r.checkout(local)
+	}
+	if ref, err := r.repo.Reference(plumbing.NewRemoteReferenceName(r.RemoteName, branch), true); err == nil {
+		// the tracking configuration shared by worktrees is not written, push sets the upstream
+		if err := r.repo.Storer.SetReference(plumbing.NewHashReference(local, ref.Hash())); err != nil {
+			return false, err
+		}
+		return false, r.checkout(local)
+	}
+	h, err := r.repo.ResolveRevision(plumbing.Revision(primaryBranch))
+	if err != nil {
+		return false, fmt.Errorf("branch %s: %w", primaryBranch, err)
+	}
+	if err := r.repo.Storer.SetReference(plumbing.NewHashReference(local, *h)); err != nil {
+		return false, err
+	}
+	return true, r.checkout(local)
+}
+
+// RecreateBranch discards a branch content and reset it from primaryBranch.
+func (r *Repo) RecreateBranch(branch, primaryBranch string) error {
+	h, err := r.repo.ResolveRevision(plumbing.Revision(primaryBranch))
+	if err != nil {
+		return fmt.Errorf("branch %s: %w", primaryBranch, err)
+	}
+	local := plumbing.NewBranchReferenceName(branch)
+	if err := r.repo.Storer.SetReference(plumbing.NewHashReference(local, *h)); err != nil {
+		return err
+	}
+	return r.checkout(local)
+}
+
+// DeleteBranch deletes the local branch. The next SwitchToBranch starts from the fetched remote branch.
+func (r *Repo) DeleteBranch(branch string) error {
+	local := plumbing.NewBranchReferenceName(branch)
+	ref, err := r.repo.Reference(local, true)
+	if err != nil {
+		return fmt.Errorf("branch %s: %w", branch, err)
+	}
+	head, err := r.repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return err
+	}
+	if head.Type() == plumbing.SymbolicReference && head.Target() == local {
+		if err := r.repo.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, ref.Hash())); err != nil {
+			return err
+		}
+	}
+	if err := r.repo.Storer.RemoveReference(local); err != nil {
+		return err
+	}
+	cfg, err := r.repo.Config()
+	if err != nil {
+		return err
+	}
+	delete(cfg.Branches, branch)
+	return r.repo.SetConfig(cfg)
+}
+
+// GetLastCommitMessage fetches the commit message from the most recent change of the branch
+func (r *Repo) GetLastCommitMessage() (msg string, err error) {
+	c, err := r.head()
+	if err != nil {
+		return "", err
+	}
+	return c.Message, nil
+}
+
+// Commit all changes in gitopsPath to the current branch. returns true if there were any changes
+func (r *Repo) Commit(message, gitopsPath string) (bool, error) {
+	w, err := r.repo.Worktree()
+	if err != nil {
+		return false, err
+	}
+	st, err := w.Status()
+	if err != nil {
+		return false, err
+	}
+	changed := false
+	for name, fs := range st {
+		if !r.inSparse(name) || !isRootPath(gitopsPath) && !inDir(name, gitopsPath) {
+			continue
+		}
+		switch {
+		case fs.Worktree == gogit.Deleted:
+			if _, err := w.Remove(name); err != nil {
+				return false, err
+			}
+		case fs.Worktree != gogit.Unmodified:
+			if _, err := w.Add(name); err != nil {
+				return false, err
+			}
+		case fs.Staging == gogit.Unmodified:
+			continue
+		}
+		changed = true
+	}
+	if !changed {
+		return false, nil
+	}
+	author, err := r.author()
+	if err != nil {
+		return false, err
+	}
+	if _, err := w.Commit(message, &gogit.CommitOptions{Author: author}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RestoreFile restores the specified file in the repository to its original state
+func (r *Repo) RestoreFile(fileName string) error {
+	name, err := r.relPath(fileName)
+	if err != nil {
+		return err
+	}
+	idx, err := r.repo.Storer.Index()
+	if err != nil {
+		return err
+	}
+	e, err := idx.Entry(name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	blob, err := r.repo.BlobObject(e.Hash)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(filepath.Join(r.Dir, filepath.FromSlash(name))); err != nil {
+		return err
+	}
+	return r.writeFile(name, object.NewFile(name, e.Mode, blob))
+}
+
+// GetChangedFiles returns a list of files that have been changed in the repository
+func (r *Repo) GetChangedFiles() ([]string, error) {
+	st, err := r.status()
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for name, fs := range st {
+		if fs.Worktree != gogit.Unmodified && fs.Worktree != gogit.Untracked {
+			files = append(files, name)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// GetLastCommitFiles returns a list of files changed by the most recent commit of the current branch
+func (r *Repo) GetLastCommitFiles() ([]string, error) {
+	c, err := r.head()
+	if err != nil {
+		return nil, err
+	}
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+	var parentTree *object.Tree
+	if c.NumParents() > 0 {
+		p, err := c.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		if parentTree, err = p.Tree(); err != nil {
+			return nil, err
+		}
+	}
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, ch := range changes {
+		name := ch.To.Name
+		if name == "" {
+			name = ch.From.Name
+		}
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Diff returns the files in path changed between from and to revisions
+func (r *Repo) Diff(from, to, path string) ([]git.FileChange, error) {
+	fromTree, err := r.tree(from)
+	if err != nil {
+		return nil, err
+	}
+	toTree, err := r.tree(to)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := object.DiffTree(fromTree, toTree)
+	if err != nil {
+		return nil, err
+	}
+	var files []git.FileChange
+	for _, ch := range changes {
+		fc := git.FileChange{Path: ch.To.Name, Status: git.FileModified}
+		switch {
+		case ch.From.Name == "":
+			fc.Status = git.FileAdded
+		case ch.To.Name == "":
+			fc.Path, fc.Status = ch.From.Name, git.FileDeleted
+		}
+		if isRootPath(path) || inDir(fc.Path, path) {
+			files = append(files, fc)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// ReadFile returns the content of the file in the revision
+func (r *Repo) ReadFile(revision, path string) ([]byte, error) {
+	tree, err := r.tree(revision)
+	if err != nil {
+		return nil, err
+	}
+	f, err := tree.File(filepath.ToSlash(path))
+	if err == object.ErrFileNotFound {
+		return nil, fmt.Errorf("%s:%s: %w", revision, path, os.ErrNotExist)
+	}
+	if err != nil {
+		return nil, err
+	}
+	content, err := f.Contents()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(content), nil
+}
+
+// tree returns the tree of the revision commit
+func (r *Repo) tree(revision string) (*object.Tree, error) {
+	h, err := r.repo.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return nil, fmt.Errorf("revision %s: %w", revision, err)
+	}
+	c, err := r.repo.CommitObject(*h)
+	if err != nil {
+		return nil, err
+	}
+	return c.Tree()
+}
+
+// IsClean returns true if there is no local changes (nothing to commit)
+func (r *Repo) IsClean() (bool, error) {
+	st, err := r.status()
+	if err != nil {
+		return false, err
+	}
+	return len(st) == 0, nil
+}
+
+// Log returns up to max most recent commits of the revision changing files in path following the first parents
+func (r *Repo) Log(revision, path string, max int) ([]git.Commit, error) {
+	h, err := r.repo.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return nil, fmt.Errorf("revision %s: %w", revision, err)
+	}
+	c, err := r.repo.CommitObject(*h)
+	if err != nil {
+		return nil, err
+	}
+	var commits []git.Commit
+	for c != nil && (max <= 0 || len(commits) < max) {
+		var parent *object.Commit
+		var parentTree *object.Tree
+		if c.NumParents() > 0 {
+			if parent, err = c.Parent(0); err != nil {
+				return nil, err
+			}
+			if parentTree, err = parent.Tree(); err != nil {
+				return nil, err
+			}
+		}
+		tree, err := c.Tree()
+		if err != nil {
+			return nil, err
+		}
+		changes, err := object.DiffTree(parentTree, tree)
+		if err != nil {
+			return nil, err
+		}
+		for _, ch := range changes {
+			name := ch.To.Name
+			if name == "" {
+				name = ch.From.Name
+			}
+			if isRootPath(path) || inDir(name, path) {
+				gc := git.Commit{Hash: c.Hash.String(), Author: c.Author.Name + " <" + c.Author.Email + ">", Message: strings.TrimRight(c.Message, "\n"), Committed: c.Committer.When}
+				for _, p := range c.ParentHashes {
+					gc.Parents = append(gc.Parents, p.String())
+				}
+				commits = append(commits, gc)
+				break
+			}
+		}
+		c = parent
+	}
+	return commits, nil
+}
+
+// RemoteBranches returns the fetched remote branches with names starting with prefix
+func (r *Repo) RemoteBranches(prefix string) ([]git.RemoteBranch, error) {
+	refs, err := r.repo.References()
+	if err != nil {
+		return nil, err
+	}
+	refPrefix := "refs/remotes/" + r.RemoteName + "/"
+	var branches []git.RemoteBranch
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		name := strings.TrimPrefix(ref.Name().String(), refPrefix)
+		if ref.Type() != plumbing.HashReference || !strings.HasPrefix(ref.Name().String(), refPrefix) || !strings.HasPrefix(name, prefix) {
+			return nil
+		}
+		c, err := r.repo.CommitObject(ref.Hash())
+		if err != nil {
+			return fmt.Errorf("branch %s: %w", name, err)
+		}
+		branches = append(branches, git.RemoteBranch{Name: name, Committed: c.Committer.When})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(branches, func(i, j int) bool { return branches[i].Name < branches[j].Name })
+	return branches, nil
+}
+
+// DeleteRemoteBranch deletes the branch in the remote repository and its remote tracking branch
+func (r *Repo) DeleteRemoteBranch(branch string) error {
+	remote := plumbing.NewBranchReferenceName(branch)
+	// go-git reports the deletion of a missing branch as up to date
+	advertised, err := r.remoteRefs()
+	if err != nil {
+		return err
+	}
+	if _, ok := advertised[remote]; !ok {
+		return fmt.Errorf("delete %s: %w", branch, plumbing.ErrReferenceNotFound)
+	}
+	err = r.repo.Push(&gogit.PushOptions{
+		RemoteName: r.RemoteName,
+		RefSpecs:   []config.RefSpec{config.RefSpec(":" + remote.String())},
+		Auth:       r.Auth,
+	})
+	if err != nil {
+		return fmt.Errorf("delete %s: %w", branch, err)
+	}
+	err = r.repo.Storer.RemoveReference(plumbing.NewRemoteReferenceName(r.RemoteName, branch))
+	if err != nil && !errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return err
+	}
+	return nil
+}
+
+// Push pushes all local changes to the remote repository
+// all changes should be already commited
+func (r *Repo) Push(branches []string, mode git.PushMode) ([]git.PushResult, error) {
+	if len(branches) == 0 {
+		return nil, nil
+	}
+	var advertised map[plumbing.ReferenceName]plumbing.Hash
+	switch mode {
+	case git.PushForce:
+	case git.PushForceWithLease:
+		var err error
+		if advertised, err = r.remoteRefs(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported push mode %d", mode)
+	}
+	results := make([]git.PushResult, 0, len(branches))
+	for _, b := range branches {
+		results = append(results, r.pushBranch(b, mode, advertised))
+	}
+	return results, git.PushError(results)
+}
+
+// pushBranch pushes a single branch. With the lease the advertised remote branch must match the fetched one.
+func (r *Repo) pushBranch(branch string, mode git.PushMode, advertised map[plumbing.ReferenceName]plumbing.Hash) git.PushResult {
+	res := git.PushResult{Branch: branch, Status: git.PushOK}
+	local := plumbing.NewBranchReferenceName(branch)
+	ref, err := r.repo.Reference(local, true)
+	if err != nil {
+		res.Status, res.Reason = git.PushFailed, err.Error()
+		return res
+	}
+	opts := &gogit.PushOptions{
+		RemoteName: r.RemoteName,
+		RefSpecs:   []config.RefSpec{config.RefSpec(fmt.Sprintf("+%s:%s", local, local))},
+		Auth:       r.Auth,
+	}
+	if mode == git.PushForceWithLease {
+		var expected plumbing.Hash
+		if tracking, err := r.repo.Reference(plumbing.NewRemoteReferenceName(r.RemoteName, branch), true); err == nil {
+			expected = tracking.Hash()
+		}
+		if advertised[local] != expected {
+			res.Status, res.Reason = git.PushRejected, "stale info"
+			return res
+		}
+		if expected.IsZero() {
+			// a branch created in the remote repository since the check fails the fast-forward check
+			opts.RefSpecs = []config.RefSpec{config.RefSpec(fmt.Sprintf("%s:%s", local, local))}
+		} else {
+			opts.ForceWithLease = &gogit.ForceWithLease{RefName: local, Hash: expected}
+		}
+	}
+	err = r.repo.Push(opts)
+	switch {
+	case errors.Is(err, gogit.NoErrAlreadyUpToDate):
+		res.Status = git.PushUpToDate
+	case err != nil && strings.Contains(err.Error(), "non-fast-forward update"):
+		// go-git does not export the error of lease or fast-forward check failure
+		res.Status, res.Reason = git.PushRejected, err.Error()
+		return res
+	case err != nil:
+		res.Status, res.Reason = git.PushFailed, err.Error()
+		return res
+	}
+	// same as git push --set-upstream
+	if err := r.setBranch(branch, ref.Hash()); err != nil {
+		res.Status, res.Reason = git.PushFailed, err.Error()
+	}
+	return res
+}
+
+// checkout switches the worktree to the branch discarding local changes.
+// With sparse paths the index holds the full tree and only the sparse paths are written to the worktree.
+func (r *Repo) checkout(branch plumbing.ReferenceName) error {
+	w, err := r.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	if len(r.sparse) == 0 {
+		if err := w.Checkout(&gogit.CheckoutOptions{Branch: branch, Force: true}); err != nil {
+			return fmt.Errorf("checkout %s: %w", branch.Short(), err)
+		}
+		return nil
+	}
+	if err := w.Checkout(&gogit.CheckoutOptions{Branch: branch, Keep: true}); err != nil {
+		return fmt.Errorf("checkout %s: %w", branch.Short(), err)
+	}
+	c, err := r.head()
+	if err != nil {
+		return err
+	}
+	if err := r.checkoutSparse(w, c.Hash); err != nil {
+		return fmt.Errorf("checkout %s: %w", branch.Short(), err)
+	}
+	return nil
+}
+
+// checkoutSparse resets the index to the commit and writes the sparse paths of its tree to the worktree
+func (r *Repo) checkoutSparse(w *gogit.Worktree, h plumbing.Hash) error {
+	if err := w.Reset(&gogit.ResetOptions{Commit: h, Mode: gogit.MixedReset}); err != nil {
+		return err
+	}
+	c, err := r.repo.CommitObject(h)
+	if err != nil {
+		return err
+	}
+	tree, err := c.Tree()
+	if err != nil {
+		return err
+	}
+	for _, dir := range r.sparse {
+		if err := os.RemoveAll(filepath.Join(r.Dir, filepath.FromSlash(dir))); err != nil {
+			return err
+		}
+		sub, err := tree.Tree(dir)
+		if errors.Is(err, object.ErrDirectoryNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		err = sub.Files().ForEach(func(f *object.File) error {
+			return r.writeFile(path.Join(dir, f.Name), f)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFile writes the file content to the worktree
+func (r *Repo) writeFile(name string, f *object.File) error {
+	fn := filepath.Join(r.Dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+		return err
+	}
+	if f.Mode == filemode.Symlink {
+		target, err := f.Contents()
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, fn)
+	}
+	mode, err := f.Mode.ToOSFileMode()
+	if err != nil {
+		return err
+	}
+	rd, err := f.Reader()
+	if err != nil {
+		return err
+	}
+	defer rd.Close()
+	out, err := os.OpenFile(fn, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := out.ReadFrom(rd); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// setBranch points the local branch and its remote tracking branch to h and sets the upstream
+func (r *Repo) setBranch(branch string, h plumbing.Hash) error {
+	refs := []plumbing.ReferenceName{
+		plumbing.NewBranchReferenceName(branch),
+		plumbing.NewRemoteReferenceName(r.RemoteName, branch),
+	}
+	for _, name := range refs {
+		if err := r.repo.Storer.SetReference(plumbing.NewHashReference(name, h)); err != nil {
+			return err
+		}
+	}
+	cfg, err := r.repo.Config()
+	if err != nil {
+		return err
+	}
+	cfg.Branches[branch] = &config.Branch{
+		Name:   branch,
+		Remote: r.RemoteName,
+		Merge:  plumbing.NewBranchReferenceName(branch),
+	}
+	return r.repo.SetConfig(cfg)
+}
+
+// author returns the commit signature from GIT_AUTHOR_NAME and GIT_AUTHOR_EMAIL or the git config
+func (r *Repo) author() (*object.Signature, error) {
+	cfg, err := r.repo.ConfigScoped(config.GlobalScope)
+	if err != nil {
+		return nil, err
+	}
+	sig := &object.Signature{Name: cfg.User.Name, Email: cfg.User.Email, When: time.Now()}
+	if cfg.Author.Name != "" {
+		sig.Name = cfg.Author.Name
+	}
+	if cfg.Author.Email != "" {
+		sig.Email = cfg.Author.Email
+	}
+	if v := os.Getenv("GIT_AUTHOR_NAME"); v != "" {
+		sig.Name = v
+	}
+	if v := os.Getenv("GIT_AUTHOR_EMAIL"); v != "" {
+		sig.Email = v
+	}
+	if sig.Name == "" || sig.Email == "" {
+		return nil, errors.New("unable to commit: author identity unknown, set user.name and user.email in git config")
+	}
+	return sig, nil
+}
+
+func (r *Repo) head() (*object.Commit, error) {
+	ref, err := r.repo.Head()
+	if err != nil {
+		return nil, err
+	}
+	return r.repo.CommitObject(ref.Hash())
+}
+
+// status returns the worktree status restricted to the sparse checkout paths
+func (r *Repo) status() (gogit.Status, error) {
+	w, err := r.repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	st, err := w.Status()
+	if err != nil {
+		return nil, err
+	}
+	for name, fs := range st {
+		if !r.inSparse(name) || fs.Worktree == gogit.Unmodified && fs.Staging == gogit.Unmodified {
+			delete(st, name)
+		}
+	}
+	return st, nil
+}
+
+// inSparse reports whether the path is checked out
+func (r *Repo) inSparse(name string) bool {
+	if len(r.sparse) == 0 {
+		return true
+	}
+	for _, dir := range r.sparse {
+		if inDir(name, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// relPath converts fileName to the slash separated path relative to the repo root
+func (r *Repo) relPath(fileName string) (string, error) {
+	if !filepath.IsAbs(fileName) {
+		return filepath.ToSlash(filepath.Clean(fileName)), nil
+	}
+	rel, err := filepath.Rel(r.Dir, fileName)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("%s is outside of the repository %s", fileName, r.Dir)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// inDir reports whether the slash separated path is inside dir
+func inDir(name, dir string) bool {
+	dir = strings.Trim(filepath.ToSlash(dir), "/")
+	return name == dir || strings.HasPrefix(name, dir+"/")
+}
+
+// isRootPath is an internal helper to detect "full repo" case.
+func isRootPath(gitopsPath string) bool {
+	return gitopsPath == "" || gitopsPath == "."
+}
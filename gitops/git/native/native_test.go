@@ -0,0 +1,32 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package native_test
+
+import (
+	"testing"
+
+	"github.com/adobe/rules_gitops/gitops/git"
+	"github.com/adobe/rules_gitops/gitops/git/gittest"
+	"github.com/adobe/rules_gitops/gitops/git/native"
+)
+
+func TestRepo(t *testing.T) {
+	gittest.TestRepo(t, func(repo, dir, mirrorDir, primaryBranch, gitopsPath string) (git.Repo, error) {
+		return native.Clone(repo, dir, mirrorDir, primaryBranch, gitopsPath, nil)
+	})
+}
+
+func TestCredentials(t *testing.T) {
+	gittest.TestCredentials(t, func(repo, dir string, creds git.Credentials) (git.Repo, error) {
+		return native.Clone(repo, dir, "", "master", "cloud", native.CredentialsAuth(creds))
+	})
+}
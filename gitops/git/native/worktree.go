@@ -0,0 +1,141 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package native
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/storage"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+
+	"github.com/adobe/rules_gitops/gitops/git"
+)
+
+// Worktree adds a working copy of the repository in dir with primaryBranch commit checked out.
+// go-git does not support linked worktrees: the worktree opens the repository storage
+// with its own HEAD and index kept in memory.
+func (r *Repo) Worktree(dir, primaryBranch string) (git.Repo, error) {
+	h, err := r.repo.ResolveRevision(plumbing.Revision(primaryBranch))
+	if err != nil {
+		return nil, fmt.Errorf("branch %s: %w", primaryBranch, err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("Unable to add worktree: %w", err)
+	}
+	// the storage caches are not safe for concurrent use, every worktree gets its own
+	st := &worktreeStorer{
+		Storer: filesystem.NewStorage(osfs.New(r.gitDir), cache.NewObjectLRUDefault()),
+		head:   plumbing.NewHashReference(plumbing.HEAD, *h),
+	}
+	repo, err := gogit.Open(st, osfs.New(dir))
+	if err != nil {
+		return nil, fmt.Errorf("Unable to add worktree: %w", err)
+	}
+	wt := &Repo{
+		Dir:        dir,
+		RemoteName: r.RemoteName,
+		Auth:       r.Auth,
+		repo:       repo,
+		gitDir:     r.gitDir,
+		sparse:     r.sparse,
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	if len(wt.sparse) == 0 {
+		err = w.Reset(&gogit.ResetOptions{Commit: *h, Mode: gogit.HardReset})
+	} else {
+		err = wt.checkoutSparse(w, *h)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to add worktree: %w", err)
+	}
+	return wt, nil
+}
+
+// worktreeStorer is the repository storage with HEAD and index of a worktree
+type worktreeStorer struct {
+	storage.Storer
+	head  *plumbing.Reference
+	index *index.Index
+}
+
+func (s *worktreeStorer) SetReference(ref *plumbing.Reference) error {
+	if ref.Name() == plumbing.HEAD {
+		s.head = ref
+		return nil
+	}
+	return s.Storer.SetReference(ref)
+}
+
+func (s *worktreeStorer) CheckAndSetReference(new, old *plumbing.Reference) error {
+	if new.Name() != plumbing.HEAD {
+		return s.Storer.CheckAndSetReference(new, old)
+	}
+	if old != nil && old.Hash() != s.head.Hash() {
+		return storage.ErrReferenceHasChanged
+	}
+	s.head = new
+	return nil
+}
+
+func (s *worktreeStorer) Reference(name plumbing.ReferenceName) (*plumbing.Reference, error) {
+	if name == plumbing.HEAD {
+		return s.head, nil
+	}
+	return s.Storer.Reference(name)
+}
+
+func (s *worktreeStorer) IterReferences() (storer.ReferenceIter, error) {
+	iter, err := s.Storer.IterReferences()
+	if err != nil {
+		return nil, err
+	}
+	refs := []*plumbing.Reference{s.head}
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Name() != plumbing.HEAD {
+			refs = append(refs, ref)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return storer.NewReferenceSliceIter(refs), nil
+}
+
+func (s *worktreeStorer) RemoveReference(name plumbing.ReferenceName) error {
+	if name == plumbing.HEAD {
+		return fmt.Errorf("unable to remove worktree %s", name)
+	}
+	return s.Storer.RemoveReference(name)
+}
+
+func (s *worktreeStorer) SetIndex(idx *index.Index) error {
+	s.index = idx
+	return nil
+}
+
+func (s *worktreeStorer) Index() (*index.Index, error) {
+	if s.index == nil {
+		return &index.Index{Version: 2}, nil
+	}
+	return s.index, nil
+}
@@ -1,15 +1,102 @@
 package git
 
+import (
+	"errors"
+	"fmt"
+)
+
+// PullRequest describes the pull request created or reused by a Server
+type PullRequest struct {
+	// Number is the pull request number (id) assigned by the git server. Zero if unknown.
+	Number int
+	// URL is the web URL of the pull request. Empty if unknown.
+	URL string
+	// Created is true if the pull request was created, false if an existing one was reused.
+	Created bool
+}
+
+// ErrNotSupported is returned by Server operations the git server does not provide
+var ErrNotSupported = errors.New("not supported by the git server")
+
+// Merge methods of EnableAutoMerge
+const (
+	// MergeMethodDefault is the merge method configured for the repository
+	MergeMethodDefault = ""
+	// MergeMethodMerge merges with a merge commit
+	MergeMethodMerge = "merge"
+	// MergeMethodSquash squashes the pull request commits into a single commit
+	MergeMethodSquash = "squash"
+	// MergeMethodRebase rebases the pull request commits onto the target branch
+	MergeMethodRebase = "rebase"
+)
+
+// ValidMergeMethod returns an error if the method is not one of the MergeMethod constants
+func ValidMergeMethod(method string) error {
+	switch method {
+	case MergeMethodDefault, MergeMethodMerge, MergeMethodSquash, MergeMethodRebase:
+		return nil
+	}
+	return fmt.Errorf("unknown merge method %q, expected %q, %q or %q", method, MergeMethodMerge, MergeMethodSquash, MergeMethodRebase)
+}
+
+// Server manages the pull requests of the deployment branches.
+// Pull requests are identified by the number assigned by the git server.
 type Server interface {
-	CreatePR(from, to, title, body string) error
+	// CreatePR creates a pull request from branch into branch.
+	// The open pull request of the branches is returned if it already exists.
+	CreatePR(from, to, title, body string) (*PullRequest, error)
+	// FindPR returns the open pull request from the head branch. Returns nil if there is none.
+	FindPR(from string) (*PullRequest, error)
+	// UpdatePR replaces the title and the description of the pull request
+	UpdatePR(number int, title, body string) error
+	// AddLabels adds the labels to the pull request
+	AddLabels(number int, labels []string) error
+	// RequestReviewers requests the review of the pull request from the users
+	RequestReviewers(number int, reviewers []string) error
+	// SetDraft marks the pull request as a draft or as ready for review
+	SetDraft(number int, draft bool) error
+	// ClosePR closes the pull request without merging
+	ClosePR(number int) error
+	// EnableAutoMerge merges the pull request with the merge method once the required checks pass
+	EnableAutoMerge(number int, method string) error
 }
 
-type ServerFunc func(from, to, title, body string) error
+// ServerFunc adapts a function creating pull requests to Server.
+// All other operations return ErrNotSupported.
+type ServerFunc func(from, to, title, body string) (*PullRequest, error)
 
-func (f ServerFunc) CreatePR(from, to, title, body string) error {
+func (f ServerFunc) CreatePR(from, to, title, body string) (*PullRequest, error) {
 	if body == "" {
 		body = title
 	}
 
 	return f(from, to, title, body)
 }
+
+func (f ServerFunc) FindPR(from string) (*PullRequest, error) {
+	return nil, ErrNotSupported
+}
+
+func (f ServerFunc) UpdatePR(number int, title, body string) error {
+	return ErrNotSupported
+}
+
+func (f ServerFunc) AddLabels(number int, labels []string) error {
+	return ErrNotSupported
+}
+
+func (f ServerFunc) RequestReviewers(number int, reviewers []string) error {
+	return ErrNotSupported
+}
+
+func (f ServerFunc) SetDraft(number int, draft bool) error {
+	return ErrNotSupported
+}
+
+func (f ServerFunc) ClosePR(number int) error {
+	return ErrNotSupported
+}
+
+func (f ServerFunc) EnableAutoMerge(number int, method string) error {
+	return ErrNotSupported
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+// gitops-history prints the deployments of the gitops targets merged into the gitops_pr_into branch:
+// when they were merged, the source branch and commit and the author of the merge.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adobe/rules_gitops/gitops/git"
+	"github.com/adobe/rules_gitops/gitops/git/hosting"
+	prer "github.com/adobe/rules_gitops/gitops/prer/pkg"
+)
+
+func init() {
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+}
+
+// targetFlags is a repeatable flag of gitops targets
+type targetFlags []string
+
+func (t *targetFlags) String() string {
+	return strings.Join(*t, ",")
+}
+
+func (t *targetFlags) Set(value string) error {
+	*t = append(*t, value)
+	return nil
+}
+
+var (
+	repo         = flag.String("git_repo", "", "git repo location")
+	gitMirror    = flag.String("git_mirror", "", "git mirror location, like /mnt/mirror/bitbucket.tubemogul.info/tm/repo.git for jenkins")
+	gitDir       = flag.String("git_dir", "", "read the history of an existing clone instead of cloning git_repo. gitops_pr_into is a revision of it, like origin/master")
+	gitopsPath   = flag.String("gitops_path", "cloud", "location of the generated files in repo.")
+	gitopsTmpDir = flag.String("gitops_tmpdir", os.TempDir(), "location to check out git tree with /cloud.")
+	prInto       = flag.String("gitops_pr_into", "master", "the branch with the merged deployments")
+	maxHistory   = flag.Int("max_history", 1000, "the number of the most recent gitops_pr_into commits searched for the deployments. 0 searches all")
+	gitHost      = flag.String("git_server", "bitbucket", "the git server of git_repo, selects the clone credentials. 'bitbucket', 'bitbucket_cloud', 'github', 'gitlab', 'gitea' or 'azure'")
+	gitBackend   = flag.String("git_backend", "exec", "the git implementation to use. 'exec' runs git binary, 'native' does not require git to be installed")
+	output       = flag.String("output", "table", "the output format: 'table' or 'json'")
+	targets      targetFlags
+)
+
+func main() {
+	flag.Var(&targets, "target", "the gitops target to report, like //app:prod. Can be repeated, all gitops targets are reported if not set")
+	flag.Parse()
+	if err := run(os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(w io.Writer) error {
+	if *output != "table" && *output != "json" {
+		return fmt.Errorf("unknown output format %q", *output)
+	}
+	var r git.Repo
+	if *gitDir != "" {
+		r = &git.ExecRepo{Dir: *gitDir, RemoteName: "origin"}
+	} else {
+		clone, err := hosting.Cloner(*gitBackend, *gitHost)
+		if err != nil {
+			return err
+		}
+		gitopsdir, err := ioutil.TempDir(*gitopsTmpDir, "gitops")
+		if err != nil {
+			return fmt.Errorf("Unable to create tempdir in %s: %w", *gitopsTmpDir, err)
+		}
+		defer os.RemoveAll(gitopsdir)
+		if r, err = clone(*repo, gitopsdir, *gitMirror, *prInto, *gitopsPath); err != nil {
+			return fmt.Errorf("Unable to clone repo: %w", err)
+		}
+	}
+	history, err := prer.History(prer.HistoryOptions{
+		Targets:    targets,
+		GitopsPath: *gitopsPath,
+		PRInto:     *prInto,
+		MaxHistory: *maxHistory,
+		Repo:       r,
+	})
+	if err != nil {
+		return err
+	}
+	return write(w, history, *output)
+}
+
+// write writes the history to w in the output format
+func write(w io.Writer, history []prer.TargetHistory, format string) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(history)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TARGET\tDEPLOYED\tCOMMIT\tSOURCE BRANCH\tSOURCE COMMIT\tAUTHOR\tNOTE")
+	for _, th := range history {
+		for _, d := range th.Deployments {
+			var note string
+			if len(d.RolledBack) > 0 {
+				note = "rollback of " + strings.Join(shortHashes(d.RolledBack), ", ")
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", th.Target, d.Time.UTC().Format(time.RFC3339), prer.ShortHash(d.Commit),
+				orDash(d.SourceBranch), orDash(prer.ShortHash(d.SourceCommit)), d.Author, note)
+		}
+	}
+	return tw.Flush()
+}
+
+func shortHashes(hashes []string) []string {
+	short := make([]string, len(hashes))
+	for i, h := range hashes {
+		short[i] = prer.ShortHash(h)
+	}
+	return short
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	prer "github.com/adobe/rules_gitops/gitops/prer/pkg"
+)
+
+func TestWrite(t *testing.T) {
+	t1 := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	history := []prer.TargetHistory{{
+		Target: "//app:prod",
+		Deployments: []prer.Deployment{
+			{Commit: "0123456789abcdef", Time: t1.Add(time.Hour), Author: "ops <ops@example.com>", SourceBranch: "master", SourceCommit: "fedcba9876543210", RolledBack: []string{"aaaaaaaaaaaaaaaa"}},
+			{Commit: "aaaaaaaaaaaaaaaa", Time: t1, Author: "dev <dev@example.com>"},
+		},
+	}}
+	var b strings.Builder
+	if err := write(&b, history, "table"); err != nil {
+		t.Fatal(err)
+	}
+	expected := `TARGET      DEPLOYED              COMMIT        SOURCE BRANCH  SOURCE COMMIT  AUTHOR                 NOTE
+//app:prod  2024-01-02T04:04:05Z  0123456789ab  master         fedcba987654   ops <ops@example.com>  rollback of aaaaaaaaaaaa
+//app:prod  2024-01-02T03:04:05Z  aaaaaaaaaaaa  -              -              dev <dev@example.com>  
+`
+	if b.String() != expected {
+		t.Errorf("unexpected table:\n%s", b.String())
+	}
+
+	b.Reset()
+	if err := write(&b, history[:1], "json"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `"target": "//app:prod"`) || !strings.Contains(b.String(), `"rolled_back": [`) {
+		t.Errorf("unexpected JSON:\n%s", b.String())
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	prer "github.com/adobe/rules_gitops/gitops/prer/pkg"
+	"github.com/ghodss/yaml"
+)
+
+// trainsKey is the config file key holding per release train overrides
+const trainsKey = "trains"
+
+// loadConfig reads YAML or JSON config file and applies its values to the flags of fs.
+// Top level keys are flag names. Flags explicitly set on the command line take precedence over the file values.
+// The trains key contains per release train overrides.
+func loadConfig(path string, fs *flag.FlagSet) (map[string]prer.TrainConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config file %s: %w", path, err)
+	}
+	trains, err := parseConfig(data, fs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return trains, nil
+}
+
+func parseConfig(data []byte, fs *flag.FlagSet) (map[string]prer.TrainConfig, error) {
+	js, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(js, &values); err != nil {
+		return nil, err
+	}
+
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var errs []string
+	var trains map[string]prer.TrainConfig
+	for _, k := range keys {
+		if k == trainsKey {
+			dec := json.NewDecoder(bytes.NewReader(values[k]))
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(&trains); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", k, err))
+			}
+			continue
+		}
+		f := fs.Lookup(k)
+		if f == nil || k == "config" {
+			errs = append(errs, fmt.Sprintf("unknown key %q", k))
+			continue
+		}
+		if explicit[k] {
+			continue
+		}
+		if err := setFlag(f, values[k]); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", k, err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.New(strings.Join(errs, "; "))
+	}
+	return trains, nil
+}
+
+// setFlag sets the flag value from a JSON scalar or, for multi value flags, a list of scalars.
+func setFlag(f *flag.Flag, raw json.RawMessage) error {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return err
+	}
+	if list, ok := v.([]interface{}); ok {
+		if _, multi := f.Value.(*SliceFlags); !multi {
+			return errors.New("list value is not supported")
+		}
+		for _, item := range list {
+			s, err := scalarString(item)
+			if err != nil {
+				return err
+			}
+			if err := f.Value.Set(s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	s, err := scalarString(v)
+	if err != nil {
+		return err
+	}
+	return f.Value.Set(s)
+}
+
+func scalarString(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return fmt.Sprint(v), nil
+	case float64:
+		// the large integers are not formatted in e-notation, which flag.Int rejects
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v", v)
+	}
+}
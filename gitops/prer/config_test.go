@@ -0,0 +1,133 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package main
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+
+	prer "github.com/adobe/rules_gitops/gitops/prer/pkg"
+)
+
+func testFlagSet() (*flag.FlagSet, *string, *int, *bool, *SliceFlags) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	into := fs.String("gitops_pr_into", "master", "")
+	parallelism := fs.Int("push_parallelism", 5, "")
+	stamp := fs.Bool("stamp", false, "")
+	var kinds SliceFlags
+	fs.Var(&kinds, "gitops_dependencies_kind", "")
+	return fs, into, parallelism, stamp, &kinds
+}
+
+func TestParseConfig(t *testing.T) {
+	fs, into, parallelism, stamp, kinds := testFlagSet()
+	if err := fs.Parse([]string{"--push_parallelism", "2"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg := `
+gitops_pr_into: main
+push_parallelism: 10
+stamp: true
+gitops_dependencies_kind:
+  - k8s_container_push
+  - push_oci
+trains:
+  prod:
+    gitops_pr_into: release
+    gitops_pr_title: Production deployment
+    auto_merge: false
+    merge_method: squash
+`
+	trains, err := parseConfig([]byte(cfg), fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *into != "main" {
+		t.Errorf("unexpected gitops_pr_into: %s", *into)
+	}
+	if *parallelism != 2 {
+		t.Errorf("command line flag should take precedence, got push_parallelism %d", *parallelism)
+	}
+	if !*stamp {
+		t.Error("stamp should be set")
+	}
+	if !reflect.DeepEqual([]string(*kinds), []string{"k8s_container_push", "push_oci"}) {
+		t.Errorf("unexpected gitops_dependencies_kind: %v", *kinds)
+	}
+	expected := map[string]prer.TrainConfig{
+		"prod": {PRInto: "release", PRTitle: "Production deployment", AutoMerge: new(bool), MergeMethod: "squash"},
+	}
+	if !reflect.DeepEqual(trains, expected) {
+		t.Errorf("unexpected trains: %v", trains)
+	}
+}
+
+func TestParseConfigLargeNumbers(t *testing.T) {
+	fs, into, parallelism, _, _ := testFlagSet()
+	maxSize := fs.Int("gitops_pr_body_max_size", 30000, "")
+	cfg := "gitops_pr_into: 1000000\npush_parallelism: 1e6\ngitops_pr_body_max_size: 12345678\n"
+	if _, err := parseConfig([]byte(cfg), fs); err != nil {
+		t.Fatal(err)
+	}
+	if *into != "1000000" || *parallelism != 1000000 || *maxSize != 12345678 {
+		t.Errorf("unexpected values: gitops_pr_into %s, push_parallelism %d, gitops_pr_body_max_size %d", *into, *parallelism, *maxSize)
+	}
+}
+
+func TestDeprecatedAutoMerge(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Bool("azure_auto_complete", false, "")
+	fs.Bool("gitlab_merge_when_pipeline_succeeds", false, "")
+	if names := deprecatedAutoMerge(fs); names != nil {
+		t.Errorf("unexpected deprecated flags: %v", names)
+	}
+	if _, err := parseConfig([]byte("gitlab_merge_when_pipeline_succeeds: true"), fs); err != nil {
+		t.Fatal(err)
+	}
+	if names := deprecatedAutoMerge(fs); !reflect.DeepEqual(names, []string{"gitlab_merge_when_pipeline_succeeds"}) {
+		t.Errorf("unexpected deprecated flags: %v", names)
+	}
+	// the git server flags are registered by the backends
+	for _, name := range deprecatedAutoMergeFlags {
+		if flag.Lookup(name) == nil {
+			t.Errorf("flag %s is not registered", name)
+		}
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  string
+		want string
+	}{
+		{"unknown key", "gitops_pr_int: main", `unknown key "gitops_pr_int"`},
+		{"unknown train key", "trains:\n  prod:\n    pr_title: x", `unknown field "pr_title"`},
+		{"list for scalar flag", "gitops_pr_into: [a, b]", "gitops_pr_into: list value is not supported"},
+		{"invalid int", "push_parallelism: many", "push_parallelism: "},
+		{"object value", "stamp: {a: b}", "stamp: unsupported value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs, _, _, _, _ := testFlagSet()
+			_, err := parseConfig([]byte(tt.cfg), fs)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("unexpected error %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
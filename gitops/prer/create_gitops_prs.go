@@ -12,28 +12,23 @@ governing permissions and limitations under the License.
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"net/url"
 	"os"
-	oe "os/exec"
 	"strings"
-	"sync"
+	"time"
 
-	"github.com/adobe/rules_gitops/gitops/analysis"
 	"github.com/adobe/rules_gitops/gitops/bazel"
-	"github.com/adobe/rules_gitops/gitops/commitmsg"
-	"github.com/adobe/rules_gitops/gitops/digester"
 	"github.com/adobe/rules_gitops/gitops/exec"
 	"github.com/adobe/rules_gitops/gitops/git"
-	"github.com/adobe/rules_gitops/gitops/git/bitbucket"
-	"github.com/adobe/rules_gitops/gitops/git/github"
-	"github.com/adobe/rules_gitops/gitops/git/gitlab"
-	"github.com/adobe/rules_gitops/templating/fasttemplate"
-
-	proto "github.com/golang/protobuf/proto"
+	"github.com/adobe/rules_gitops/gitops/git/hosting"
+	prer "github.com/adobe/rules_gitops/gitops/prer/pkg"
 )
 
 func init() {
@@ -55,26 +50,45 @@ func (i *SliceFlags) Set(value string) error {
 var (
 	releaseBranch          = flag.String("release_branch", "master", "filter gitops targets by release branch")
 	bazelCmd               = flag.String("bazel_cmd", "tools/bazel", "bazel binary to use")
+	resolveExecutables     = flag.Bool("resolve_executables", false, "locate the executables of the gitops and push targets with bazel cquery instead of deriving them from the labels, like for the targets of bzlmod modules")
+	queryOutput            = flag.String("query_output", bazel.JSONProto, "the bazel cquery output format: 'jsonproto' or 'streamed_jsonproto'. streamed_jsonproto requires bazel with cquery support of it")
 	workspace              = flag.String("workspace", "", "path to workspace root")
 	repo                   = flag.String("git_repo", "", "git repo location")
 	gitMirror              = flag.String("git_mirror", "", "git mirror location, like /mnt/mirror/bitbucket.tubemogul.info/tm/repo.git for jenkins")
 	gitopsPath             = flag.String("gitops_path", "cloud", "location to store files in repo.")
 	gitopsTmpDir           = flag.String("gitops_tmpdir", os.TempDir(), "location to check out git tree with /cloud.")
-	target                 = flag.String("target", "//... except //experimental/...", "target to scan. Useful for debugging only")
+	target                 = flag.String("target", prer.DefaultTarget, "target to scan. Useful for debugging only")
 	pushParallelism        = flag.Int("push_parallelism", 5, "Number of image pushes to perform concurrently")
+	trainParallelism       = flag.Int("train_parallelism", 1, "Number of release trains to update concurrently in separate git worktrees")
 	prInto                 = flag.String("gitops_pr_into", "master", "use this branch as the source branch and target for deployment PR")
-	prBody                 = flag.String("gitops_pr_body", "", "a body message for deployment PR")
+	prBody                 = flag.String("gitops_pr_body", "", "a body message for deployment PR. The summary of the deployment branch changes is generated if empty")
+	prBodyMaxSize          = flag.Int("gitops_pr_body_max_size", prer.DefaultMaxBodySize, "the size limit of the generated deployment PR body. Azure DevOps requires 4000")
 	prTitle                = flag.String("gitops_pr_title", "", "a title for deployment PR")
 	branchName             = flag.String("branch_name", "unknown", "Branch name to use in commit message")
 	gitCommit              = flag.String("git_commit", "unknown", "Git commit to use in commit message")
+	sourceRepo             = flag.String("source_repo", "", "the source repository URL recorded in the commit metadata, --git_repo if empty")
 	deploymentBranchPrefix = flag.String("deployment_branch_prefix", "deploy/", "the prefix to add to all deployment branch names")
 	deploymentBranchSuffix = flag.String("deployment_branch_suffix", "", "suffix to add to all deployment branch names")
-	gitHost                = flag.String("git_server", "bitbucket", "the git server api to use. 'bitbucket', 'github' or 'gitlab'")
+	gitHost                = flag.String("git_server", "bitbucket", "the git server api to use. 'bitbucket', 'bitbucket_cloud', 'github', 'gitlab', 'gitea' or 'azure'")
+	gitBackend             = flag.String("git_backend", "exec", "the git implementation to use. 'exec' runs git binary, 'native' does not require git to be installed")
 	gitopsKind             SliceFlags
 	gitopsRuleName         SliceFlags
 	gitopsRuleAttr         SliceFlags
 	stamp                  = flag.Bool("stamp", false, "Stamp results of gitops targets with volatile information")
 	dryRun                 = flag.Bool("dry_run", false, "Do not create PRs, just print what would be done")
+	forceWithLease         = flag.Bool("force_with_lease", false, "push deployment branches only if they were not updated concurrently since fetched, render and push the rejected branches again")
+	pushAttempts           = flag.Int("push_attempts", 3, "maximum number of deployment branches push attempts with --force_with_lease")
+	configFile             = flag.String("config", "", "YAML or JSON file with flag values and per release train overrides. Command line flags take precedence")
+	reportFile             = flag.String("report_file", "", "write a JSON report of release trains, pushed images and created PRs to this file")
+	prune                  = flag.Bool("prune", false, "delete the files generated by the removed gitops targets from the deployment branches, the generated files are tracked in the index of every release train")
+	pruneMaxFiles          = flag.Int("prune_max_files", 10, "fail the run instead of deleting more files in all the release trains with --prune. 0 disables the limit")
+	autoMerge              = flag.Bool("auto_merge", false, "enable the auto-merge of the deployment PRs, they are merged by the git server once the required checks pass")
+	mergeMethod            = flag.String("merge_method", "", "the auto-merge method: 'merge', 'squash' or 'rebase'. The git server default is used if empty")
+	gc                     = flag.Bool("gc", false, "instead of creating PRs, close the PRs and delete the deployment branches of the release trains not found by the query. Use with --dry_run to list them")
+	incrementalRange       = flag.String("incremental_range", "", "render only the release trains affected by the workspace files changed between the commits, like BASE..HEAD. HEAD is used if the second commit is omitted")
+	incrementalFiles       = flag.String("incremental_files", "", "render only the release trains affected by the workspace files listed one per line in this file, - reads the standard input")
+	gcMinAge               = flag.Duration("gc_min_age", 7*24*time.Hour, "keep the orphaned deployment branches with commits more recent than this with --gc")
+	gcPartialTarget        = flag.Bool("gc_partial_target", false, "allow --gc with a --target narrower than the workspace: the release trains of the gitops targets outside of it are orphaned")
 )
 
 func init() {
@@ -83,241 +97,226 @@ func init() {
 	flag.Var(&gitopsRuleAttr, "gitops_dependencies_attr", "dependency attribute(s) to run during gitops phase. Use attribute=value format. Can be specified multiple times. Default is empty")
 }
 
-func bazelQuery(query string) *analysis.CqueryResult {
-	log.Println("Executing bazel cquery ", query)
-	cmd := oe.Command(*bazelCmd, "cquery", query, "--output=proto")
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		log.Fatal(err)
-	}
-	go func() {
-		io.Copy(os.Stderr, stderr)
-	}()
-	buildproto, err := cmd.Output()
-	if err != nil {
-		log.Fatal(err)
-	}
-	qr := &analysis.CqueryResult{}
-	if err := proto.Unmarshal(buildproto, qr); err != nil {
-		log.Fatal(err)
+func main() {
+	flag.Parse()
+	rep := &report{}
+	err := run(rep)
+	if *reportFile != "" {
+		if werr := rep.write(*reportFile); werr != nil {
+			log.Printf("Unable to write report file %s: %v", *reportFile, werr)
+			if err == nil {
+				err = werr
+			}
+		}
 	}
-	return qr
-}
-
-func getGitStatusDict(workdir *git.Repo, gitCommit, branchName string) map[string]interface{} {
-	utcDate, err := exec.Ex("", "date", "-u")
 	if err != nil {
 		log.Fatal(err)
 	}
-	utcDate = strings.TrimSpace(utcDate)
-
-	ctx := map[string]interface{}{
-		"GIT_REVISION": gitCommit,
-		"UTC_DATE":     utcDate,
-		"GIT_BRANCH":   branchName,
-	}
-
-	return ctx
 }
 
-func stampFile(fullPath string, ctx map[string]interface{}) {
-	template, err := os.ReadFile(fullPath)
-	if err != nil {
-		log.Fatal(err)
+func run(rep *report) error {
+	var trainOverrides map[string]prer.TrainConfig
+	if *configFile != "" {
+		var err error
+		trainOverrides, err = loadConfig(*configFile, flag.CommandLine)
+		if err != nil {
+			return err
+		}
 	}
-
-	outf, err := os.OpenFile(fullPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
-	if err != nil {
-		log.Fatal(err)
+	// the flags could be set by the config file
+	rep.setHeader()
+	if names := deprecatedAutoMerge(flag.CommandLine); len(names) > 0 {
+		log.Printf("--%s is deprecated, use --auto_merge", strings.Join(names, " and --"))
+		*autoMerge = true
 	}
-	defer outf.Close()
-
-	_, err = fasttemplate.Execute(string(template), "{{", "}}", outf, ctx)
-	if err != nil {
-		log.Fatal(err)
-	}
-}
-
-func main() {
-	flag.Parse()
 	if *workspace != "" {
 		if err := os.Chdir(*workspace); err != nil {
-			log.Fatal(err)
+			return err
 		}
 	}
 	if len(gitopsKind) == 0 {
 		gitopsKind = []string{"k8s_container_push"}
 	}
-
-	var gitServer git.Server
-	switch *gitHost {
-	case "github":
-		gitServer = git.ServerFunc(github.CreatePR)
-	case "gitlab":
-		gitServer = git.ServerFunc(gitlab.CreatePR)
-	case "bitbucket":
-		gitServer = git.ServerFunc(bitbucket.CreatePR)
-	default:
-		log.Fatalf("unknown vcs host: %s", *gitHost)
+	incremental := *incrementalRange != "" || *incrementalFiles != ""
+	var changed []string
+	if incremental {
+		var err error
+		if changed, err = changedFiles(); err != nil {
+			return err
+		}
 	}
 
-	q := fmt.Sprintf("attr(deployment_branch, \".+\", attr(release_branch_prefix, \"%s\", kind(gitops, %s)))", *releaseBranch, *target)
-	qr := bazelQuery(q)
-	releaseTrains := make(map[string][]string)
-	for _, t := range qr.Results {
-		var releaseTrain string
-		for _, a := range t.Target.GetRule().GetAttribute() {
-			if a.GetName() == "deployment_branch" {
-				releaseTrain = a.GetStringValue()
-			}
-		}
-		releaseTrains[releaseTrain] = append(releaseTrains[releaseTrain], t.Target.Rule.GetName())
+	gitServer, err := hosting.Server(*gitHost)
+	if err != nil {
+		return err
 	}
-	if (len(releaseTrains)) == 0 {
-		log.Println("No matching targets found")
-		return
+	clone, err := hosting.Cloner(*gitBackend, *gitHost)
+	if err != nil {
+		return err
 	}
 
-	for train, targets := range releaseTrains {
-		fmt.Println(train)
-		for _, t := range targets {
-			fmt.Println(" ", t)
+	querier := &bazel.ExecQuerier{BazelCmd: *bazelCmd, Output: *queryOutput}
+	ctx := context.Background()
+	// GC queries the release trains of all the release branches
+	var releaseTrains map[string][]string
+	if !*gc {
+		releaseTrains, err = prer.QueryReleaseTrains(ctx, querier, *releaseBranch, *target)
+		if err != nil {
+			return err
+		}
+		if len(releaseTrains) == 0 {
+			log.Println("No matching targets found")
+			return nil
 		}
 	}
 
 	gitopsdir, err := ioutil.TempDir(*gitopsTmpDir, "gitops")
 	if err != nil {
-		log.Fatalf("Unable to create tempdir in %s: %v", *gitopsTmpDir, err)
+		return fmt.Errorf("Unable to create tempdir in %s: %w", *gitopsTmpDir, err)
 	}
 	defer os.RemoveAll(gitopsdir)
-	workdir, err := git.Clone(*repo, gitopsdir, *gitMirror, *prInto, *gitopsPath)
+	workdir, err := clone(*repo, gitopsdir, *gitMirror, *prInto, *gitopsPath)
 	if err != nil {
-		log.Fatalf("Unable to clone repo: %v", err)
+		return fmt.Errorf("Unable to clone repo: %w", err)
 	}
-	workdir.Fetch(*deploymentBranchPrefix + "*")
-
-	var updatedGitopsTargets []string
-	var updatedGitopsBranches []string
 
-	for train, targets := range releaseTrains {
-		log.Println("train", train)
-		branch := *deploymentBranchPrefix + train + *deploymentBranchSuffix
-		newBranch := workdir.SwitchToBranch(branch, *prInto)
-		if !newBranch {
-			// Find if we need to recreate the branch because target was deleted
-			msg := workdir.GetLastCommitMessage()
-			targetset := make(map[string]bool)
-			for _, t := range targets {
-				targetset[t] = true
-			}
-			oldtargets := commitmsg.ExtractTargets(msg)
-			for _, t := range oldtargets {
-				if !targetset[t] {
-					// target t is not present in a new list
-					workdir.RecreateBranch(branch, *prInto)
-					break
-				}
-			}
-		}
-		for _, target := range targets {
-			log.Println("train", train, "target", target)
-			bin := bazel.TargetToExecutable(target)
-			exec.Mustex("", bin, "--nopush", "--nobazel", "--deployment_root", gitopsdir)
-		}
-		if *stamp {
-			changedFiles := workdir.GetChangedFiles()
-			if len(changedFiles) > 0 {
-				ctx := getGitStatusDict(workdir, *gitCommit, *branchName)
-				for _, filePath := range changedFiles {
-					fullPath := gitopsdir + "/" + filePath
-					if digester.VerifyDigest(fullPath) {
-						workdir.RestoreFile(fullPath)
-					} else {
-						digester.SaveDigest(fullPath)
-						stampFile(fullPath, ctx)
-					}
-				}
-			}
-		}
-		if workdir.Commit(fmt.Sprintf("GitOps for release branch %s from %s commit %s\n%s", *releaseBranch, *branchName, *gitCommit, commitmsg.Generate(targets)), *gitopsPath) {
-			log.Println("branch", branch, "has changes, push is required")
-			updatedGitopsTargets = append(updatedGitopsTargets, targets...)
-			updatedGitopsBranches = append(updatedGitopsBranches, branch)
-		}
+	if *gc {
+		rep.StaleBranches, err = prer.GC(ctx, prer.GCOptions{
+			Target:                 *target,
+			PartialTarget:          *gcPartialTarget,
+			DeploymentBranchPrefix: *deploymentBranchPrefix,
+			DeploymentBranchSuffix: *deploymentBranchSuffix,
+			MinAge:                 *gcMinAge,
+			DryRun:                 *dryRun,
+			Querier:                querier,
+			Repo:                   workdir,
+			Server:                 gitServer,
+		})
+		return err
 	}
-	if len(updatedGitopsTargets) == 0 {
-		log.Println("No gitops changes to push")
-		return
+
+	runner := &prer.ExecRunner{}
+	if *resolveExecutables {
+		runner.BazelCmd = *bazelCmd
 	}
+	res, err := prer.Run(ctx, prer.Options{
+		ReleaseBranch:          *releaseBranch,
+		Target:                 *target,
+		GitopsPath:             *gitopsPath,
+		PRInto:                 *prInto,
+		PRTitle:                *prTitle,
+		PRBody:                 *prBody,
+		MaxBodySize:            *prBodyMaxSize,
+		BranchName:             *branchName,
+		GitCommit:              *gitCommit,
+		SourceRepo:             sourceRepoURL(),
+		DeploymentBranchPrefix: *deploymentBranchPrefix,
+		DeploymentBranchSuffix: *deploymentBranchSuffix,
+		GitopsKinds:            gitopsKind,
+		GitopsRuleNames:        gitopsRuleName,
+		GitopsRuleAttrs:        gitopsRuleAttr,
+		PushParallelism:        *pushParallelism,
+		TrainParallelism:       *trainParallelism,
+		Stamp:                  *stamp,
+		DryRun:                 *dryRun,
+		ForceWithLease:         *forceWithLease,
+		PushAttempts:           *pushAttempts,
+		Prune:                  *prune,
+		MaxPrunedFiles:         *pruneMaxFiles,
+		AutoMerge:              *autoMerge,
+		MergeMethod:            *mergeMethod,
+		Incremental:            incremental,
+		ChangedFiles:           changed,
+		Trains:                 trainOverrides,
+		ReleaseTrains:          releaseTrains,
+		Querier:                querier,
+		IncrementalQuerier:     &bazel.ExecQuerier{BazelCmd: *bazelCmd, Output: *queryOutput, Args: []string{"--keep_going"}},
+		ImageDigest:            runner.ImageDigest,
+		Runner:                 runner,
+		Repo:                   workdir,
+		Server:                 gitServer,
+	})
+	rep.Result = *res
+	return err
+}
 
-	// Push images
+// deprecatedAutoMergeFlags are the git server flags replaced by --auto_merge
+var deprecatedAutoMergeFlags = []string{"azure_auto_complete", "gitlab_merge_when_pipeline_succeeds"}
 
-	// Create space separated set('//a' '//b' ... '//z') of targets.
-	// Target names need to be quoted to protect from + and other special characters
-	depsList := "set('" + strings.Join(updatedGitopsTargets, "' '") + "')"
-	var qv []string
-	for _, kind := range gitopsKind {
-		q := fmt.Sprintf("kind(%s, deps(%s))", kind, depsList)
-		qv = append(qv, q)
-	}
-	for _, name := range gitopsRuleName {
-		q := fmt.Sprintf("filter(%s, deps(%s))", name, depsList)
-		qv = append(qv, q)
-	}
-	for _, attr := range gitopsRuleAttr {
-		name, value, found := strings.Cut(attr, "=")
-		if !found {
-			value = ".*"
+// deprecatedAutoMerge returns the deprecated auto-merge flags of fs set to true
+func deprecatedAutoMerge(fs *flag.FlagSet) []string {
+	var names []string
+	for _, name := range deprecatedAutoMergeFlags {
+		if f := fs.Lookup(name); f != nil && f.Value.String() == "true" {
+			names = append(names, name)
 		}
-		q := fmt.Sprintf("attr(%s, %s, deps(%s))", name, value, depsList)
-		qv = append(qv, q)
 	}
+	return names
+}
 
-	query := strings.Join(qv, " union ")
-	qr = bazelQuery(query)
-	targetsCh := make(chan string)
-	var wg sync.WaitGroup
-	wg.Add(*pushParallelism)
-	for i := 0; i < *pushParallelism; i++ {
-		go func() {
-			defer wg.Done()
-			for target := range targetsCh {
-				bin := bazel.TargetToExecutable(target)
-				exec.Mustex("", bin)
-			}
-		}()
+// setHeader sets the fields of the report describing the run from the flags
+func (r *report) setHeader() {
+	r.ReleaseBranch = *releaseBranch
+	r.BranchName = *branchName
+	r.GitCommit = *gitCommit
+	r.DryRun = *dryRun
+}
+
+// sourceRepoURL returns --source_repo or --git_repo without credentials
+func sourceRepoURL() string {
+	if *sourceRepo != "" {
+		return *sourceRepo
 	}
-	for _, t := range qr.Results {
-		targetsCh <- t.Target.Rule.GetName()
+	u, err := url.Parse(*repo)
+	if err != nil || u.User == nil {
+		return *repo
 	}
-	close(targetsCh)
-	wg.Wait()
+	u.User = nil
+	return u.String()
+}
 
-	if *dryRun {
-		log.Println("dry-run: updated gitops branches: ", updatedGitopsBranches)
-		log.Println("dry-run: skipping push")
-	} else {
-		workdir.Push(updatedGitopsBranches)
+// changedFiles returns the workspace files of --incremental_files or changed in --incremental_range
+func changedFiles() ([]string, error) {
+	if *incrementalRange != "" && *incrementalFiles != "" {
+		return nil, errors.New("--incremental_range and --incremental_files are exclusive")
 	}
-
-	for _, branch := range updatedGitopsBranches {
-		if *dryRun {
-			log.Println("dry-run: skipping PR creation: branch ", branch, "into ", *prInto)
-			continue
-		}
-
-		title := *prTitle
-		if title == "" {
-			title = fmt.Sprintf("GitOps deployment %s", branch)
+	if *incrementalFiles != "" {
+		var b []byte
+		var err error
+		if *incrementalFiles == "-" {
+			b, err = io.ReadAll(os.Stdin)
+		} else {
+			b, err = os.ReadFile(*incrementalFiles)
 		}
-
-		body := *prBody
-		if body == "" {
-			body = branch
+		if err != nil {
+			return nil, err
 		}
-
-		if err := gitServer.CreatePR(branch, *prInto, title, body); err != nil {
-			log.Fatal("unable to create PR: ", err)
+		var files []string
+		for _, line := range strings.Split(string(b), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				files = append(files, line)
+			}
 		}
+		return files, nil
+	}
+	base, head, _ := strings.Cut(*incrementalRange, "..")
+	if head == "" {
+		head = "HEAD"
+	}
+	// the workspace could be a subdirectory of the repository, git diff reports the paths relative to the repository root
+	prefix, err := exec.Ex(".", "git", "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, fmt.Errorf("unable to find the workspace in the git repository: %w", err)
+	}
+	prefix = strings.TrimSpace(prefix)
+	changes, err := (&git.ExecRepo{Dir: "."}).Diff(base, head, "")
+	if err != nil {
+		return nil, fmt.Errorf("unable to find the files changed in %s: %w", *incrementalRange, err)
+	}
+	files := make([]string, 0, len(changes))
+	for _, ch := range changes {
+		// the path is restricted to the workspace
+		files = append(files, strings.TrimPrefix(ch.Path, prefix))
 	}
+	return files, nil
 }
@@ -0,0 +1,283 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package prer
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	diff "github.com/adobe/rules_gitops/gitops/diff/pkg"
+	"github.com/adobe/rules_gitops/gitops/git"
+)
+
+// DefaultMaxBodySize is the default size limit of the generated PR description.
+// It fits GitHub, GitLab, Gitea and Bitbucket limits, Azure DevOps allows 4000 characters only.
+const DefaultMaxBodySize = 30000
+
+// truncatedNote ends the generated PR description exceeding the size limit
+const truncatedNote = "\n_The summary is truncated, see the pull request changes._\n"
+
+// fileSummary describes the Kubernetes objects changed in a file
+type fileSummary struct {
+	Path    string
+	Status  string
+	Objects []diff.Change
+	// Note explains why the objects are not listed
+	Note string
+}
+
+// imageChange is an image repository used with different tags or digests, "-" if it is not used
+type imageChange struct {
+	Image, Old, New string
+}
+
+// describeTrain returns the Markdown description of the deployment PR.
+// It summarizes the changes of the Kubernetes objects and images between the PR target and the deployment branch.
+func (opts *Options) describeTrain(tr *TrainResult) (string, error) {
+	files, images, err := opts.summarize(opts.baseRevision(tr.Into), tr.Branch)
+	if err != nil {
+		return "", err
+	}
+	header := fmt.Sprintf("GitOps deployment of `%s` into `%s` from branch `%s` commit `%s`.", tr.Branch, tr.Into, opts.BranchName, opts.GitCommit)
+	return opts.renderBody(header, tr.Targets, files, images), nil
+}
+
+// summarize returns the changes of the Kubernetes objects and images in GitopsPath from base to branch
+func (opts *Options) summarize(base, branch string) ([]fileSummary, []imageChange, error) {
+	changes, err := opts.Repo.Diff(base, branch, opts.GitopsPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	var files []fileSummary
+	oldImages := make(map[string]map[string]bool)
+	newImages := make(map[string]map[string]bool)
+	for _, ch := range changes {
+		if !diff.IsManifest(ch.Path) {
+			files = append(files, fileSummary{Path: ch.Path, Status: ch.Status, Note: "not a Kubernetes manifest"})
+			continue
+		}
+		var oldObjects, newObjects diff.Objects
+		var oldErr, newErr error
+		if ch.Status != git.FileAdded {
+			if oldObjects, oldErr = opts.readObjects(base, ch.Path); oldErr != nil && !isParseError(oldErr) {
+				return nil, nil, oldErr
+			}
+		}
+		if ch.Status != git.FileDeleted {
+			if newObjects, newErr = opts.readObjects(branch, ch.Path); newErr != nil && !isParseError(newErr) {
+				return nil, nil, newErr
+			}
+		}
+		fs := fileSummary{Path: ch.Path, Status: ch.Status}
+		switch {
+		case oldErr != nil:
+			fs.Note = oldErr.Error()
+		case newErr != nil:
+			fs.Note = newErr.Error()
+		default:
+			fs.Objects = diff.Diff(oldObjects, newObjects, diff.Options{})
+			if len(oldObjects) == 0 && len(newObjects) == 0 {
+				fs.Note = "no Kubernetes objects"
+			} else if len(fs.Objects) == 0 {
+				fs.Note = "no object changes"
+			}
+		}
+		files = append(files, fs)
+		for _, obj := range oldObjects {
+			collectImages(obj, oldImages)
+		}
+		for _, obj := range newObjects {
+			collectImages(obj, newImages)
+		}
+	}
+	return files, diffImages(oldImages, newImages), nil
+}
+
+// baseRevision returns the revision the deployment branches into the branch are created from
+func (opts *Options) baseRevision(into string) string {
+	if into != opts.PRInto {
+		// only the primary branch is checked out locally
+		return opts.Repo.Remote() + "/" + into
+	}
+	return opts.PRInto
+}
+
+type parseError struct {
+	path string
+	err  error
+}
+
+func (e *parseError) Error() string {
+	return fmt.Sprintf("unable to parse %s: %v", e.path, e.err)
+}
+
+func isParseError(err error) bool {
+	_, ok := err.(*parseError)
+	return ok
+}
+
+// readObjects returns the Kubernetes objects of the manifests file in the revision
+func (opts *Options) readObjects(revision, path string) (diff.Objects, error) {
+	data, err := opts.Repo.ReadFile(revision, path)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := diff.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, &parseError{path: path, err: err}
+	}
+	return objects, nil
+}
+
+// collectImages adds the container images of the object to the tags or digests per image repository
+func collectImages(v interface{}, images map[string]map[string]bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, field := range v {
+			if image, ok := field.(string); ok && k == "image" && image != "" {
+				repo, ref := splitImage(image)
+				if images[repo] == nil {
+					images[repo] = make(map[string]bool)
+				}
+				images[repo][ref] = true
+				continue
+			}
+			collectImages(field, images)
+		}
+	case []interface{}:
+		for _, item := range v {
+			collectImages(item, images)
+		}
+	}
+}
+
+// splitImage splits the image reference into the repository and the tag or digest
+func splitImage(image string) (repo, ref string) {
+	if i := strings.Index(image, "@"); i >= 0 {
+		return image[:i], image[i+1:]
+	}
+	// the colon before the last slash separates the registry port
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+	return image, "latest"
+}
+
+// diffImages returns the image repositories with changed tags or digests sorted by repository
+func diffImages(old, new map[string]map[string]bool) []imageChange {
+	repos := make(map[string]bool)
+	for repo := range old {
+		repos[repo] = true
+	}
+	for repo := range new {
+		repos[repo] = true
+	}
+	var changes []imageChange
+	for repo := range repos {
+		o, n := joinRefs(old[repo]), joinRefs(new[repo])
+		if o != n {
+			changes = append(changes, imageChange{Image: repo, Old: o, New: n})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Image < changes[j].Image })
+	return changes
+}
+
+func joinRefs(refs map[string]bool) string {
+	if len(refs) == 0 {
+		return "-"
+	}
+	sorted := make([]string, 0, len(refs))
+	for ref := range refs {
+		sorted = append(sorted, ref)
+	}
+	sort.Strings(sorted)
+	return strings.Join(sorted, ", ")
+}
+
+// bodyWriter collects the description lines until the size limit is reached
+type bodyWriter struct {
+	b         strings.Builder
+	max       int
+	truncated bool
+}
+
+func (w *bodyWriter) line(format string, args ...interface{}) {
+	if w.truncated {
+		return
+	}
+	line := fmt.Sprintf(format, args...) + "\n"
+	if w.b.Len()+len(line)+len(truncatedNote) > w.max {
+		w.truncated = true
+		return
+	}
+	w.b.WriteString(line)
+}
+
+func (w *bodyWriter) String() string {
+	if w.truncated {
+		return w.b.String() + truncatedNote
+	}
+	return w.b.String()
+}
+
+// renderBody returns the Markdown description of the train changes up to MaxBodySize.
+// The files are listed last, they are truncated first.
+func (opts *Options) renderBody(header string, targets []string, files []fileSummary, images []imageChange) string {
+	max := opts.MaxBodySize
+	if max <= 0 {
+		max = DefaultMaxBodySize
+	}
+	w := &bodyWriter{max: max}
+	w.line("%s", header)
+	if len(targets) > 0 {
+		w.line("")
+		w.line("### Targets")
+		w.line("")
+		for _, t := range targets {
+			w.line("- `%s`", t)
+		}
+	}
+	if len(images) > 0 {
+		w.line("")
+		w.line("### Images")
+		w.line("")
+		w.line("| Image | Old | New |")
+		w.line("| --- | --- | --- |")
+		for _, img := range images {
+			w.line("| `%s` | %s | %s |", img.Image, img.Old, img.New)
+		}
+	}
+	w.line("")
+	w.line("### Changes")
+	if len(files) == 0 {
+		w.line("")
+		w.line("No changes in `%s`.", opts.GitopsPath)
+	}
+	for _, f := range files {
+		w.line("")
+		w.line("#### `%s` (%s)", f.Path, f.Status)
+		w.line("")
+		if f.Note != "" {
+			w.line("_%s_", f.Note)
+			continue
+		}
+		w.line("| Change | Kind | Namespace | Name |")
+		w.line("| --- | --- | --- | --- |")
+		for _, obj := range f.Objects {
+			w.line("| %s | %s | %s | %s |", obj.Status, obj.Key.Kind, obj.Key.Namespace, obj.Key.Name)
+		}
+	}
+	return w.String()
+}
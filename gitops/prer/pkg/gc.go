@@ -0,0 +1,173 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package prer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/adobe/rules_gitops/gitops/git"
+)
+
+// Stale deployment branch statuses
+const (
+	// StaleDeleted is a stale branch deleted in the remote repository
+	StaleDeleted = "deleted"
+	// StaleDryRun is a stale branch which would be deleted without DryRun
+	StaleDryRun = "dry_run"
+	// StaleRecent is an orphaned branch updated within MinAge, it is kept
+	StaleRecent = "recent"
+	// StaleFailed is a stale branch which could not be cleaned up
+	StaleFailed = "failed"
+)
+
+// DefaultTarget selects the gitops targets of the workspace, it is the default target of create_gitops_prs
+const DefaultTarget = "//... except //experimental/..."
+
+// GCOptions configures GC
+type GCOptions struct {
+	// Target selects the current release trains of all the release branches, see QueryReleaseTrains.
+	// Target is DefaultTarget if empty. The release trains of the gitops targets outside of Target look orphaned,
+	// so GC fails for a Target other than DefaultTarget or //... unless PartialTarget is set.
+	Target                 string
+	PartialTarget          bool
+	DeploymentBranchPrefix string
+	DeploymentBranchSuffix string
+	// MinAge is the time since the last commit of an orphaned deployment branch before it is deleted
+	MinAge time.Duration
+	// DryRun reports the stale branches without closing PRs and deleting branches
+	DryRun bool
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+	// ReleaseTrains are the current release trains of all the release branches. Queried by GC if nil.
+	ReleaseTrains map[string][]string
+
+	Querier Querier
+	Repo    git.Repo
+	Server  git.Server
+}
+
+// StaleBranch describes a deployment branch of a release train which does not exist anymore
+type StaleBranch struct {
+	Branch    string    `json:"branch"`
+	Train     string    `json:"train"`
+	Committed time.Time `json:"committed"`
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason,omitempty"`
+	// PRNumber and PRURL describe the open pull request of the branch, closed unless DryRun
+	PRNumber int    `json:"pr_number,omitempty"`
+	PRURL    string `json:"pr_url,omitempty"`
+}
+
+// GC closes the pull requests and deletes the deployment branches of the release trains not returned by the query,
+// which is not filtered by the release branch as the deployment branches of all the release branches share the prefix,
+// unless they were updated within MinAge. Only the branches with DeploymentBranchPrefix and DeploymentBranchSuffix are considered.
+// Returns the orphaned branches along with an error if any of them could not be cleaned up.
+func GC(ctx context.Context, opts GCOptions) ([]StaleBranch, error) {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	if (opts.ReleaseTrains == nil && opts.Querier == nil) || opts.Repo == nil || opts.Server == nil {
+		return nil, errors.New("Querier, Repo and Server options are required")
+	}
+	if opts.Target == "" {
+		opts.Target = DefaultTarget
+	}
+	if opts.Target != DefaultTarget && opts.Target != "//..." && !opts.PartialTarget {
+		return nil, fmt.Errorf("refusing to delete the deployment branches of the release trains outside of the target %s", opts.Target)
+	}
+	releaseTrains := opts.ReleaseTrains
+	if releaseTrains == nil {
+		var err error
+		releaseTrains, err = QueryReleaseTrains(ctx, opts.Querier, "", opts.Target)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// a broken query should not look like all the release trains were removed
+	if len(releaseTrains) == 0 {
+		return nil, errors.New("no release trains found, refusing to delete all deployment branches")
+	}
+
+	if err := opts.Repo.Fetch(opts.DeploymentBranchPrefix + "*"); err != nil {
+		return nil, err
+	}
+	branches, err := opts.Repo.RemoteBranches(opts.DeploymentBranchPrefix)
+	if err != nil {
+		return nil, err
+	}
+	var stale []StaleBranch
+	var failed []string
+	for _, b := range branches {
+		if !strings.HasSuffix(b.Name, opts.DeploymentBranchSuffix) {
+			continue
+		}
+		train := strings.TrimSuffix(strings.TrimPrefix(b.Name, opts.DeploymentBranchPrefix), opts.DeploymentBranchSuffix)
+		if _, ok := releaseTrains[train]; ok {
+			continue
+		}
+		sb := StaleBranch{Branch: b.Name, Train: train, Committed: b.Committed}
+		if age := opts.Now().Sub(b.Committed); age < opts.MinAge {
+			log.Printf("Keeping orphaned branch %s updated %v ago", b.Name, age.Round(time.Second))
+			sb.Status = StaleRecent
+		} else {
+			opts.cleanUp(&sb)
+		}
+		if sb.Status == StaleFailed {
+			failed = append(failed, fmt.Sprintf("%s (%s)", sb.Branch, sb.Reason))
+		}
+		stale = append(stale, sb)
+	}
+	if len(failed) > 0 {
+		return stale, fmt.Errorf("unable to clean up %s", strings.Join(failed, ", "))
+	}
+	return stale, nil
+}
+
+// cleanUp closes the open pull request of the stale branch and deletes the branch
+func (opts *GCOptions) cleanUp(sb *StaleBranch) {
+	pr, err := opts.Server.FindPR(sb.Branch)
+	switch {
+	case errors.Is(err, git.ErrNotSupported):
+		log.Printf("Unable to find the pull request of %s: %v", sb.Branch, err)
+	case err != nil:
+		sb.Status, sb.Reason = StaleFailed, err.Error()
+		return
+	case pr != nil:
+		sb.PRNumber, sb.PRURL = pr.Number, pr.URL
+	}
+	if opts.DryRun {
+		log.Printf("Dry run: stale branch %s would be deleted, pull request: %s", sb.Branch, sb.PRURL)
+		sb.Status = StaleDryRun
+		return
+	}
+	if sb.PRNumber != 0 {
+		log.Printf("Closing pull request %d of stale branch %s", sb.PRNumber, sb.Branch)
+		err := opts.Server.ClosePR(sb.PRNumber)
+		if errors.Is(err, git.ErrNotSupported) {
+			log.Printf("Unable to close pull request %d: %v", sb.PRNumber, err)
+		} else if err != nil {
+			sb.Status, sb.Reason = StaleFailed, err.Error()
+			return
+		}
+	}
+	log.Printf("Deleting stale branch %s", sb.Branch)
+	if err := opts.Repo.DeleteRemoteBranch(sb.Branch); err != nil {
+		sb.Status, sb.Reason = StaleFailed, err.Error()
+		return
+	}
+	sb.Status = StaleDeleted
+}
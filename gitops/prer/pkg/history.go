@@ -0,0 +1,158 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package prer
+
+import (
+	"errors"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/adobe/rules_gitops/gitops/commitmsg"
+	"github.com/adobe/rules_gitops/gitops/git"
+)
+
+// HistoryOptions configures History
+type HistoryOptions struct {
+	// Targets select the gitops targets to report, all if empty
+	Targets []string
+	// GitopsPath is the location of the generated files in the repo
+	GitopsPath string
+	// PRInto is the branch with the merged deployments
+	PRInto string
+	// MaxHistory is the number of the most recent PRInto commits searched for the deployments, all if 0
+	MaxHistory int
+
+	Repo git.Repo
+}
+
+// TargetHistory is the sequence of the deployments of a gitops target, newest first
+type TargetHistory struct {
+	Target      string       `json:"target"`
+	Deployments []Deployment `json:"deployments"`
+}
+
+// Deployment is a PRInto commit changing the files of gitops targets
+type Deployment struct {
+	// Commit is the PRInto commit, Time is its commit time and Author is its author
+	Commit string    `json:"commit"`
+	Time   time.Time `json:"time"`
+	Author string    `json:"author"`
+	// SourceRepo, SourceBranch and SourceCommit identify the deployed change
+	SourceRepo   string `json:"source_repo,omitempty"`
+	SourceBranch string `json:"source_branch,omitempty"`
+	SourceCommit string `json:"source_commit,omitempty"`
+	// Images are the images of the gitops target recorded by the deployment
+	Images []commitmsg.Image `json:"images,omitempty"`
+	// RolledBack are the deployments reverted by a rollback, SourceCommit is the restored deployment then
+	RolledBack []string `json:"rolled_back,omitempty"`
+}
+
+// deployment is a PRInto commit with the metadata of the deployment
+type deployment struct {
+	git.Commit
+	Meta *commitmsg.Metadata
+}
+
+// legacySource matches the subject of the deployment commits written without the source metadata
+var legacySource = regexp.MustCompile(`^GitOps for release branch \S+ from (\S+) commit (\S+)$`)
+
+// History returns the deployments of the gitops targets merged into PRInto sorted by target.
+// The deployments are found by the gitops targets in the commit messages like in Rollback.
+func History(opts HistoryOptions) ([]TargetHistory, error) {
+	if opts.Repo == nil {
+		return nil, errors.New("Repo option is required")
+	}
+	commits, err := deploymentCommits(opts.Repo, opts.PRInto, opts.GitopsPath, opts.MaxHistory)
+	if err != nil {
+		return nil, err
+	}
+	wanted := make(map[string]bool)
+	for _, t := range opts.Targets {
+		wanted[t] = true
+	}
+	byTarget := make(map[string][]Deployment)
+	for _, d := range commits {
+		dep := Deployment{
+			Commit:       d.Hash,
+			Time:         d.Committed,
+			Author:       d.Author,
+			SourceRepo:   d.Meta.SourceRepo,
+			SourceBranch: d.Meta.SourceBranch,
+			SourceCommit: d.Meta.SourceCommit,
+			RolledBack:   d.Meta.RolledBack,
+		}
+		for _, t := range d.Meta.Targets {
+			if len(wanted) == 0 || wanted[t] {
+				dep.Images = d.Meta.Images[t]
+				byTarget[t] = append(byTarget[t], dep)
+			}
+		}
+	}
+	history := make([]TargetHistory, 0, len(byTarget))
+	for t, deployments := range byTarget {
+		history = append(history, TargetHistory{Target: t, Deployments: deployments})
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].Target < history[j].Target })
+	return history, nil
+}
+
+// deploymentCommits returns the first parent commits of PRInto changing the gitops path with their metadata, newest first.
+// The metadata of a merge commit without gitops targets is read from the merged deployment branch commit.
+// The commits with a malformed metadata block, like a hand edited message, are skipped.
+func deploymentCommits(repo git.Repo, into, gitopsPath string, max int) ([]deployment, error) {
+	commits, err := repo.Log(into, gitopsPath, max)
+	if err != nil {
+		return nil, err
+	}
+	deployments := make([]deployment, 0, len(commits))
+	for _, c := range commits {
+		msg := c.Message
+		meta, err := commitmsg.Parse(msg)
+		if err != nil {
+			log.Printf("Skipping commit %s: %v", c.Hash, err)
+			continue
+		}
+		if len(meta.Targets) == 0 && len(meta.RolledBack) == 0 && len(c.Parents) > 1 {
+			// the merge commit of the deployment branch
+			merged, err := repo.Log(c.Parents[1], gitopsPath, 1)
+			if err != nil {
+				return nil, err
+			}
+			if len(merged) > 0 {
+				msg = merged[0].Message
+				if meta, err = commitmsg.Parse(msg); err != nil {
+					log.Printf("Skipping commit %s merging %s: %v", c.Hash, merged[0].Hash, err)
+					continue
+				}
+			}
+		}
+		if meta.SourceCommit == "" {
+			subject, _, _ := strings.Cut(msg, "\n")
+			if m := legacySource.FindStringSubmatch(subject); m != nil {
+				meta.SourceBranch, meta.SourceCommit = m[1], m[2]
+			}
+		}
+		deployments = append(deployments, deployment{Commit: c, Meta: meta})
+	}
+	return deployments, nil
+}
+
+// ShortHash abbreviates a commit hash to 12 characters for the branch names and the reports
+func ShortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
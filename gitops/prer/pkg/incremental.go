@@ -0,0 +1,132 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package prer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/adobe/rules_gitops/gitops/bazel"
+)
+
+// keepGoingExitCode is the bazel exit code of the partial query results with --keep_going
+const keepGoingExitCode = 3
+
+// buildFiles change the build graph, the release trains affected by them can not be found by rdeps
+var buildFiles = map[string]bool{
+	"BUILD":           true,
+	"BUILD.bazel":     true,
+	"WORKSPACE":       true,
+	"WORKSPACE.bazel": true,
+	"MODULE.bazel":    true,
+	".bazelrc":        true,
+	".bazelversion":   true,
+}
+
+// SourceLabels returns the labels of the changed files of the workspace, slash separated relative paths.
+// The files outside of the packages are not part of the build and skipped.
+// Returns a reason instead if all the release trains should be rendered: a build file changed or a file was deleted.
+func SourceLabels(workspace string, files []string) (labels []string, fullReason string) {
+	for _, f := range files {
+		f = path.Clean(filepath.ToSlash(f))
+		if buildFiles[path.Base(f)] || path.Ext(f) == ".bzl" {
+			return nil, fmt.Sprintf("build file %s changed", f)
+		}
+		if _, err := os.Stat(filepath.Join(workspace, filepath.FromSlash(f))); err != nil {
+			return nil, fmt.Sprintf("file %s deleted", f)
+		}
+		if pkg, ok := findPackage(workspace, path.Dir(f)); ok {
+			labels = append(labels, "//"+pkg+":"+strings.TrimPrefix(f, pkg+"/"))
+		}
+	}
+	sort.Strings(labels)
+	return labels, ""
+}
+
+// findPackage returns the package of the slash separated workspace directory
+func findPackage(workspace, dir string) (string, bool) {
+	for {
+		if dir == "." {
+			dir = ""
+		}
+		for _, name := range []string{"BUILD.bazel", "BUILD"} {
+			if fi, err := os.Stat(filepath.Join(workspace, filepath.FromSlash(dir), name)); err == nil && !fi.IsDir() {
+				return dir, true
+			}
+		}
+		if dir == "" {
+			return "", false
+		}
+		dir = path.Dir(dir)
+	}
+}
+
+// affectedTrains returns the sorted release trains with the gitops targets depending on ChangedFiles,
+// or all of them if the changes could affect any target or bazel fails to find the reverse dependencies
+func (opts *Options) affectedTrains(ctx context.Context, releaseTrains map[string][]string) ([]string, error) {
+	var trains []string
+	for train := range releaseTrains {
+		trains = append(trains, train)
+	}
+	sort.Strings(trains)
+	workspace := opts.Workspace
+	if workspace == "" {
+		workspace = "."
+	}
+	labels, reason := SourceLabels(workspace, opts.ChangedFiles)
+	if reason != "" {
+		log.Printf("Rendering all release trains: %s", reason)
+		return trains, nil
+	}
+	if len(labels) == 0 {
+		return nil, nil
+	}
+	query := fmt.Sprintf("kind(gitops, rdeps(%s, set('%s')))", opts.Target, strings.Join(labels, "' '"))
+	querier := opts.IncrementalQuerier
+	if querier == nil {
+		querier = opts.Querier
+	}
+	targets, err := querier.Query(ctx, query)
+	var qe *bazel.QueryError
+	switch {
+	case err == nil:
+	case errors.As(err, &qe) && qe.ExitCode == keepGoingExitCode && opts.IncrementalQuerier != nil:
+		// the changed files which are not targets, like the documentation, are skipped
+		log.Printf("Ignoring the errors of the reverse dependencies query: %v", err)
+	case errors.As(err, &qe):
+		log.Printf("Rendering all release trains: %v", err)
+		return trains, nil
+	default:
+		return nil, err
+	}
+	affected := make(map[string]bool)
+	for _, t := range targets {
+		affected[t.Name()] = true
+	}
+	var res []string
+	for _, train := range trains {
+		for _, t := range releaseTrains[train] {
+			if affected[t] {
+				res = append(res, train)
+				break
+			}
+		}
+	}
+	return res, nil
+}
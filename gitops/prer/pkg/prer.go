@@ -0,0 +1,1042 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+// Package prer implements the creation of GitOps pull requests:
+// it queries gitops targets grouped into release trains, renders them into deployment branches,
+// pushes the images and opens the pull requests.
+package prer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adobe/rules_gitops/gitops/bazel"
+	"github.com/adobe/rules_gitops/gitops/commitmsg"
+	"github.com/adobe/rules_gitops/gitops/digester"
+	"github.com/adobe/rules_gitops/gitops/exec"
+	"github.com/adobe/rules_gitops/gitops/git"
+	"github.com/adobe/rules_gitops/templating/fasttemplate"
+)
+
+// Release train branch statuses
+const (
+	// StatusNew is a new deployment branch created from the PR target branch
+	StatusNew = "new"
+	// StatusRecreated is a deployment branch reset to the PR target branch because a gitops target was removed
+	StatusRecreated = "recreated"
+	// StatusUpdated is an existing deployment branch with new changes
+	StatusUpdated = "updated"
+	// StatusUnchanged is a deployment branch without changes. Nothing is pushed for it
+	StatusUnchanged = "unchanged"
+)
+
+// Pull request auto-merge statuses
+const (
+	// AutoMergeEnabled is a PR that will be merged by the git server once the checks pass
+	AutoMergeEnabled = "enabled"
+	// AutoMergeNotSupported is a PR of a git server without auto-merge
+	AutoMergeNotSupported = "not_supported"
+	// AutoMergeFailed is a PR the git server refused to auto-merge
+	AutoMergeFailed = "failed"
+)
+
+// Querier runs bazel cquery, see bazel.ExecQuerier
+type Querier = bazel.Querier
+
+// Runner runs executables of bazel targets: gitops targets and image pushes
+type Runner interface {
+	Run(ctx context.Context, target string, args ...string) error
+}
+
+// TrainConfig holds the settings that could be overridden for a single release train.
+// Release trains are identified by the deployment_branch attribute of gitops targets.
+type TrainConfig struct {
+	PRInto      string `json:"gitops_pr_into,omitempty"`
+	PRTitle     string `json:"gitops_pr_title,omitempty"`
+	PRBody      string `json:"gitops_pr_body,omitempty"`
+	AutoMerge   *bool  `json:"auto_merge,omitempty"`
+	MergeMethod string `json:"merge_method,omitempty"`
+}
+
+// Options configures Run
+type Options struct {
+	// ReleaseBranch filters gitops targets by release_branch_prefix attribute
+	ReleaseBranch string
+	// Target is the query scope, like "//..."
+	Target string
+	// GitopsPath is the location of the generated files in the repo
+	GitopsPath string
+	// PRInto is the source branch for new deployment branches and the target for deployment PRs
+	PRInto  string
+	PRTitle string
+	// PRBody is the description of the PRs. If empty, the description summarizing the changes
+	// of the deployment branch is generated up to MaxBodySize bytes, DefaultMaxBodySize if not set.
+	PRBody      string
+	MaxBodySize int
+	// BranchName and GitCommit describe the source change in the commit messages and stamps.
+	// SourceRepo is the source repository recorded in the commit metadata.
+	BranchName             string
+	GitCommit              string
+	SourceRepo             string
+	DeploymentBranchPrefix string
+	DeploymentBranchSuffix string
+	// GitopsKinds, GitopsRuleNames and GitopsRuleAttrs select the dependencies of the updated gitops targets to push.
+	// GitopsRuleAttrs entries use attribute=value format.
+	GitopsKinds     []string
+	GitopsRuleNames []string
+	GitopsRuleAttrs []string
+	PushParallelism int
+	// TrainParallelism is the number of release trains updated concurrently in separate worktrees of Repo.
+	// The log output of every release train is written after the preceding trains are done.
+	TrainParallelism int
+	// ImageDigest returns the digest of the image pushed by the push target. Defaults to ImageDigest.
+	ImageDigest func(target string) string
+	// Stamp enables stamping of changed files with volatile information
+	Stamp bool
+	// DryRun disables deployment branches push and PR creation
+	DryRun bool
+	// ForceWithLease pushes the deployment branches only if they were not updated since fetched.
+	// The rejected branches are fetched, updated and pushed again up to PushAttempts times.
+	ForceWithLease bool
+	PushAttempts   int
+	// AutoMerge enables the auto-merge of the created or reused PRs with MergeMethod, one of git.MergeMethod constants.
+	// Failures to enable the auto-merge are recorded in the PR results.
+	AutoMerge   bool
+	MergeMethod string
+	// Prune deletes the files generated by the removed gitops targets, see Index.
+	// A release train fails instead if the run would delete more than MaxPrunedFiles in all the release trains,
+	// unless it is 0. Nothing is pushed then, as the release trains are updated before the push.
+	Prune          bool
+	MaxPrunedFiles int
+	// Incremental renders only the release trains with the gitops targets depending on ChangedFiles, found with rdeps.
+	// All the release trains are rendered if a build file changed or a file was deleted, see SourceLabels.
+	Incremental bool
+	// ChangedFiles are the workspace relative paths of the source files changed since the last run
+	ChangedFiles []string
+	// Workspace is the bazel workspace root, the current directory if empty
+	Workspace string
+	// Trains contains per release train overrides
+	Trains map[string]TrainConfig
+	// ReleaseTrains are gitops targets grouped by release train as returned by QueryReleaseTrains.
+	// Queried by Run if nil.
+	ReleaseTrains map[string][]string
+
+	Querier Querier
+	// IncrementalQuerier runs the reverse dependencies query of Incremental with --keep_going,
+	// so the changed files which are not bazel targets are skipped. Querier is used if nil.
+	IncrementalQuerier Querier
+	Runner             Runner
+	// Repo working copy location is passed to gitops targets as the deployment root
+	Repo   git.Repo
+	Server git.Server
+
+	// pruned counts the files pruned by the release trains of the run
+	pruned *pruneCounter
+	// pushTargets are the push targets of the gitops targets of the run, by gitops target
+	pushTargets map[string][]string
+}
+
+// Result describes the outcome of Run
+type Result struct {
+	Trains []TrainResult `json:"trains"`
+	Images []ImageResult `json:"images"`
+	// SkippedTrains are the release trains not affected by the changed files with Incremental
+	SkippedTrains []string `json:"skipped_trains,omitempty"`
+}
+
+// TrainResult describes the processing of a single release train
+type TrainResult struct {
+	Train        string      `json:"train"`
+	Branch       string      `json:"branch"`
+	Into         string      `json:"into"`
+	Targets      []string    `json:"targets"`
+	Status       string      `json:"status"`
+	ChangedFiles []string    `json:"changed_files,omitempty"`
+	PrunedFiles  []string    `json:"pruned_files,omitempty"`
+	Push         *PushResult `json:"push,omitempty"`
+	PR           *PRResult   `json:"pr,omitempty"`
+}
+
+// PushResult describes the push of a release train deployment branch
+type PushResult struct {
+	// Status is one of git.PushOK, git.PushUpToDate, git.PushRejected or git.PushFailed
+	Status   string `json:"status"`
+	Reason   string `json:"reason,omitempty"`
+	Attempts int    `json:"attempts"`
+}
+
+// PRResult describes the pull request created or reused for a release train
+type PRResult struct {
+	Number  int    `json:"number,omitempty"`
+	URL     string `json:"url,omitempty"`
+	Created bool   `json:"created"`
+	// Updated is true if the title and the description of the reused pull request were replaced
+	Updated bool `json:"updated,omitempty"`
+	// AutoMerge is the auto-merge status if it was requested for the release train
+	AutoMerge       string `json:"auto_merge,omitempty"`
+	AutoMergeReason string `json:"auto_merge_reason,omitempty"`
+}
+
+// ImageResult describes a pushed image
+type ImageResult struct {
+	Target string `json:"target"`
+	Digest string `json:"digest,omitempty"`
+}
+
+// Train returns the result of the release train using the deployment branch
+func (r *Result) Train(branch string) *TrainResult {
+	for i := range r.Trains {
+		if r.Trains[i].Branch == branch {
+			return &r.Trains[i]
+		}
+	}
+	return nil
+}
+
+func (tr *TrainResult) setPR(pr *git.PullRequest) {
+	if pr == nil {
+		return
+	}
+	tr.PR = &PRResult{
+		Number:  pr.Number,
+		URL:     pr.URL,
+		Created: pr.Created,
+	}
+}
+
+// BazelQuerier is a Querier running bazel cquery command
+type BazelQuerier = bazel.ExecQuerier
+
+// Resolver is implemented by the Runners locating the executables of the targets before running them
+type Resolver interface {
+	Resolve(ctx context.Context, targets []string) error
+}
+
+// ExecRunner is a Runner executing targets from bazel-bin of the current workspace.
+// If BazelCmd is set, Resolve finds the executables with bazel cquery, see bazel.Executables.
+// The executables of the targets not resolved are found with bazel.TargetToExecutable.
+type ExecRunner struct {
+	BazelCmd string
+
+	mu          sync.Mutex
+	executables map[string]string
+}
+
+// Resolve implements Resolver
+func (r *ExecRunner) Resolve(ctx context.Context, targets []string) error {
+	if r.BazelCmd == "" {
+		return nil
+	}
+	var unresolved []string
+	r.mu.Lock()
+	for _, t := range targets {
+		if _, ok := r.executables[bazel.MainLabel(t)]; !ok {
+			unresolved = append(unresolved, t)
+		}
+	}
+	r.mu.Unlock()
+	if len(unresolved) == 0 {
+		return nil
+	}
+	executables, err := bazel.Executables(ctx, r.BazelCmd, unresolved, nil)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.executables == nil {
+		r.executables = make(map[string]string)
+	}
+	for label, executable := range executables {
+		r.executables[label] = executable
+	}
+	return nil
+}
+
+// Executable returns the executable of the target relative to the workspace root
+func (r *ExecRunner) Executable(target string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if executable, ok := r.executables[bazel.MainLabel(target)]; ok {
+		return executable
+	}
+	return bazel.TargetToExecutable(target)
+}
+
+// Run implements Runner
+func (r *ExecRunner) Run(ctx context.Context, target string, args ...string) error {
+	_, err := exec.ExContext(ctx, "", r.Executable(target), args...)
+	return err
+}
+
+// ImageDigest returns the digest of the image pushed by the push target like the ImageDigest function,
+// next to the resolved executable of the push target
+func (r *ExecRunner) ImageDigest(target string) string {
+	return readDigest(r.Executable(target) + ".digest")
+}
+
+// ImageDigest returns the digest of the image pushed by the push target.
+// Returns empty string if the push target does not produce digest file in bazel-bin.
+func ImageDigest(target string) string {
+	return readDigest(bazel.TargetToExecutable(target) + ".digest")
+}
+
+func readDigest(name string) string {
+	digest, err := os.ReadFile(name)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(digest))
+}
+
+// Run queries the gitops targets, updates the deployment branches, pushes images and creates PRs.
+// The partial Result is returned along with an error.
+func Run(ctx context.Context, opts Options) (*Result, error) {
+	res := &Result{}
+	if opts.PushParallelism < 1 {
+		opts.PushParallelism = 1
+	}
+	if opts.PushAttempts < 1 {
+		opts.PushAttempts = 1
+	}
+	if opts.ImageDigest == nil {
+		opts.ImageDigest = ImageDigest
+	}
+	opts.pruned = &pruneCounter{}
+	if opts.Querier == nil || opts.Runner == nil || opts.Repo == nil || opts.Server == nil {
+		return res, errors.New("Querier, Runner, Repo and Server options are required")
+	}
+	if err := git.ValidMergeMethod(opts.MergeMethod); err != nil {
+		return res, err
+	}
+	for train, tc := range opts.Trains {
+		if err := git.ValidMergeMethod(tc.MergeMethod); err != nil {
+			return res, fmt.Errorf("release train %s: %w", train, err)
+		}
+	}
+
+	releaseTrains := opts.ReleaseTrains
+	if releaseTrains == nil {
+		var err error
+		releaseTrains, err = QueryReleaseTrains(ctx, opts.Querier, opts.ReleaseBranch, opts.Target)
+		if err != nil {
+			return res, err
+		}
+		// the release trains of the other indexes are needed to prune
+		opts.ReleaseTrains = releaseTrains
+	}
+	if len(releaseTrains) == 0 {
+		log.Println("No matching targets found")
+		return res, nil
+	}
+	trains := make([]string, 0, len(releaseTrains))
+	for train := range releaseTrains {
+		trains = append(trains, train)
+	}
+	sort.Strings(trains)
+	if opts.Incremental {
+		affected, err := opts.affectedTrains(ctx, releaseTrains)
+		if err != nil {
+			return res, err
+		}
+		isAffected := make(map[string]bool)
+		for _, train := range affected {
+			isAffected[train] = true
+		}
+		for _, train := range trains {
+			if !isAffected[train] {
+				res.SkippedTrains = append(res.SkippedTrains, train)
+			}
+		}
+		log.Printf("%d of %d release trains are affected by %d changed files", len(affected), len(trains), len(opts.ChangedFiles))
+		trains = affected
+		if len(trains) == 0 {
+			return res, nil
+		}
+	}
+	for _, train := range trains {
+		fmt.Println(train)
+		for _, t := range releaseTrains[train] {
+			fmt.Println(" ", t)
+		}
+	}
+
+	if err := opts.Repo.Fetch(opts.DeploymentBranchPrefix + "*"); err != nil {
+		return res, err
+	}
+	for _, tc := range opts.Trains {
+		if tc.PRInto != "" && tc.PRInto != opts.PRInto {
+			if err := opts.Repo.Fetch(tc.PRInto); err != nil {
+				return res, err
+			}
+		}
+	}
+
+	var gitopsTargets []string
+	for _, train := range trains {
+		gitopsTargets = append(gitopsTargets, releaseTrains[train]...)
+	}
+	var err error
+	// the push targets are queried once, the trains record their images and the updated ones push them
+	if opts.pushTargets, err = opts.queryPushTargets(ctx, gitopsTargets); err != nil {
+		return res, err
+	}
+	if err := opts.resolve(ctx, append(gitopsTargets, pushTargets(opts.pushTargets, gitopsTargets)...)); err != nil {
+		return res, err
+	}
+	res.Trains, err = opts.updateTrains(ctx, trains, releaseTrains)
+	if err != nil {
+		return res, err
+	}
+	var updatedGitopsTargets []string
+	var updatedGitopsBranches []string
+	for _, tr := range res.Trains {
+		if tr.Status != StatusUnchanged {
+			updatedGitopsTargets = append(updatedGitopsTargets, tr.Targets...)
+			updatedGitopsBranches = append(updatedGitopsBranches, tr.Branch)
+		}
+	}
+	if len(updatedGitopsTargets) == 0 {
+		log.Println("No gitops changes to push")
+		return res, nil
+	}
+
+	images, err := opts.pushImages(ctx, pushTargets(opts.pushTargets, updatedGitopsTargets))
+	res.Images = images
+	if err != nil {
+		return res, err
+	}
+
+	pushedBranches := updatedGitopsBranches
+	if opts.DryRun {
+		log.Println("dry-run: updated gitops branches: ", updatedGitopsBranches)
+		log.Println("dry-run: skipping push")
+	} else {
+		pushedBranches, err = opts.pushBranches(ctx, res, updatedGitopsBranches)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	for _, branch := range pushedBranches {
+		tr := res.Train(branch)
+		tc := opts.Trains[tr.Train]
+		if opts.DryRun {
+			log.Println("dry-run: skipping PR creation: branch ", branch, "into ", tr.Into)
+			continue
+		}
+
+		title := tc.PRTitle
+		if title == "" {
+			title = opts.PRTitle
+		}
+		if title == "" {
+			title = fmt.Sprintf("GitOps deployment %s", branch)
+		}
+
+		body := tc.PRBody
+		if body == "" {
+			body = opts.PRBody
+		}
+		if body == "" {
+			if body, err = opts.describeTrain(tr); err != nil {
+				log.Printf("Unable to describe the changes of %s: %v", branch, err)
+				body = branch
+			}
+		}
+
+		pr, err := opts.Server.CreatePR(branch, tr.Into, title, body)
+		if err != nil {
+			return res, fmt.Errorf("unable to create PR: %w", err)
+		}
+		tr.setPR(pr)
+		if pr == nil || pr.Number == 0 {
+			continue
+		}
+		if !pr.Created {
+			// the reused PR describes the previous run
+			err = opts.Server.UpdatePR(pr.Number, title, body)
+			switch {
+			case errors.Is(err, git.ErrNotSupported):
+				log.Printf("Unable to update PR %d: %v", pr.Number, err)
+			case err != nil:
+				return res, fmt.Errorf("unable to update PR %d: %w", pr.Number, err)
+			default:
+				tr.PR.Updated = true
+			}
+		}
+		if autoMerge, method := opts.autoMerge(tc); autoMerge {
+			opts.enableAutoMerge(tr.PR, method)
+		}
+	}
+	return res, nil
+}
+
+// autoMerge returns whether the auto-merge is enabled for the release train and the merge method
+func (opts *Options) autoMerge(tc TrainConfig) (bool, string) {
+	autoMerge, method := opts.AutoMerge, opts.MergeMethod
+	if tc.AutoMerge != nil {
+		autoMerge = *tc.AutoMerge
+	}
+	if tc.MergeMethod != "" {
+		method = tc.MergeMethod
+	}
+	return autoMerge, method
+}
+
+// enableAutoMerge enables the auto-merge of the PR. The failure does not stop the run, it is recorded in the result.
+func (opts *Options) enableAutoMerge(pr *PRResult, method string) {
+	err := opts.Server.EnableAutoMerge(pr.Number, method)
+	switch {
+	case errors.Is(err, git.ErrNotSupported):
+		pr.AutoMerge = AutoMergeNotSupported
+		pr.AutoMergeReason = err.Error()
+	case err != nil:
+		pr.AutoMerge = AutoMergeFailed
+		pr.AutoMergeReason = err.Error()
+	default:
+		pr.AutoMerge = AutoMergeEnabled
+		log.Printf("Enabled auto-merge of PR %d", pr.Number)
+		return
+	}
+	log.Printf("Unable to enable auto-merge of PR %d: %v", pr.Number, err)
+}
+
+// pushBranches pushes the deployment branches and records the push results.
+// With ForceWithLease the branches rejected because of concurrent updates are fetched again,
+// updated on top of the remote branch and pushed again. Returns the pushed branches.
+func (opts *Options) pushBranches(ctx context.Context, res *Result, branches []string) ([]string, error) {
+	mode := git.PushForce
+	if opts.ForceWithLease {
+		mode = git.PushForceWithLease
+	}
+	var pushed []string
+	for attempt := 1; ; attempt++ {
+		results, err := opts.Repo.Push(branches, mode)
+		var rejected []string
+		for _, pr := range results {
+			tr := res.Train(pr.Branch)
+			if tr == nil {
+				continue
+			}
+			tr.Push = &PushResult{Status: pr.Status, Reason: pr.Reason, Attempts: attempt}
+			switch pr.Status {
+			case git.PushOK, git.PushUpToDate:
+				pushed = append(pushed, pr.Branch)
+			case git.PushRejected:
+				rejected = append(rejected, pr.Branch)
+			}
+		}
+		if err == nil {
+			return pushed, nil
+		}
+		if !errors.Is(err, git.ErrStaleLease) || attempt >= opts.PushAttempts {
+			return pushed, err
+		}
+		log.Printf("branches %v were updated concurrently, retrying (attempt %d of %d)", rejected, attempt+1, opts.PushAttempts)
+		if err := opts.Repo.Fetch(opts.DeploymentBranchPrefix + "*"); err != nil {
+			return pushed, err
+		}
+		branches = nil
+		for _, branch := range rejected {
+			if err := ctx.Err(); err != nil {
+				return pushed, err
+			}
+			tr := res.Train(branch)
+			// start over from the fetched remote branch
+			if err := opts.Repo.DeleteBranch(branch); err != nil {
+				return pushed, err
+			}
+			updated, err := opts.updateTrain(ctx, tr.Train, tr.Targets)
+			if err != nil {
+				return pushed, err
+			}
+			if updated.Status == StatusUnchanged {
+				// the concurrent update has the same changes
+				updated.Push = nil
+			} else {
+				updated.Push = tr.Push
+				branches = append(branches, branch)
+			}
+			*tr = *updated
+		}
+		if len(branches) == 0 {
+			return pushed, nil
+		}
+	}
+}
+
+// QueryReleaseTrains returns gitops targets matching the release branch grouped by deployment_branch attribute.
+// The gitops targets of all the release branches are returned if releaseBranch is empty.
+func QueryReleaseTrains(ctx context.Context, q Querier, releaseBranch, target string) (map[string][]string, error) {
+	query := fmt.Sprintf("kind(gitops, %s)", target)
+	if releaseBranch != "" {
+		query = fmt.Sprintf("attr(release_branch_prefix, \"%s\", %s)", releaseBranch, query)
+	}
+	query = fmt.Sprintf("attr(deployment_branch, \".+\", %s)", query)
+	targets, err := q.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	releaseTrains := make(map[string][]string)
+	for _, t := range targets {
+		releaseTrain, _ := t.Rule.StringAttr("deployment_branch")
+		releaseTrains[releaseTrain] = append(releaseTrains[releaseTrain], t.Name())
+	}
+	return releaseTrains, nil
+}
+
+// updateTrains updates the deployment branches of the release trains.
+// With TrainParallelism the trains are updated in worktrees of Repo, which are removed before returning.
+// Returns the results of the trains preceding the first failure and the failed train.
+func (opts *Options) updateTrains(ctx context.Context, trains []string, releaseTrains map[string][]string) ([]TrainResult, error) {
+	var results []TrainResult
+	if opts.TrainParallelism < 2 || len(trains) < 2 {
+		for _, train := range trains {
+			if err := ctx.Err(); err != nil {
+				return results, err
+			}
+			tr, err := opts.updateTrain(ctx, train, releaseTrains[train])
+			if tr != nil {
+				results = append(results, *tr)
+			}
+			if err != nil {
+				return results, err
+			}
+		}
+		return results, nil
+	}
+
+	workers := opts.TrainParallelism
+	if workers > len(trains) {
+		workers = len(trains)
+	}
+	logger := exec.Logger(ctx)
+	var worktrees []git.Repo
+	// the git commands of a worktree are logged to the train it updates
+	var loggers []*log.Logger
+	defer func() {
+		for _, wt := range worktrees {
+			if err := wt.Clean(); err != nil {
+				log.Printf("Unable to remove worktree %s: %v", wt.WorkDir(), err)
+			}
+		}
+	}()
+	for i := 0; i < workers; i++ {
+		wt, err := opts.Repo.Worktree(fmt.Sprintf("%s-worktree%d", opts.Repo.WorkDir(), i), opts.PRInto)
+		if err != nil {
+			return nil, err
+		}
+		worktrees = append(worktrees, wt)
+		wlogger := log.New(io.Discard, "", logger.Flags())
+		if l, ok := wt.(git.LoggerSetter); ok {
+			l.SetLogger(wlogger)
+		}
+		loggers = append(loggers, wlogger)
+	}
+
+	type update struct {
+		tr   *TrainResult
+		err  error
+		log  bytes.Buffer
+		done chan struct{}
+	}
+	updates := make([]*update, len(trains))
+	for i := range updates {
+		updates[i] = &update{done: make(chan struct{})}
+	}
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var firstErr error
+	var mu sync.Mutex
+	next := make(chan int)
+	wg.Add(len(worktrees))
+	for w, wt := range worktrees {
+		wopts := *opts
+		wopts.Repo = wt
+		wlogger := loggers[w]
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				u := updates[i]
+				wlogger.SetOutput(&u.log)
+				tctx := exec.WithLogger(ctx, wlogger)
+				u.tr, u.err = wopts.updateTrain(tctx, trains[i], releaseTrains[trains[i]])
+				if u.err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = u.err
+					}
+					mu.Unlock()
+					// stop the other trains
+					cancel()
+				}
+				close(u.done)
+			}
+		}()
+	}
+	go func() {
+		defer close(next)
+		for i := range trains {
+			select {
+			case next <- i:
+			case <-ctx.Done():
+				for _, u := range updates[i:] {
+					u.err = ctx.Err()
+					close(u.done)
+				}
+				return
+			}
+		}
+	}()
+
+	var err error
+	for _, u := range updates {
+		<-u.done
+		logger.Writer().Write(u.log.Bytes())
+		if err != nil {
+			continue
+		}
+		if u.tr != nil {
+			results = append(results, *u.tr)
+		}
+		if u.err != nil {
+			mu.Lock()
+			err = firstErr
+			mu.Unlock()
+			if err == nil {
+				err = u.err
+			}
+		}
+	}
+	return results, err
+}
+
+// updateTrain switches the repo to the release train deployment branch, runs the gitops targets and commits the changes.
+func (opts *Options) updateTrain(ctx context.Context, train string, targets []string) (*TrainResult, error) {
+	logger := exec.Logger(ctx)
+	logger.Println("train", train)
+	branch := opts.DeploymentBranchPrefix + train + opts.DeploymentBranchSuffix
+	into := opts.PRInto
+	if tc := opts.Trains[train]; tc.PRInto != "" {
+		into = tc.PRInto
+	}
+	base := opts.baseRevision(into)
+	tr := &TrainResult{
+		Train:   train,
+		Branch:  branch,
+		Into:    into,
+		Targets: targets,
+		Status:  StatusUpdated,
+	}
+	newBranch, err := opts.Repo.SwitchToBranch(branch, base)
+	if err != nil {
+		return tr, err
+	}
+	if newBranch {
+		tr.Status = StatusNew
+	} else {
+		// Find if we need to recreate the branch because target was deleted
+		msg, err := opts.Repo.GetLastCommitMessage()
+		if err != nil {
+			return tr, err
+		}
+		targetset := make(map[string]bool)
+		for _, t := range targets {
+			targetset[t] = true
+		}
+		oldtargets := commitmsg.ExtractTargets(msg)
+		for _, t := range oldtargets {
+			if !targetset[t] {
+				// target t is not present in a new list
+				if err := opts.Repo.RecreateBranch(branch, base); err != nil {
+					return tr, err
+				}
+				tr.Status = StatusRecreated
+				break
+			}
+		}
+	}
+	var idx *Index
+	if opts.Prune {
+		if idx, err = opts.runTargetsIndexed(ctx, train, targets); err != nil {
+			return tr, err
+		}
+	} else {
+		for _, target := range targets {
+			logger.Println("train", train, "target", target)
+			if err := opts.Runner.Run(ctx, target, "--nopush", "--nobazel", "--deployment_root", opts.Repo.WorkDir()); err != nil {
+				return tr, fmt.Errorf("gitops target %s failed: %w", target, err)
+			}
+		}
+	}
+	if opts.Stamp {
+		if err := opts.stampChangedFiles(); err != nil {
+			return tr, err
+		}
+	}
+	if opts.Prune {
+		indexes, err := opts.readIndexes()
+		if err != nil {
+			return tr, err
+		}
+		if tr.PrunedFiles, err = opts.prune(opts.orphanedFiles(idx, indexes)); err != nil {
+			return tr, fmt.Errorf("release train %s: %w", train, err)
+		}
+		for _, f := range tr.PrunedFiles {
+			logger.Println("train", train, "pruned", f)
+		}
+		// the index is written after stamping, it is not a template
+		if err := opts.writeIndex(idx); err != nil {
+			return tr, err
+		}
+	}
+	meta := commitmsg.Metadata{
+		Targets:      targets,
+		SourceRepo:   opts.SourceRepo,
+		SourceBranch: opts.BranchName,
+		SourceCommit: opts.GitCommit,
+		Images:       opts.images(targets),
+	}
+	committed, err := opts.Repo.Commit(fmt.Sprintf("GitOps for release branch %s from %s commit %s\n%s", opts.ReleaseBranch, opts.BranchName, opts.GitCommit, meta.Generate()), opts.GitopsPath)
+	if err != nil {
+		return tr, err
+	}
+	if !committed {
+		tr.Status = StatusUnchanged
+		return tr, nil
+	}
+	logger.Println("branch", branch, "has changes, push is required")
+	tr.ChangedFiles, err = opts.Repo.GetLastCommitFiles()
+	return tr, err
+}
+
+// stampChangedFiles stamps the files changed in the repo unless the unstamped content digest is unchanged.
+func (opts *Options) stampChangedFiles() error {
+	changedFiles, err := opts.Repo.GetChangedFiles()
+	if err != nil || len(changedFiles) == 0 {
+		return err
+	}
+	ctx := map[string]interface{}{
+		"GIT_REVISION": opts.GitCommit,
+		"UTC_DATE":     time.Now().UTC().Format(time.UnixDate),
+		"GIT_BRANCH":   opts.BranchName,
+	}
+	for _, filePath := range changedFiles {
+		fullPath := filepath.Join(opts.Repo.WorkDir(), filePath)
+		unchanged, err := digester.VerifyDigest(fullPath)
+		if err != nil {
+			return err
+		}
+		if unchanged {
+			if err := opts.Repo.RestoreFile(fullPath); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := digester.SaveDigest(fullPath); err != nil {
+			return err
+		}
+		if err := stampFile(fullPath, ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func stampFile(fullPath string, ctx map[string]interface{}) error {
+	template, err := os.ReadFile(fullPath)
+	if err != nil {
+		return err
+	}
+
+	outf, err := os.OpenFile(fullPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer outf.Close()
+
+	_, err = fasttemplate.Execute(string(template), "{{", "}}", outf, ctx)
+	if err != nil {
+		return fmt.Errorf("unable to stamp %s: %w", fullPath, err)
+	}
+	return nil
+}
+
+// resolve locates the executables of the targets if Runner is a Resolver
+func (opts *Options) resolve(ctx context.Context, targets []string) error {
+	r, ok := opts.Runner.(Resolver)
+	if !ok || len(targets) == 0 {
+		return nil
+	}
+	if err := r.Resolve(ctx, targets); err != nil {
+		return fmt.Errorf("unable to resolve the executables: %w", err)
+	}
+	return nil
+}
+
+// targetSet returns the query of the space separated set('//a' '//b' ... '//z') of targets.
+// Target names need to be quoted to protect from + and other special characters
+func targetSet(targets []string) string {
+	return "set('" + strings.Join(targets, "' '") + "')"
+}
+
+// pushQuery returns the query selecting dependencies of the gitops targets to push
+func (opts *Options) pushQuery(gitopsTargets []string) string {
+	depsList := targetSet(gitopsTargets)
+	var qv []string
+	for _, kind := range opts.GitopsKinds {
+		q := fmt.Sprintf("kind(%s, deps(%s))", kind, depsList)
+		qv = append(qv, q)
+	}
+	for _, name := range opts.GitopsRuleNames {
+		q := fmt.Sprintf("filter(%s, deps(%s))", name, depsList)
+		qv = append(qv, q)
+	}
+	for _, attr := range opts.GitopsRuleAttrs {
+		name, value, found := strings.Cut(attr, "=")
+		if !found {
+			value = ".*"
+		}
+		q := fmt.Sprintf("attr(%s, %s, deps(%s))", name, value, depsList)
+		qv = append(qv, q)
+	}
+	return strings.Join(qv, " union ")
+}
+
+// queryPushTargets returns the push targets of the gitops targets by gitops target.
+// The push targets of all the gitops targets are queried at once, the paths from the gitops targets
+// to the push targets assign them to the gitops targets.
+func (opts *Options) queryPushTargets(ctx context.Context, gitopsTargets []string) (map[string][]string, error) {
+	query := opts.pushQuery(gitopsTargets)
+	if query == "" || len(gitopsTargets) == 0 {
+		return nil, nil
+	}
+	targets, err := opts.Querier.Query(ctx, query)
+	if err != nil || len(targets) == 0 {
+		return nil, err
+	}
+	isPush := make(map[string]bool)
+	for _, t := range targets {
+		isPush[t.Name()] = true
+	}
+	paths, err := opts.Querier.Query(ctx, fmt.Sprintf("allpaths(%s, %s)", targetSet(gitopsTargets), query))
+	if err != nil {
+		return nil, err
+	}
+	deps := make(map[string][]string)
+	for _, t := range paths {
+		if t.Rule != nil {
+			deps[t.Rule.Name] = t.Rule.RuleInput
+		}
+	}
+	pushTargets := make(map[string][]string)
+	for _, gt := range gitopsTargets {
+		seen := map[string]bool{gt: true}
+		stack := []string{gt}
+		for len(stack) > 0 {
+			name := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if isPush[name] {
+				pushTargets[gt] = append(pushTargets[gt], name)
+			}
+			for _, dep := range deps[name] {
+				if !seen[dep] {
+					seen[dep] = true
+					stack = append(stack, dep)
+				}
+			}
+		}
+		sort.Strings(pushTargets[gt])
+	}
+	return pushTargets, nil
+}
+
+// pushTargets returns the sorted push targets of the gitops targets without duplicates
+func pushTargets(byGitopsTarget map[string][]string, gitopsTargets []string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, gt := range gitopsTargets {
+		for _, name := range byGitopsTarget[gt] {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// images returns the digests of the images pushed by the push targets of the gitops targets, by gitops target
+func (opts *Options) images(gitopsTargets []string) map[string][]commitmsg.Image {
+	var images map[string][]commitmsg.Image
+	for _, gt := range gitopsTargets {
+		for _, name := range opts.pushTargets[gt] {
+			if images == nil {
+				images = make(map[string][]commitmsg.Image)
+			}
+			images[gt] = append(images[gt], commitmsg.Image{Target: name, Digest: opts.ImageDigest(name)})
+		}
+	}
+	return images
+}
+
+// pushImages runs the push targets with PushParallelism concurrency. Returns the first failure.
+func (opts *Options) pushImages(ctx context.Context, names []string) ([]ImageResult, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	targetsCh := make(chan string)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var images []ImageResult
+	var errs []error
+	wg.Add(opts.PushParallelism)
+	for i := 0; i < opts.PushParallelism; i++ {
+		go func() {
+			defer wg.Done()
+			for target := range targetsCh {
+				err := opts.Runner.Run(ctx, target)
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("unable to push %s: %w", target, err))
+				} else {
+					images = append(images, ImageResult{Target: target, Digest: opts.ImageDigest(target)})
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, name := range names {
+		targetsCh <- name
+	}
+	close(targetsCh)
+	wg.Wait()
+	sort.Slice(images, func(i, j int) bool { return images[i].Target < images[j].Target })
+	if len(errs) > 0 {
+		return images, errs[0]
+	}
+	return images, nil
+}
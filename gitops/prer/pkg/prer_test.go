@@ -0,0 +1,1535 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package prer_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adobe/rules_gitops/gitops/bazel"
+	"github.com/adobe/rules_gitops/gitops/commitmsg"
+	"github.com/adobe/rules_gitops/gitops/exec"
+	"github.com/adobe/rules_gitops/gitops/git"
+	prer "github.com/adobe/rules_gitops/gitops/prer/pkg"
+)
+
+// fakeQuerier returns gitops targets for the release trains query, rdeps for the reverse dependencies query,
+// the gitops targets depending on their push targets for the allpaths query and push targets for any other query
+type fakeQuerier struct {
+	gitops map[string]string // target -> deployment_branch
+	push   []string
+	// pushDeps are the push targets of the gitops targets, all the push targets if not set
+	pushDeps map[string][]string
+	rdeps    []string
+	queries  []string
+	// rdepsErr is returned along with the reverse dependencies
+	rdepsErr error
+}
+
+func (q *fakeQuerier) Query(ctx context.Context, query string) ([]bazel.Target, error) {
+	q.queries = append(q.queries, query)
+	var targets []bazel.Target
+	switch {
+	case strings.HasPrefix(query, "kind(gitops, rdeps("):
+		for _, name := range q.rdeps {
+			targets = append(targets, bazel.NewRule(name, "gitops", nil))
+		}
+		return targets, q.rdepsErr
+	case strings.HasPrefix(query, "allpaths(set('"):
+		set := strings.TrimPrefix(query[:strings.Index(query, "')")], "allpaths(set('")
+		for _, name := range strings.Split(set, "' '") {
+			t := bazel.NewRule(name, "gitops", nil)
+			t.Rule.RuleInput = q.push
+			if q.pushDeps != nil {
+				t.Rule.RuleInput = q.pushDeps[name]
+			}
+			targets = append(targets, t)
+		}
+		for _, name := range q.push {
+			targets = append(targets, bazel.NewRule(name, "k8s_container_push", nil))
+		}
+	case strings.HasPrefix(query, "attr(deployment_branch"):
+		for name, train := range q.gitops {
+			targets = append(targets, bazel.NewRule(name, "gitops", map[string]string{"deployment_branch": train}))
+		}
+	default:
+		for _, name := range q.push {
+			targets = append(targets, bazel.NewRule(name, "k8s_container_push", nil))
+		}
+	}
+	return targets, nil
+}
+
+// fakeRunner writes the files of the target into the deployment root
+type fakeRunner struct {
+	mu       sync.Mutex
+	runs     []string
+	resolved []string
+	fail     string
+	files    map[string][]string
+}
+
+func (r *fakeRunner) Resolve(ctx context.Context, targets []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resolved = append(r.resolved, targets...)
+	return nil
+}
+
+func (r *fakeRunner) Run(ctx context.Context, target string, args ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs = append(r.runs, target)
+	if target == r.fail {
+		return errors.New("exit status 1")
+	}
+	for _, f := range r.files[target] {
+		root := args[len(args)-1]
+		if err := writeFile(filepath.Join(root, f), "# GENERATED BY "+target+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(name, content string) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(name, []byte(content), 0644)
+}
+
+// fakeRepo keeps the last commit message per branch. Commit succeeds for branches listed in changes.
+// Push rejects the branches listed in reject once.
+type fakeRepo struct {
+	mu       sync.Mutex
+	branches map[string]string
+	changes  map[string]bool
+	reject   map[string]bool
+	// diffs are the changes between revisions keyed by "from..to", files are the contents keyed by "revision:path"
+	diffs     map[string][]git.FileChange
+	files     map[string]string
+	current   string
+	deleted   []string
+	pushed    []string
+	worktrees []string
+	cleaned   []string
+	dir       string
+	// logs are the commits keyed by "revision:path"
+	logs map[string][]git.Commit
+	// remote are the remote branches, deleteErr fails DeleteRemoteBranch
+	remote        []git.RemoteBranch
+	deletedRemote []string
+	deleteErr     error
+}
+
+func (r *fakeRepo) WorkDir() string {
+	if r.dir != "" {
+		return r.dir
+	}
+	return "/tmp/gitops"
+}
+
+func (r *fakeRepo) Remote() string { return "origin" }
+
+func (r *fakeRepo) Clean() error { return nil }
+
+func (r *fakeRepo) Fetch(pattern string) error { return nil }
+
+func (r *fakeRepo) SwitchToBranch(branch, primaryBranch string) (bool, error) {
+	r.current = branch
+	if _, ok := r.branches[branch]; ok {
+		return false, nil
+	}
+	r.branches[branch] = ""
+	return true, nil
+}
+
+func (r *fakeRepo) RecreateBranch(branch, primaryBranch string) error {
+	r.current = branch
+	r.branches[branch] = ""
+	return nil
+}
+
+func (r *fakeRepo) DeleteBranch(branch string) error {
+	r.deleted = append(r.deleted, branch)
+	return nil
+}
+
+func (r *fakeRepo) GetLastCommitMessage() (string, error) { return r.branches[r.current], nil }
+
+func (r *fakeRepo) Commit(message, gitopsPath string) (bool, error) {
+	if !r.changes[r.current] {
+		return false, nil
+	}
+	r.branches[r.current] = message
+	return true, nil
+}
+
+func (r *fakeRepo) RestoreFile(fileName string) error { return nil }
+
+func (r *fakeRepo) GetChangedFiles() ([]string, error) { return nil, nil }
+
+func (r *fakeRepo) IsClean() (bool, error) { return !r.changes[r.current], nil }
+
+func (r *fakeRepo) GetLastCommitFiles() ([]string, error) {
+	return []string{"cloud/" + r.current + ".yaml"}, nil
+}
+
+func (r *fakeRepo) Diff(from, to, path string) ([]git.FileChange, error) {
+	return r.diffs[from+".."+to], nil
+}
+
+func (r *fakeRepo) ReadFile(revision, path string) ([]byte, error) {
+	content, ok := r.files[revision+":"+path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return []byte(content), nil
+}
+
+func (r *fakeRepo) Log(revision, path string, max int) ([]git.Commit, error) {
+	commits, ok := r.logs[revision+":"+path]
+	if !ok {
+		return nil, fmt.Errorf("unknown revision %s", revision)
+	}
+	if max > 0 && len(commits) > max {
+		commits = commits[:max]
+	}
+	return commits, nil
+}
+
+func (r *fakeRepo) RemoteBranches(prefix string) ([]git.RemoteBranch, error) {
+	var branches []git.RemoteBranch
+	for _, b := range r.remote {
+		if strings.HasPrefix(b.Name, prefix) {
+			branches = append(branches, b)
+		}
+	}
+	return branches, nil
+}
+
+func (r *fakeRepo) DeleteRemoteBranch(branch string) error {
+	if r.deleteErr != nil {
+		return r.deleteErr
+	}
+	r.deletedRemote = append(r.deletedRemote, branch)
+	return nil
+}
+
+func (r *fakeRepo) Push(branches []string, mode git.PushMode) ([]git.PushResult, error) {
+	var results []git.PushResult
+	for _, b := range branches {
+		if r.reject[b] && mode == git.PushForceWithLease {
+			delete(r.reject, b)
+			results = append(results, git.PushResult{Branch: b, Status: git.PushRejected, Reason: "stale info"})
+			continue
+		}
+		r.pushed = append(r.pushed, b)
+		results = append(results, git.PushResult{Branch: b, Status: git.PushOK})
+	}
+	return results, git.PushError(results)
+}
+
+func (r *fakeRepo) Worktree(dir, primaryBranch string) (git.Repo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.worktrees = append(r.worktrees, dir)
+	return &fakeWorktree{fakeRepo: r, dir: dir}, nil
+}
+
+// fakeWorktree shares the branches of the repo and logs the branch switches
+type fakeWorktree struct {
+	*fakeRepo
+	dir     string
+	current string
+	logger  *log.Logger
+}
+
+func (w *fakeWorktree) SetLogger(logger *log.Logger) { w.logger = logger }
+
+func (w *fakeWorktree) WorkDir() string { return w.dir }
+
+func (w *fakeWorktree) Clean() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.cleaned = append(w.cleaned, w.dir)
+	return nil
+}
+
+func (w *fakeWorktree) SwitchToBranch(branch, primaryBranch string) (bool, error) {
+	if w.logger != nil {
+		w.logger.Println("checkout", branch)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.current = branch
+	if _, ok := w.branches[branch]; ok {
+		return false, nil
+	}
+	w.branches[branch] = ""
+	return true, nil
+}
+
+func (w *fakeWorktree) RecreateBranch(branch, primaryBranch string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.branches[branch] = ""
+	return nil
+}
+
+func (w *fakeWorktree) GetLastCommitMessage() (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.branches[w.current], nil
+}
+
+func (w *fakeWorktree) Commit(message, gitopsPath string) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.changes[w.current] {
+		return false, nil
+	}
+	w.branches[w.current] = message
+	return true, nil
+}
+
+func (w *fakeWorktree) IsClean() (bool, error) { return !w.changes[w.current], nil }
+
+func (w *fakeWorktree) GetLastCommitFiles() ([]string, error) {
+	return []string{"cloud/" + w.current + ".yaml"}, nil
+}
+
+// fakeServer creates PRs numbered in order. PRs of the branches listed in existing are reused.
+type fakeServer struct {
+	created  []string
+	bodies   []string
+	existing map[string]int
+	updated  []string
+	// autoMerged are the PRs with auto-merge enabled, unless autoMergeErr is set
+	autoMerged   []string
+	autoMergeErr error
+	// open are the PR numbers found by head branch, FindPR and ClosePR are not supported if nil
+	open   map[string]int
+	closed []int
+}
+
+func (s *fakeServer) CreatePR(from, to, title, body string) (*git.PullRequest, error) {
+	if n, ok := s.existing[from]; ok {
+		return &git.PullRequest{Number: n, URL: "https://example.com/pr"}, nil
+	}
+	s.created = append(s.created, from+"->"+to+": "+title)
+	s.bodies = append(s.bodies, body)
+	return &git.PullRequest{Number: len(s.created), URL: "https://example.com/pr", Created: true}, nil
+}
+
+func (s *fakeServer) FindPR(from string) (*git.PullRequest, error) {
+	if s.open == nil {
+		return nil, git.ErrNotSupported
+	}
+	if n, ok := s.open[from]; ok {
+		return &git.PullRequest{Number: n, URL: fmt.Sprintf("https://example.com/pr/%d", n)}, nil
+	}
+	return nil, nil
+}
+
+func (s *fakeServer) UpdatePR(number int, title, body string) error {
+	s.updated = append(s.updated, fmt.Sprintf("%d: %s", number, title))
+	return nil
+}
+
+func (s *fakeServer) AddLabels(number int, labels []string) error { return git.ErrNotSupported }
+
+func (s *fakeServer) RequestReviewers(number int, reviewers []string) error {
+	return git.ErrNotSupported
+}
+
+func (s *fakeServer) SetDraft(number int, draft bool) error { return git.ErrNotSupported }
+
+func (s *fakeServer) ClosePR(number int) error {
+	if s.open == nil {
+		return git.ErrNotSupported
+	}
+	s.closed = append(s.closed, number)
+	return nil
+}
+
+func (s *fakeServer) EnableAutoMerge(number int, method string) error {
+	if s.autoMergeErr != nil {
+		return s.autoMergeErr
+	}
+	s.autoMerged = append(s.autoMerged, fmt.Sprintf("%d: %s", number, method))
+	return nil
+}
+
+func testOptions() (prer.Options, *fakeRunner, *fakeRepo, *fakeServer) {
+	runner := &fakeRunner{}
+	repo := &fakeRepo{
+		branches: map[string]string{
+			"deploy/prod":  "GitOps\n" + commitmsg.Generate([]string{"//app:prod"}),
+			"deploy/stage": "GitOps\n" + commitmsg.Generate([]string{"//app:stage", "//app:removed"}),
+		},
+		changes: map[string]bool{"deploy/dev": true, "deploy/stage": true},
+	}
+	server := &fakeServer{}
+	return prer.Options{
+		ReleaseBranch:          "master",
+		Target:                 "//...",
+		GitopsPath:             "cloud",
+		PRInto:                 "master",
+		BranchName:             "master",
+		GitCommit:              "abc123",
+		DeploymentBranchPrefix: "deploy/",
+		GitopsKinds:            []string{"k8s_container_push"},
+		PushParallelism:        2,
+		ImageDigest:            func(target string) string { return "sha256:" + target },
+		Trains: map[string]prer.TrainConfig{
+			"stage": {PRTitle: "Stage deployment"},
+		},
+		Querier: &fakeQuerier{
+			gitops: map[string]string{"//app:dev": "dev", "//app:prod": "prod", "//app:stage": "stage"},
+			push:   []string{"//app:image"},
+		},
+		Runner: runner,
+		Repo:   repo,
+		Server: server,
+	}, runner, repo, server
+}
+
+func TestRun(t *testing.T) {
+	opts, runner, repo, server := testOptions()
+	res, err := prer.Run(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &prer.Result{
+		Trains: []prer.TrainResult{
+			{Train: "dev", Branch: "deploy/dev", Into: "master", Targets: []string{"//app:dev"}, Status: prer.StatusNew,
+				ChangedFiles: []string{"cloud/deploy/dev.yaml"}, Push: &prer.PushResult{Status: git.PushOK, Attempts: 1},
+				PR: &prer.PRResult{Number: 1, URL: "https://example.com/pr", Created: true}},
+			{Train: "prod", Branch: "deploy/prod", Into: "master", Targets: []string{"//app:prod"}, Status: prer.StatusUnchanged},
+			{Train: "stage", Branch: "deploy/stage", Into: "master", Targets: []string{"//app:stage"}, Status: prer.StatusRecreated,
+				ChangedFiles: []string{"cloud/deploy/stage.yaml"}, Push: &prer.PushResult{Status: git.PushOK, Attempts: 1},
+				PR: &prer.PRResult{Number: 2, URL: "https://example.com/pr", Created: true}},
+		},
+		Images: []prer.ImageResult{{Target: "//app:image", Digest: "sha256://app:image"}},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("unexpected result:\n%+v\nexpected:\n%+v", res, expected)
+	}
+	if !reflect.DeepEqual(runner.runs, []string{"//app:dev", "//app:prod", "//app:stage", "//app:image"}) {
+		t.Errorf("unexpected runs: %v", runner.runs)
+	}
+	if !reflect.DeepEqual(runner.resolved[:3], []string{"//app:dev", "//app:prod", "//app:stage"}) || runner.resolved[len(runner.resolved)-1] != "//app:image" {
+		t.Errorf("unexpected resolved executables: %v", runner.resolved)
+	}
+	if !reflect.DeepEqual(repo.pushed, []string{"deploy/dev", "deploy/stage"}) {
+		t.Errorf("unexpected pushed branches: %v", repo.pushed)
+	}
+	if !reflect.DeepEqual(server.created, []string{"deploy/dev->master: GitOps deployment deploy/dev", "deploy/stage->master: Stage deployment"}) {
+		t.Errorf("unexpected PRs: %v", server.created)
+	}
+	meta, err := commitmsg.Parse(repo.branches["deploy/dev"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Version != commitmsg.Version || !reflect.DeepEqual(meta.Targets, []string{"//app:dev"}) || meta.SourceBranch != "master" || meta.SourceCommit != "abc123" ||
+		!reflect.DeepEqual(meta.Images, map[string][]commitmsg.Image{"//app:dev": {{Target: "//app:image", Digest: "sha256://app:image"}}}) || meta.GeneratedAt.IsZero() {
+		t.Errorf("unexpected commit metadata: %+v", meta)
+	}
+}
+
+func TestRunPushTargets(t *testing.T) {
+	opts, runner, repo, _ := testOptions()
+	querier := &fakeQuerier{
+		gitops:   map[string]string{"//app:dev": "dev", "//app:dev-worker": "dev", "//app:prod": "prod", "//app:stage": "stage"},
+		push:     []string{"//app:image", "//app:worker-image", "//app:prod-image"},
+		pushDeps: map[string][]string{"//app:dev": {"//app:image"}, "//app:dev-worker": {"//app:image", "//app:worker-image"}, "//app:prod": {"//app:prod-image"}, "//app:stage": {"//app:image"}},
+	}
+	opts.Querier = querier
+	opts.TrainParallelism = 2
+	if _, err := prer.Run(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	var pushQueries int
+	for _, q := range querier.queries {
+		if !strings.HasPrefix(q, "attr(deployment_branch") {
+			pushQueries++
+		}
+	}
+	if pushQueries != 2 {
+		t.Errorf("the push targets should be queried once per run: %v", querier.queries)
+	}
+	meta, err := commitmsg.Parse(repo.branches["deploy/dev"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]commitmsg.Image{
+		"//app:dev":        {{Target: "//app:image", Digest: "sha256://app:image"}},
+		"//app:dev-worker": {{Target: "//app:image", Digest: "sha256://app:image"}, {Target: "//app:worker-image", Digest: "sha256://app:worker-image"}},
+	}
+	if !reflect.DeepEqual(meta.Images, expected) {
+		t.Errorf("unexpected images by gitops target: %+v", meta.Images)
+	}
+	// the unchanged prod train does not push its image
+	var pushed []string
+	for _, r := range runner.runs {
+		if strings.HasSuffix(r, "image") {
+			pushed = append(pushed, r)
+		}
+	}
+	sort.Strings(pushed)
+	if !reflect.DeepEqual(pushed, []string{"//app:image", "//app:worker-image"}) {
+		t.Errorf("unexpected pushed images: %v", pushed)
+	}
+}
+
+func TestRunDryRun(t *testing.T) {
+	opts, _, repo, server := testOptions()
+	opts.DryRun = true
+	res, err := prer.Run(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.pushed) != 0 || len(server.created) != 0 {
+		t.Errorf("dry run should not push or create PRs: %v %v", repo.pushed, server.created)
+	}
+	if len(res.Images) != 1 {
+		t.Errorf("images should be pushed in dry run: %v", res.Images)
+	}
+}
+
+func TestRunGitopsTargetFailure(t *testing.T) {
+	opts, runner, repo, server := testOptions()
+	runner.fail = "//app:prod"
+	res, err := prer.Run(context.Background(), opts)
+	if err == nil || !strings.Contains(err.Error(), "gitops target //app:prod failed") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Trains) != 2 || res.Trains[1].Train != "prod" {
+		t.Errorf("partial result expected: %+v", res.Trains)
+	}
+	if len(repo.pushed) != 0 || len(server.created) != 0 {
+		t.Errorf("nothing should be pushed on failure: %v %v", repo.pushed, server.created)
+	}
+}
+
+func TestRunPushRetry(t *testing.T) {
+	opts, runner, repo, server := testOptions()
+	opts.ForceWithLease = true
+	opts.PushAttempts = 2
+	repo.reject = map[string]bool{"deploy/dev": true}
+	res, err := prer.Run(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(repo.deleted, []string{"deploy/dev"}) {
+		t.Errorf("rejected branch should be deleted before retry: %v", repo.deleted)
+	}
+	if !reflect.DeepEqual(runner.runs, []string{"//app:dev", "//app:prod", "//app:stage", "//app:image", "//app:dev"}) {
+		t.Errorf("rejected train should be rendered again: %v", runner.runs)
+	}
+	if !reflect.DeepEqual(repo.pushed, []string{"deploy/stage", "deploy/dev"}) {
+		t.Errorf("unexpected pushed branches: %v", repo.pushed)
+	}
+	if tr := res.Train("deploy/dev"); tr.Push == nil || *tr.Push != (prer.PushResult{Status: git.PushOK, Attempts: 2}) {
+		t.Errorf("unexpected push result: %+v", tr.Push)
+	}
+	if len(server.created) != 2 {
+		t.Errorf("unexpected PRs: %v", server.created)
+	}
+}
+
+func TestRunPushRetryExhausted(t *testing.T) {
+	opts, _, repo, server := testOptions()
+	opts.ForceWithLease = true
+	repo.reject = map[string]bool{"deploy/dev": true}
+	res, err := prer.Run(context.Background(), opts)
+	if !errors.Is(err, git.ErrStaleLease) {
+		t.Fatalf("expected ErrStaleLease, got %v", err)
+	}
+	if tr := res.Train("deploy/dev"); tr.Push == nil || tr.Push.Status != git.PushRejected {
+		t.Errorf("unexpected push result: %+v", tr.Push)
+	}
+	if len(server.created) != 0 {
+		t.Errorf("PRs should not be created on push failure: %v", server.created)
+	}
+}
+
+func TestRunTrainParallelism(t *testing.T) {
+	opts, _, _, _ := testOptions()
+	expected, err := prer.Run(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, runner, repo, server := testOptions()
+	opts.TrainParallelism = 2
+	var logs bytes.Buffer
+	ctx := exec.WithLogger(context.Background(), log.New(&logs, "", 0))
+	res, err := prer.Run(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("unexpected result:\n%+v\nexpected:\n%+v", res, expected)
+	}
+	if !reflect.DeepEqual(repo.worktrees, []string{"/tmp/gitops-worktree0", "/tmp/gitops-worktree1"}) {
+		t.Errorf("unexpected worktrees: %v", repo.worktrees)
+	}
+	sort.Strings(repo.cleaned)
+	if !reflect.DeepEqual(repo.cleaned, repo.worktrees) {
+		t.Errorf("worktrees should be removed: %v", repo.cleaned)
+	}
+	sort.Strings(runner.runs)
+	if !reflect.DeepEqual(runner.runs, []string{"//app:dev", "//app:image", "//app:prod", "//app:stage"}) {
+		t.Errorf("unexpected runs: %v", runner.runs)
+	}
+	if !reflect.DeepEqual(repo.pushed, []string{"deploy/dev", "deploy/stage"}) {
+		t.Errorf("unexpected pushed branches: %v", repo.pushed)
+	}
+	if len(server.created) != 2 {
+		t.Errorf("unexpected PRs: %v", server.created)
+	}
+	// the log output, including the git commands of the worktrees, is grouped by train
+	var trains []string
+	checkouts := make(map[string]string)
+	for _, line := range strings.Split(logs.String(), "\n") {
+		if strings.HasPrefix(line, "train ") {
+			train := strings.Fields(line)[1]
+			if len(trains) == 0 || trains[len(trains)-1] != train {
+				trains = append(trains, train)
+			}
+		}
+		if strings.HasPrefix(line, "checkout ") && len(trains) > 0 {
+			checkouts[strings.Fields(line)[1]] = trains[len(trains)-1]
+		}
+	}
+	if !reflect.DeepEqual(trains, []string{"dev", "prod", "stage"}) {
+		t.Errorf("unexpected log output:\n%s", logs.String())
+	}
+	if !reflect.DeepEqual(checkouts, map[string]string{"deploy/dev": "dev", "deploy/prod": "prod", "deploy/stage": "stage"}) {
+		t.Errorf("the git commands should be logged with their train: %v\n%s", checkouts, logs.String())
+	}
+}
+
+func TestRunTrainParallelismFailure(t *testing.T) {
+	opts, runner, repo, server := testOptions()
+	opts.TrainParallelism = 3
+	runner.fail = "//app:prod"
+	res, err := prer.Run(context.Background(), opts)
+	if err == nil || !strings.Contains(err.Error(), "gitops target //app:prod failed") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Trains) != 2 || res.Trains[0].Train != "dev" || res.Trains[1].Train != "prod" {
+		t.Errorf("partial result expected: %+v", res.Trains)
+	}
+	if len(repo.cleaned) != 3 {
+		t.Errorf("worktrees should be removed: %v", repo.cleaned)
+	}
+	if len(repo.pushed) != 0 || len(server.created) != 0 {
+		t.Errorf("nothing should be pushed on failure: %v %v", repo.pushed, server.created)
+	}
+}
+
+func TestRunUpdatesExistingPR(t *testing.T) {
+	opts, _, _, server := testOptions()
+	server.existing = map[string]int{"deploy/stage": 12}
+	res, err := prer.Run(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(server.updated, []string{"12: Stage deployment"}) {
+		t.Errorf("unexpected PR updates: %v", server.updated)
+	}
+	expected := &prer.PRResult{Number: 12, URL: "https://example.com/pr", Updated: true}
+	if tr := res.Train("deploy/stage"); !reflect.DeepEqual(tr.PR, expected) {
+		t.Errorf("unexpected PR result: %+v", tr.PR)
+	}
+	if tr := res.Train("deploy/dev"); tr.PR == nil || !tr.PR.Created || tr.PR.Updated {
+		t.Errorf("unexpected PR result: %+v", tr.PR)
+	}
+}
+
+func TestRunServerFunc(t *testing.T) {
+	opts, _, _, _ := testOptions()
+	opts.Server = git.ServerFunc(func(from, to, title, body string) (*git.PullRequest, error) {
+		return &git.PullRequest{Number: 3}, nil
+	})
+	res, err := prer.Run(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr := res.Train("deploy/stage"); tr.PR == nil || tr.PR.Number != 3 || tr.PR.Updated {
+		t.Errorf("unexpected PR result: %+v", tr.PR)
+	}
+}
+
+func TestRunAutoMerge(t *testing.T) {
+	opts, _, _, server := testOptions()
+	enabled := true
+	opts.Trains["stage"] = prer.TrainConfig{PRTitle: "Stage deployment", AutoMerge: &enabled, MergeMethod: git.MergeMethodSquash}
+	res, err := prer.Run(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(server.autoMerged, []string{"2: squash"}) {
+		t.Errorf("unexpected auto-merged PRs: %v", server.autoMerged)
+	}
+	if tr := res.Train("deploy/dev"); tr.PR.AutoMerge != "" {
+		t.Errorf("unexpected PR result: %+v", tr.PR)
+	}
+	if tr := res.Train("deploy/stage"); tr.PR.AutoMerge != prer.AutoMergeEnabled {
+		t.Errorf("unexpected PR result: %+v", tr.PR)
+	}
+
+	// the failure is recorded, the PRs of the other trains are created
+	opts, _, _, server = testOptions()
+	opts.AutoMerge = true
+	server.autoMergeErr = errors.New("auto-merge is not allowed")
+	res, err = prer.Run(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &prer.PRResult{Number: 2, URL: "https://example.com/pr", Created: true, AutoMerge: prer.AutoMergeFailed, AutoMergeReason: "auto-merge is not allowed"}
+	if tr := res.Train("deploy/stage"); !reflect.DeepEqual(tr.PR, expected) {
+		t.Errorf("unexpected PR result: %+v", tr.PR)
+	}
+
+	opts.Server = git.ServerFunc(func(from, to, title, body string) (*git.PullRequest, error) {
+		return &git.PullRequest{Number: 3}, nil
+	})
+	res, err = prer.Run(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr := res.Train("deploy/dev"); tr.PR.AutoMerge != prer.AutoMergeNotSupported {
+		t.Errorf("unexpected PR result: %+v", tr.PR)
+	}
+
+	opts.MergeMethod = "fast-forward"
+	if _, err := prer.Run(context.Background(), opts); err == nil {
+		t.Error("unknown merge method should fail")
+	}
+}
+
+func TestRunPRBody(t *testing.T) {
+	deployment := func(image string) string {
+		return `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: dev
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: ` + image + "\n"
+	}
+	opts, _, repo, server := testOptions()
+	opts.Trains = nil
+	repo.diffs = map[string][]git.FileChange{
+		"master..deploy/dev": {
+			{Path: "cloud/dev/app.yaml", Status: git.FileModified},
+			{Path: "cloud/dev/readme.txt", Status: git.FileAdded},
+			{Path: "cloud/dev/old.yaml", Status: git.FileDeleted},
+		},
+	}
+	repo.files = map[string]string{
+		"master:cloud/dev/app.yaml":       deployment("registry:5000/app:v1"),
+		"deploy/dev:cloud/dev/app.yaml":   deployment("registry:5000/app:v2") + "---\napiVersion: v1\nkind: Service\nmetadata:\n  name: app\n  namespace: dev\n",
+		"deploy/dev:cloud/dev/readme.txt": "readme",
+		"master:cloud/dev/old.yaml":       "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: old\n",
+	}
+	if _, err := prer.Run(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	expected := "GitOps deployment of `deploy/dev` into `master` from branch `master` commit `abc123`." + `
+
+### Targets
+
+- ` + "`//app:dev`" + `
+
+### Images
+
+| Image | Old | New |
+| --- | --- | --- |
+| ` + "`registry:5000/app`" + ` | v1 | v2 |
+
+### Changes
+
+#### ` + "`cloud/dev/app.yaml`" + ` (modified)
+
+| Change | Kind | Namespace | Name |
+| --- | --- | --- | --- |
+| added | Service | dev | app |
+| modified | Deployment | dev | app |
+
+#### ` + "`cloud/dev/readme.txt`" + ` (added)
+
+_not a Kubernetes manifest_
+
+#### ` + "`cloud/dev/old.yaml`" + ` (deleted)
+
+| Change | Kind | Namespace | Name |
+| --- | --- | --- | --- |
+| deleted | ConfigMap |  | old |
+`
+	if len(server.bodies) != 2 || server.bodies[0] != expected {
+		t.Errorf("unexpected PR body:\n%s", server.bodies[0])
+	}
+	if !strings.Contains(server.bodies[1], "No changes in `cloud`.") {
+		t.Errorf("unexpected PR body:\n%s", server.bodies[1])
+	}
+
+	opts, _, repo, server = testOptions()
+	opts.MaxBodySize = 300
+	repo.diffs = make(map[string][]git.FileChange)
+	for i := 0; i < 20; i++ {
+		repo.diffs["master..deploy/dev"] = append(repo.diffs["master..deploy/dev"], git.FileChange{Path: fmt.Sprintf("cloud/dev/%d.txt", i), Status: git.FileAdded})
+	}
+	if _, err := prer.Run(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	if body := server.bodies[0]; len(body) > 300 || !strings.HasSuffix(body, "_The summary is truncated, see the pull request changes._\n") {
+		t.Errorf("unexpected truncated PR body %d:\n%s", len(body), body)
+	}
+
+	opts, _, _, server = testOptions()
+	opts.PRBody = "Please review"
+	if _, err := prer.Run(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(server.bodies, []string{"Please review", "Please review"}) {
+		t.Errorf("unexpected PR bodies: %q", server.bodies)
+	}
+}
+
+func TestRunPrune(t *testing.T) {
+	opts, runner, repo, _ := testOptions()
+	opts.Prune = true
+	repo.dir = t.TempDir()
+	runner.files = map[string][]string{
+		"//app:dev":   {"cloud/dev/app.yaml", "other/readme.txt"},
+		"//app:stage": {"cloud/stage/app.yaml", "cloud/shared.yaml"},
+	}
+	indexes := map[string]prer.Index{
+		// //app:removed is deleted, //app:dev does not generate old.yaml anymore
+		"dev": {Train: "dev", Targets: map[string][]string{
+			"//app:dev":     {"cloud/dev/app.yaml", "cloud/dev/old.yaml"},
+			"//app:removed": {"cloud/dev/removed.yaml", "cloud/shared.yaml", "cloud/dev/missing.yaml"},
+		}},
+		// shared.yaml is generated by the current target of the other train
+		"stage": {Train: "stage", Targets: map[string][]string{"//app:stage": {"cloud/shared.yaml"}}},
+	}
+	setup := func() {
+		for train, idx := range indexes {
+			b, err := json.Marshal(idx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := writeFile(filepath.Join(repo.dir, prer.IndexPath("cloud", train)), string(b)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, f := range []string{"cloud/dev/old.yaml", "cloud/dev/removed.yaml", "cloud/shared.yaml"} {
+			if err := writeFile(filepath.Join(repo.dir, f), "old"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	setup()
+	res, err := prer.Run(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr := res.Train("deploy/dev"); !reflect.DeepEqual(tr.PrunedFiles, []string{"cloud/dev/old.yaml", "cloud/dev/removed.yaml"}) {
+		t.Errorf("unexpected pruned files: %v", tr.PrunedFiles)
+	}
+	if tr := res.Train("deploy/stage"); len(tr.PrunedFiles) != 0 {
+		t.Errorf("unexpected pruned files: %v", tr.PrunedFiles)
+	}
+	for f, exists := range map[string]bool{"cloud/dev/old.yaml": false, "cloud/dev/removed.yaml": false, "cloud/shared.yaml": true, "cloud/dev/app.yaml": true, "other/readme.txt": true} {
+		if _, err := os.Stat(filepath.Join(repo.dir, f)); (err == nil) != exists {
+			t.Errorf("%s exists %v, expected %v", f, err == nil, exists)
+		}
+	}
+	b, err := os.ReadFile(filepath.Join(repo.dir, prer.IndexPath("cloud", "dev")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var idx prer.Index
+	if err := json.Unmarshal(b, &idx); err != nil {
+		t.Fatal(err)
+	}
+	expected := prer.Index{Train: "dev", Targets: map[string][]string{"//app:dev": {"cloud/dev/app.yaml"}}}
+	if !reflect.DeepEqual(idx, expected) {
+		t.Errorf("unexpected index: %+v", idx)
+	}
+
+	opts, runner, repo, _ = testOptions()
+	opts.Prune = true
+	opts.MaxPrunedFiles = 1
+	repo.dir = t.TempDir()
+	setup()
+	if _, err := prer.Run(context.Background(), opts); err == nil || !strings.Contains(err.Error(), "refusing to prune 2 files, the limit is 1") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repo.dir, "cloud/dev/old.yaml")); err != nil {
+		t.Errorf("nothing should be pruned above the limit: %v", err)
+	}
+
+	// the limit applies to the files of all the release trains
+	indexes["stage"].Targets["//app:stage-old"] = []string{"cloud/stage/old.yaml"}
+	opts, runner, repo, server := testOptions()
+	opts.Prune = true
+	opts.MaxPrunedFiles = 2
+	runner.files = map[string][]string{"//app:stage": {"cloud/stage/app.yaml", "cloud/shared.yaml"}}
+	repo.dir = t.TempDir()
+	setup()
+	if err := writeFile(filepath.Join(repo.dir, "cloud/stage/old.yaml"), "old"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := prer.Run(context.Background(), opts); err == nil || !strings.Contains(err.Error(), "refusing to prune 1 more files after 2 files of the other release trains, the limit is 2") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(repo.pushed) != 0 || len(server.created) != 0 {
+		t.Errorf("nothing should be pushed above the limit: %v %v", repo.pushed, server.created)
+	}
+}
+
+func TestGC(t *testing.T) {
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	gcOptions := func() (prer.GCOptions, *fakeRepo, *fakeServer) {
+		repo := &fakeRepo{remote: []git.RemoteBranch{
+			{Name: "deploy/prod", Committed: now.Add(-30 * 24 * time.Hour)},
+			{Name: "deploy/removed", Committed: now.Add(-30 * 24 * time.Hour)},
+			{Name: "deploy/renamed", Committed: now.Add(-10 * 24 * time.Hour)},
+			{Name: "deploy/recent", Committed: now.Add(-time.Hour)},
+			{Name: "feature/removed", Committed: now.Add(-30 * 24 * time.Hour)},
+		}}
+		server := &fakeServer{open: map[string]int{"deploy/removed": 7}}
+		return prer.GCOptions{
+			DeploymentBranchPrefix: "deploy/",
+			MinAge:                 7 * 24 * time.Hour,
+			Now:                    func() time.Time { return now },
+			Querier:                &fakeQuerier{gitops: map[string]string{"//app:prod": "prod"}},
+			Repo:                   repo,
+			Server:                 server,
+		}, repo, server
+	}
+
+	opts, repo, server := gcOptions()
+	opts.DryRun = true
+	stale, err := prer.GC(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := make(map[string]string)
+	for _, sb := range stale {
+		statuses[sb.Branch] = sb.Status
+	}
+	expected := map[string]string{"deploy/removed": prer.StaleDryRun, "deploy/renamed": prer.StaleDryRun, "deploy/recent": prer.StaleRecent}
+	if !reflect.DeepEqual(statuses, expected) {
+		t.Errorf("unexpected dry run statuses: %v", statuses)
+	}
+	if len(repo.deletedRemote) != 0 || len(server.closed) != 0 {
+		t.Errorf("dry run should not delete branches %v or close PRs %v", repo.deletedRemote, server.closed)
+	}
+
+	opts, repo, server = gcOptions()
+	stale, err = prer.GC(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(repo.deletedRemote, []string{"deploy/removed", "deploy/renamed"}) {
+		t.Errorf("unexpected deleted branches: %v", repo.deletedRemote)
+	}
+	if !reflect.DeepEqual(server.closed, []int{7}) {
+		t.Errorf("unexpected closed PRs: %v", server.closed)
+	}
+	if stale[0].Train != "removed" || stale[0].Status != prer.StaleDeleted || stale[0].PRNumber != 7 || stale[0].PRURL != "https://example.com/pr/7" {
+		t.Errorf("unexpected stale branch: %+v", stale[0])
+	}
+
+	opts, repo, _ = gcOptions()
+	opts.DeploymentBranchSuffix = "-v2"
+	repo.remote = append(repo.remote, git.RemoteBranch{Name: "deploy/prod-v2", Committed: now.Add(-30 * 24 * time.Hour)}, git.RemoteBranch{Name: "deploy/old-v2", Committed: now.Add(-30 * 24 * time.Hour)})
+	opts.Server = git.ServerFunc(func(from, to, title, body string) (*git.PullRequest, error) { return nil, nil })
+	if _, err := prer.GC(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(repo.deletedRemote, []string{"deploy/old-v2"}) {
+		t.Errorf("only the branches with the suffix should be deleted: %v", repo.deletedRemote)
+	}
+
+	opts, repo, _ = gcOptions()
+	repo.deleteErr = errors.New("permission denied")
+	stale, err = prer.GC(context.Background(), opts)
+	if err == nil || !strings.Contains(err.Error(), "deploy/removed (permission denied), deploy/renamed (permission denied)") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(stale) != 3 || stale[0].Status != prer.StaleFailed {
+		t.Errorf("unexpected stale branches: %+v", stale)
+	}
+
+	opts, repo, _ = gcOptions()
+	opts.Querier = &fakeQuerier{}
+	if _, err := prer.GC(context.Background(), opts); err == nil || len(repo.deletedRemote) != 0 {
+		t.Errorf("nothing should be deleted without release trains: %v", err)
+	}
+
+	// the release trains outside of a narrow target are not orphaned
+	opts, repo, _ = gcOptions()
+	opts.Target = "//app/..."
+	if _, err := prer.GC(context.Background(), opts); err == nil || !strings.Contains(err.Error(), "outside of the target //app/...") || len(repo.deletedRemote) != 0 {
+		t.Errorf("nothing should be deleted with a narrow target: %v", err)
+	}
+	opts, repo, _ = gcOptions()
+	opts.Target = "//app/..."
+	opts.PartialTarget = true
+	if _, err := prer.GC(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(repo.deletedRemote, []string{"deploy/removed", "deploy/renamed"}) {
+		t.Errorf("unexpected deleted branches: %v", repo.deletedRemote)
+	}
+	querier := opts.Querier.(*fakeQuerier)
+	if !strings.Contains(querier.queries[0], "kind(gitops, //app/...)") {
+		t.Errorf("unexpected query: %v", querier.queries)
+	}
+
+	// the release trains of the other release branches are not orphaned
+	opts, repo, _ = gcOptions()
+	opts.Querier = &fakeQuerier{gitops: map[string]string{"//app:prod": "prod", "//app:team": "renamed"}}
+	if _, err := prer.GC(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(repo.deletedRemote, []string{"deploy/removed"}) {
+		t.Errorf("unexpected deleted branches: %v", repo.deletedRemote)
+	}
+	querier = opts.Querier.(*fakeQuerier)
+	if expected := `attr(deployment_branch, ".+", kind(gitops, //... except //experimental/...))`; !reflect.DeepEqual(querier.queries, []string{expected}) {
+		t.Errorf("the release trains of all the release branches should be queried: %v", querier.queries)
+	}
+}
+
+func TestPromote(t *testing.T) {
+	setup := func(t *testing.T) (prer.PromoteOptions, *fakeRepo, *fakeServer) {
+		repo := &fakeRepo{
+			branches: map[string]string{},
+			changes:  map[string]bool{"promote/prod": true},
+			diffs:    map[string][]git.FileChange{},
+			logs:     map[string][]git.Commit{"master:cloud/dev": {{Hash: "abc123"}}},
+			remote:   []git.RemoteBranch{{Name: "deploy/dev"}, {Name: "deploy/dev-old"}},
+			dir:      t.TempDir(),
+		}
+		for name, content := range map[string]string{
+			"cloud/dev/app.yaml":  "spec:\n  containers:\n  - name: app\n    image: registry.example.com:5000/app@sha256:new\n  - image: \"proxy:1.1\"\n    name: proxy\n",
+			"cloud/prod/app.yaml": "spec:\n  containers:\n  - name: app\n    image: registry.example.com:5000/app:v1 # pinned\n  - image: \"proxy:1.0\"\n    name: proxy\n  - name: other\n    image: other:2\n",
+			"cloud/prod/job.json": `{"spec": {"containers": [{"image": "proxy:1.0"}]}}`,
+			"cloud/prod/old.yaml": "kind: ConfigMap\n",
+		} {
+			if err := writeFile(filepath.Join(repo.dir, name), content); err != nil {
+				t.Fatal(err)
+			}
+		}
+		server := &fakeServer{}
+		return prer.PromoteOptions{
+			SourceTrain:            "dev",
+			TargetTrain:            "prod",
+			SourcePath:             "cloud/dev",
+			TargetPath:             "cloud/prod/",
+			Mode:                   prer.PromoteImages,
+			PRInto:                 "master",
+			DeploymentBranchPrefix: "deploy/",
+			PromotionBranchPrefix:  "promote/",
+			Repo:                   repo,
+			Server:                 server,
+		}, repo, server
+	}
+	read := func(t *testing.T, repo *fakeRepo, name string) string {
+		t.Helper()
+		b, err := os.ReadFile(filepath.Join(repo.dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	opts, repo, server := setup(t)
+	res, err := prer.Promote(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != prer.StatusUpdated || res.SourceCommit != "abc123" || res.Branch != "promote/prod" || res.PR == nil || !res.PR.Created {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	expectedImages := []prer.PromotedImage{
+		{Image: "proxy", Old: "1.0", New: "1.1"},
+		{Image: "registry.example.com:5000/app", Old: "v1", New: "sha256:new"},
+	}
+	if !reflect.DeepEqual(res.Images, expectedImages) {
+		t.Errorf("unexpected images: %+v", res.Images)
+	}
+	if content := read(t, repo, "cloud/prod/app.yaml"); content != "spec:\n  containers:\n  - name: app\n    image: registry.example.com:5000/app@sha256:new # pinned\n  - image: \"proxy:1.1\"\n    name: proxy\n  - name: other\n    image: other:2\n" {
+		t.Errorf("unexpected promoted manifest:\n%s", content)
+	}
+	if content := read(t, repo, "cloud/prod/job.json"); content != `{"spec": {"containers": [{"image": "proxy:1.1"}]}}` {
+		t.Errorf("unexpected promoted manifest:\n%s", content)
+	}
+	if meta, err := commitmsg.Parse(repo.branches["promote/prod"]); err != nil || !reflect.DeepEqual(meta.PromotedFrom, &commitmsg.Promotion{Train: "dev", Commit: "abc123"}) {
+		t.Errorf("unexpected commit message: %q", repo.branches["promote/prod"])
+	}
+	if !reflect.DeepEqual(repo.pushed, []string{"promote/prod"}) || !reflect.DeepEqual(server.created, []string{"promote/prod->master: GitOps promotion of dev to prod"}) {
+		t.Errorf("unexpected pushed branches %v or PRs %v", repo.pushed, server.created)
+	}
+	if !strings.HasPrefix(server.bodies[0], "GitOps promotion of the images of release train `dev` to `prod` from `master` commit `abc123`.") {
+		t.Errorf("unexpected PR body:\n%s", server.bodies[0])
+	}
+
+	opts, repo, _ = setup(t)
+	opts.Mode = prer.PromoteManifests
+	if _, err := prer.Promote(opts); err != nil {
+		t.Fatal(err)
+	}
+	if content := read(t, repo, "cloud/prod/app.yaml"); content != read(t, repo, "cloud/dev/app.yaml") {
+		t.Errorf("unexpected promoted manifest:\n%s", content)
+	}
+	if _, err := os.Stat(filepath.Join(repo.dir, "cloud/prod/old.yaml")); !os.IsNotExist(err) {
+		t.Errorf("target manifests should be replaced: %v", err)
+	}
+
+	opts, repo, server = setup(t)
+	repo.changes = nil
+	if res, err := prer.Promote(opts); err != nil || res.Status != prer.StatusUnchanged || len(server.created) != 0 {
+		t.Errorf("unexpected result of the promoted release train %+v: %v", res, err)
+	}
+
+	opts, repo, _ = setup(t)
+	repo.diffs["master..origin/deploy/dev"] = []git.FileChange{{Path: "cloud/dev/app.yaml", Status: git.FileModified}}
+	if _, err := prer.Promote(opts); err == nil || !strings.Contains(err.Error(), "refusing to promote release train dev, deployment branch deploy/dev is not merged into master") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(repo.pushed) != 0 {
+		t.Errorf("unmerged release train should not be promoted: %v", repo.pushed)
+	}
+
+	opts, _, _ = setup(t)
+	opts.TargetPath = "cloud/dev/prod"
+	if _, err := prer.Promote(opts); err == nil || !strings.Contains(err.Error(), "overlap") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	setup := func(t *testing.T) (prer.RollbackOptions, *fakeRepo, *fakeServer) {
+		prod := commitmsg.Generate([]string{"//app:prod"})
+		repo := &fakeRepo{
+			branches: map[string]string{},
+			changes:  map[string]bool{"rollback/c5": true, "rollback/c2": true},
+			logs: map[string][]git.Commit{
+				"master:cloud": {
+					{Hash: "c5", Parents: []string{"c4", "b5"}, Message: "Merge pull request #5 from deploy/prod"},
+					{Hash: "c4", Parents: []string{"c3"}, Message: "GitOps\n" + commitmsg.Generate([]string{"//app:dev"})},
+					{Hash: "c3", Parents: []string{"c2"}, Message: "GitOps rollback\n" + commitmsg.Metadata{Targets: []string{"//app:prod"}, RolledBack: []string{"c0"}}.Generate()},
+					{Hash: "c2", Parents: []string{"c1"}, Message: "GitOps\n" + prod},
+					{Hash: "c1", Message: "GitOps\n" + prod},
+				},
+				"b5:cloud":                 {{Hash: "b5", Parents: []string{"c4"}, Message: "GitOps\n" + prod}},
+				"origin/deploy/prod:cloud": {{Hash: "b6", Message: "GitOps\n" + commitmsg.Generate([]string{"//app:prod", "//app:prod-db"})}},
+			},
+			diffs: map[string][]git.FileChange{
+				"c4..c5": {{Path: "cloud/prod/app.yaml", Status: git.FileModified}, {Path: "cloud/prod/new.yaml", Status: git.FileAdded}},
+				"c1..c2": {{Path: "cloud/prod/app.yaml", Status: git.FileModified}},
+			},
+			files: map[string]string{
+				"c4:cloud/prod/app.yaml": "app: v2-hotfix\n",
+				"c2:cloud/prod/app.yaml": "app: v2\n",
+				"c1:cloud/prod/app.yaml": "app: v1\n",
+			},
+			dir: t.TempDir(),
+		}
+		for name, content := range map[string]string{"cloud/prod/app.yaml": "app: v3\n", "cloud/prod/new.yaml": "new: v1\n"} {
+			if err := writeFile(filepath.Join(repo.dir, name), content); err != nil {
+				t.Fatal(err)
+			}
+		}
+		server := &fakeServer{}
+		return prer.RollbackOptions{
+			Targets:              []string{"//app:prod"},
+			Reason:               "crash loop",
+			GitopsPath:           "cloud",
+			PRInto:               "master",
+			RollbackBranchPrefix: "rollback/",
+			Repo:                 repo,
+			Server:               server,
+		}, repo, server
+	}
+	read := func(t *testing.T, repo *fakeRepo, name string) string {
+		t.Helper()
+		b, err := os.ReadFile(filepath.Join(repo.dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	opts, repo, server := setup(t)
+	res, err := prer.Rollback(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Branch != "rollback/c5" || res.Status != prer.StatusUpdated || !reflect.DeepEqual(res.RevertedCommits, []string{"c5"}) || res.RestoredCommit != "c4" || res.PR == nil {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	// the rollback c3 after the previous deployment c2 is kept
+	if content := read(t, repo, "cloud/prod/app.yaml"); content != "app: v2-hotfix\n" {
+		t.Errorf("unexpected restored file: %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(repo.dir, "cloud/prod/new.yaml")); !os.IsNotExist(err) {
+		t.Errorf("the file added by the reverted deployment should be deleted: %v", err)
+	}
+	msg := repo.branches["rollback/c5"]
+	if meta, err := commitmsg.Parse(msg); err != nil || !reflect.DeepEqual(meta.RolledBack, []string{"c5"}) || !reflect.DeepEqual(meta.Targets, []string{"//app:prod"}) || meta.SourceCommit != "c4" {
+		t.Errorf("unexpected commit message: %q", msg)
+	}
+	if !reflect.DeepEqual(server.created, []string{"rollback/c5->master: GitOps rollback of c5"}) {
+		t.Errorf("unexpected PRs: %v", server.created)
+	}
+	for _, s := range []string{"to the commit `c4`", "their later changes are discarded", "**Reason:** crash loop", "- `c5` Merge pull request #5 from deploy/prod", "- `//app:prod`"} {
+		if !strings.Contains(server.bodies[0], s) {
+			t.Errorf("PR body should contain %q:\n%s", s, server.bodies[0])
+		}
+	}
+
+	opts, repo, _ = setup(t)
+	opts.Commit = "c2"
+	if res, err = prer.Rollback(opts); err != nil {
+		t.Fatal(err)
+	}
+	if res.Branch != "rollback/c2" || !reflect.DeepEqual(res.RevertedCommits, []string{"c5", "c2"}) || res.RestoredCommit != "c1" {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if content := read(t, repo, "cloud/prod/app.yaml"); content != "app: v1\n" {
+		t.Errorf("unexpected restored file: %q", content)
+	}
+
+	opts, _, _ = setup(t)
+	opts.Targets = nil
+	opts.DeploymentBranch = "deploy/prod"
+	opts.DryRun = true
+	if res, err = prer.Rollback(opts); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res.Targets, []string{"//app:prod", "//app:prod-db"}) || res.PR != nil {
+		t.Errorf("unexpected result: %+v", res)
+	}
+
+	// the deployment of the other targets as well reverts the files of the targets in the indexes only
+	opts, repo, _ = setup(t)
+	index := func(targets map[string][]string) string {
+		b, err := json.Marshal(prer.Index{Train: "prod", Targets: targets})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	indexPath := prer.IndexPath("cloud", "prod")
+	db := commitmsg.Generate([]string{"//app:prod", "//app:prod-db"})
+	repo.logs["master:cloud"] = []git.Commit{
+		{Hash: "d2", Parents: []string{"d1"}, Message: "GitOps\n" + db},
+		{Hash: "d1", Parents: []string{"d0"}, Message: "GitOps\n" + db},
+	}
+	repo.diffs = map[string][]git.FileChange{
+		"d1..d2": {
+			{Path: "cloud/prod/app.yaml", Status: git.FileModified},
+			{Path: "cloud/prod/db.yaml", Status: git.FileModified},
+			{Path: "cloud/prod/new.yaml", Status: git.FileAdded},
+			{Path: indexPath, Status: git.FileModified},
+		},
+	}
+	current := map[string][]string{
+		"//app:prod":    {"cloud/prod/app.yaml", "cloud/prod/new.yaml"},
+		"//app:prod-db": {"cloud/prod/db.yaml"},
+	}
+	previous := map[string][]string{
+		"//app:prod":    {"cloud/prod/app.yaml"},
+		"//app:prod-db": {"cloud/prod/db.yaml"},
+	}
+	repo.files = map[string]string{
+		"d2:" + indexPath:          index(current),
+		"d1:" + indexPath:          index(previous),
+		"d1:cloud/prod/app.yaml":   "app: v1\n",
+		"d1:cloud/prod/db.yaml":    "db: v1\n",
+		"d1:cloud/prod/extra.yaml": "extra: v1\n",
+	}
+	for name, content := range map[string]string{"cloud/prod/db.yaml": "db: v2\n", indexPath: index(current)} {
+		if err := writeFile(filepath.Join(repo.dir, name), content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if res, err = prer.Rollback(opts); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res.RevertedCommits, []string{"d2"}) || res.RestoredCommit != "d1" {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if content := read(t, repo, "cloud/prod/app.yaml"); content != "app: v1\n" {
+		t.Errorf("unexpected restored file: %q", content)
+	}
+	if content := read(t, repo, "cloud/prod/db.yaml"); content != "db: v2\n" {
+		t.Errorf("the file of the other target should be kept: %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(repo.dir, "cloud/prod/new.yaml")); !os.IsNotExist(err) {
+		t.Errorf("the file added by the reverted deployment should be deleted: %v", err)
+	}
+	var idx prer.Index
+	if err := json.Unmarshal([]byte(read(t, repo, indexPath)), &idx); err != nil || !reflect.DeepEqual(idx.Targets, previous) {
+		t.Errorf("unexpected index: %+v, %v", idx, err)
+	}
+
+	// without the indexes the files of the targets are unknown
+	opts, repo, _ = setup(t)
+	repo.logs["master:cloud"] = []git.Commit{
+		{Hash: "d2", Parents: []string{"d1"}, Message: "GitOps\n" + db},
+		{Hash: "d1", Parents: []string{"d0"}, Message: "GitOps\n" + db},
+	}
+	repo.diffs = map[string][]git.FileChange{"d1..d2": {{Path: "cloud/prod/app.yaml", Status: git.FileModified}}}
+	if _, err := prer.Rollback(opts); err == nil || !strings.Contains(err.Error(), "deployment commit d2 deployed //app:prod-db as well") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// the deployment branch commit merged by c5
+	opts, _, _ = setup(t)
+	opts.Commit = "b5"
+	if res, err = prer.Rollback(opts); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res.RevertedCommits, []string{"c5"}) {
+		t.Errorf("unexpected result: %+v", res)
+	}
+
+	for commit, expected := range map[string]string{"c1": "no deployment of //app:prod in master before the rolled back one", "c4": "commit c4 is not a deployment of //app:prod in master"} {
+		opts, _, _ = setup(t)
+		opts.Commit = commit
+		if _, err := prer.Rollback(opts); err == nil || err.Error() != expected {
+			t.Errorf("unexpected error of %s rollback: %v", commit, err)
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	t1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	prod := commitmsg.Metadata{
+		Targets:      []string{"//app:prod"},
+		SourceBranch: "main",
+		SourceCommit: "s2",
+		Images:       map[string][]commitmsg.Image{"//app:prod": {{Target: "//app:image", Digest: "sha256:2"}}, "//app:dev": {{Target: "//app:dev-image"}}},
+	}.Generate()
+	repo := &fakeRepo{
+		logs: map[string][]git.Commit{
+			"master:cloud": {
+				{Hash: "c4", Parents: []string{"c3"}, Author: "ops <ops@example.com>", Committed: t1.Add(3 * time.Hour),
+					Message: "GitOps rollback\n" + commitmsg.Metadata{Targets: []string{"//app:prod"}, SourceBranch: "master", SourceCommit: "c1", RolledBack: []string{"c3"}}.Generate()},
+				{Hash: "c3", Parents: []string{"c2", "b3"}, Author: "dev <dev@example.com>", Committed: t1.Add(2 * time.Hour), Message: "Merge pull request #3 from deploy/prod"},
+				{Hash: "c2", Parents: []string{"c1"}, Author: "bot <bot@example.com>", Committed: t1.Add(time.Hour),
+					Message: "GitOps promotion of dev to stage\n" + commitmsg.Metadata{PromotedFrom: &commitmsg.Promotion{Train: "dev", Commit: "c0"}}.Generate()},
+				{Hash: "c1", Author: "bot <bot@example.com>", Committed: t1,
+					Message: "GitOps for release branch master from main commit s1\n" + commitmsg.Generate([]string{"//app:dev", "//app:prod"})},
+			},
+			"b3:cloud": {{Hash: "b3", Message: "GitOps for release branch master from main commit s2\n" + prod}},
+		},
+	}
+	history, err := prer.History(prer.HistoryOptions{GitopsPath: "cloud", PRInto: "master", Repo: repo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := prer.Deployment{Commit: "c1", Time: t1, Author: "bot <bot@example.com>", SourceBranch: "main", SourceCommit: "s1"}
+	expected := []prer.TargetHistory{
+		{Target: "//app:dev", Deployments: []prer.Deployment{legacy}},
+		{Target: "//app:prod", Deployments: []prer.Deployment{
+			{Commit: "c4", Time: t1.Add(3 * time.Hour), Author: "ops <ops@example.com>", SourceBranch: "master", SourceCommit: "c1", RolledBack: []string{"c3"}},
+			{Commit: "c3", Time: t1.Add(2 * time.Hour), Author: "dev <dev@example.com>", SourceBranch: "main", SourceCommit: "s2",
+				Images: []commitmsg.Image{{Target: "//app:image", Digest: "sha256:2"}}},
+			legacy,
+		}},
+	}
+	if !reflect.DeepEqual(history, expected) {
+		t.Errorf("unexpected history:\n%+v\nexpected:\n%+v", history, expected)
+	}
+
+	history, err = prer.History(prer.HistoryOptions{Targets: []string{"//app:dev"}, GitopsPath: "cloud", PRInto: "master", MaxHistory: 2, Repo: repo})
+	if err != nil || len(history) != 0 {
+		t.Errorf("unexpected history of the recent commits: %+v %v", history, err)
+	}
+
+	// the commits with a malformed metadata block are skipped
+	truncated := "GitOps\n" + commitmsg.Generate([]string{"//app:dev"}) + "--- gitops metadata begin ---\n{\"version\": 1,\n"
+	repo.logs["master:cloud"] = append([]git.Commit{
+		{Hash: "c6", Parents: []string{"c5"}, Committed: t1.Add(5 * time.Hour), Message: truncated},
+		{Hash: "c5", Parents: []string{"c4", "b5"}, Committed: t1.Add(4 * time.Hour), Message: "Merge pull request #5 from deploy/dev"},
+	}, repo.logs["master:cloud"]...)
+	repo.logs["b5:cloud"] = []git.Commit{{Hash: "b5", Message: truncated}}
+	history, err = prer.History(prer.HistoryOptions{Targets: []string{"//app:dev"}, GitopsPath: "cloud", PRInto: "master", Repo: repo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(history, expected[:1]) {
+		t.Errorf("unexpected history with malformed commits:\n%+v", history)
+	}
+}
+
+func TestSourceLabels(t *testing.T) {
+	ws := t.TempDir()
+	for _, name := range []string{"BUILD", "app/BUILD.bazel", "app/src/main.go", "app/lib/BUILD", "app/lib/lib.go", "docs/README.md"} {
+		if err := writeFile(filepath.Join(ws, name), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Remove(filepath.Join(ws, "BUILD")); err != nil {
+		t.Fatal(err)
+	}
+	labels, reason := prer.SourceLabels(ws, []string{"app/src/main.go", "app/lib/lib.go", "docs/README.md"})
+	if reason != "" || !reflect.DeepEqual(labels, []string{"//app/lib:lib.go", "//app:src/main.go"}) {
+		t.Errorf("unexpected labels %v or reason %q", labels, reason)
+	}
+	for _, tc := range []struct{ file, reason string }{
+		{"app/BUILD.bazel", "build file app/BUILD.bazel changed"},
+		{"tools/defs.bzl", "build file tools/defs.bzl changed"},
+		{"WORKSPACE", "build file WORKSPACE changed"},
+		{"app/removed.go", "file app/removed.go deleted"},
+	} {
+		if labels, reason := prer.SourceLabels(ws, []string{"app/src/main.go", tc.file}); reason != tc.reason || labels != nil {
+			t.Errorf("unexpected labels %v or reason %q of %s", labels, reason, tc.file)
+		}
+	}
+}
+
+func TestRunIncremental(t *testing.T) {
+	ws := t.TempDir()
+	for _, name := range []string{"app/BUILD", "app/prod.go"} {
+		if err := writeFile(filepath.Join(ws, name), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts, runner, _, _ := testOptions()
+	querier := opts.Querier.(*fakeQuerier)
+	querier.rdeps = []string{"//app:prod", "//other:test"}
+	opts.Incremental = true
+	opts.Workspace = ws
+	opts.ChangedFiles = []string{"app/prod.go"}
+	res, err := prer.Run(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Trains) != 1 || res.Trains[0].Train != "prod" || !reflect.DeepEqual(res.SkippedTrains, []string{"dev", "stage"}) {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if !reflect.DeepEqual(runner.runs, []string{"//app:prod"}) {
+		t.Errorf("unexpected runs: %v", runner.runs)
+	}
+	if q := querier.queries[1]; q != "kind(gitops, rdeps(//..., set('//app:prod.go')))" {
+		t.Errorf("unexpected rdeps query: %s", q)
+	}
+
+	// the keep going errors of the changed files which are not targets are ignored
+	opts, runner, _, _ = testOptions()
+	querier = opts.Querier.(*fakeQuerier)
+	querier.rdeps = []string{"//app:prod"}
+	querier.rdepsErr = &bazel.QueryError{ExitCode: 3, Stderr: "no such target '//app:README.md'"}
+	opts.IncrementalQuerier = querier
+	opts.Incremental = true
+	opts.Workspace = ws
+	opts.ChangedFiles = []string{"app/prod.go", "app/README.md"}
+	if err := writeFile(filepath.Join(ws, "app/README.md"), ""); err != nil {
+		t.Fatal(err)
+	}
+	if res, err = prer.Run(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Trains) != 1 || res.Trains[0].Train != "prod" {
+		t.Errorf("unexpected result: %+v", res)
+	}
+
+	// the failed query renders all the release trains
+	opts.IncrementalQuerier = nil
+	querier.rdepsErr = &bazel.QueryError{ExitCode: 7}
+	if res, err = prer.Run(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Trains) != 3 || res.SkippedTrains != nil {
+		t.Errorf("unexpected result: %+v", res)
+	}
+
+	// build file changes render all the release trains
+	opts, runner, _, _ = testOptions()
+	opts.Incremental = true
+	opts.Workspace = ws
+	opts.ChangedFiles = []string{"app/prod.go", "app/BUILD"}
+	if res, err = prer.Run(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Trains) != 3 || res.SkippedTrains != nil {
+		t.Errorf("unexpected result: %+v", res)
+	}
+
+	// unrelated changes render nothing
+	opts, runner, _, _ = testOptions()
+	opts.Incremental = true
+	opts.Workspace = ws
+	opts.ChangedFiles = nil
+	if res, err = prer.Run(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Trains) != 0 || len(res.SkippedTrains) != 3 || len(runner.runs) != 0 {
+		t.Errorf("unexpected result %+v or runs %v", res, runner.runs)
+	}
+}
+
+func TestExecRunner(t *testing.T) {
+	dir := t.TempDir()
+	bazelCmd := filepath.Join(dir, "bazel")
+	script := "#!/bin/sh\necho '@@//app:push bazel-out/k8-fastbuild/bin/app/push'\necho '@@repo~1.0//pkg:deploy bazel-out/k8-fastbuild/bin/external/repo~1.0/pkg/deploy'\n"
+	if err := os.WriteFile(bazelCmd, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	r := &prer.ExecRunner{BazelCmd: bazelCmd}
+	if err := r.Resolve(context.Background(), []string{"//app:push", "@@repo~1.0//pkg:deploy"}); err != nil {
+		t.Fatal(err)
+	}
+	for target, expected := range map[string]string{
+		"//app:push":             "bazel-out/k8-fastbuild/bin/app/push",
+		"@@repo~1.0//pkg:deploy": "bazel-out/k8-fastbuild/bin/external/repo~1.0/pkg/deploy",
+		"@other//pkg:deploy":     "bazel-bin/external/other/pkg/deploy",
+	} {
+		if executable := r.Executable(target); executable != expected {
+			t.Errorf("unexpected executable of %s: %s", target, executable)
+		}
+	}
+	if err := (&prer.ExecRunner{}).Resolve(context.Background(), []string{"//app:push"}); err != nil {
+		t.Errorf("resolve without bazel should do nothing: %v", err)
+	}
+}
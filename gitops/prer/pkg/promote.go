@@ -0,0 +1,313 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package prer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/adobe/rules_gitops/gitops/commitmsg"
+	diff "github.com/adobe/rules_gitops/gitops/diff/pkg"
+	"github.com/adobe/rules_gitops/gitops/git"
+)
+
+// Promotion modes
+const (
+	// PromoteImages updates the images of the target manifests to the tags or digests used by the source manifests
+	PromoteImages = "images"
+	// PromoteManifests replaces the target manifests with the source manifests
+	PromoteManifests = "manifests"
+)
+
+// PromoteOptions configures Promote
+type PromoteOptions struct {
+	// SourceTrain and TargetTrain are the release trains promoted from and to
+	SourceTrain string
+	TargetTrain string
+	// SourcePath and TargetPath are the repository relative directories of the release trains manifests.
+	// They must be checked out, inside the gitops path of the clone.
+	SourcePath string
+	TargetPath string
+	// Mode is PromoteImages or PromoteManifests
+	Mode string
+	// PRInto is the branch with the merged manifests and the target of the promotion PR
+	PRInto  string
+	PRTitle string
+	// PRBody is the description of the PR. If empty, the description summarizing the promoted changes
+	// is generated up to MaxBodySize bytes, DefaultMaxBodySize if not set.
+	PRBody      string
+	MaxBodySize int
+	// DeploymentBranchPrefix and DeploymentBranchSuffix name the deployment branch of SourceTrain
+	DeploymentBranchPrefix string
+	DeploymentBranchSuffix string
+	// PromotionBranchPrefix followed by TargetTrain is the name of the promotion branch
+	PromotionBranchPrefix string
+	// DryRun disables the promotion branch push and PR creation
+	DryRun bool
+
+	Repo   git.Repo
+	Server git.Server
+}
+
+// PromoteResult describes the outcome of Promote
+type PromoteResult struct {
+	Branch string `json:"branch"`
+	Into   string `json:"into"`
+	// SourceCommit is the last commit of PRInto changing the source manifests
+	SourceCommit string `json:"source_commit"`
+	// Status is StatusUpdated or StatusUnchanged if the target manifests are already promoted
+	Status       string          `json:"status"`
+	ChangedFiles []string        `json:"changed_files,omitempty"`
+	Images       []PromotedImage `json:"images,omitempty"`
+	PR           *PRResult       `json:"pr,omitempty"`
+}
+
+// PromotedImage is an image repository of the target manifests updated to the source tag or digest
+type PromotedImage struct {
+	Image string `json:"image"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// imageField matches the image fields of YAML and JSON manifests
+var imageField = regexp.MustCompile(`(?m)((?:^|[\s{,])(?:-\s+)?"?image"?\s*:\s*["']?)([^\s"',#}]+)`)
+
+// Promote copies the images or the manifests of the source release train merged into PRInto to the target
+// release train and creates a PR of the promotion branch. It refuses to promote the source release train
+// if its deployment branch has changes which are not merged into PRInto.
+// The partial result is returned along with an error.
+func Promote(opts PromoteOptions) (*PromoteResult, error) {
+	res := &PromoteResult{Branch: opts.PromotionBranchPrefix + opts.TargetTrain, Into: opts.PRInto}
+	if opts.Repo == nil || opts.Server == nil {
+		return res, errors.New("Repo and Server options are required")
+	}
+	if opts.Mode != PromoteImages && opts.Mode != PromoteManifests {
+		return res, fmt.Errorf("unknown promotion mode %q, expected %q or %q", opts.Mode, PromoteImages, PromoteManifests)
+	}
+	source, target := filepath.ToSlash(filepath.Clean(opts.SourcePath)), filepath.ToSlash(filepath.Clean(opts.TargetPath))
+	if opts.SourceTrain == "" || opts.TargetTrain == "" || source == "." || target == "." {
+		return res, errors.New("source and target release trains and paths are required")
+	}
+	if source == target || inDir(target, source) || inDir(source, target) {
+		return res, fmt.Errorf("source path %s and target path %s overlap", source, target)
+	}
+	if err := opts.checkMerged(source); err != nil {
+		return res, err
+	}
+	commits, err := opts.Repo.Log(opts.PRInto, source, 1)
+	if err != nil {
+		return res, err
+	}
+	if len(commits) == 0 {
+		return res, fmt.Errorf("no manifests of release train %s in %s of %s", opts.SourceTrain, source, opts.PRInto)
+	}
+	res.SourceCommit = commits[0].Hash
+
+	if err := opts.Repo.RecreateBranch(res.Branch, opts.PRInto); err != nil {
+		return res, err
+	}
+	srcDir := filepath.Join(opts.Repo.WorkDir(), filepath.FromSlash(source))
+	dstDir := filepath.Join(opts.Repo.WorkDir(), filepath.FromSlash(target))
+	for _, dir := range []string{srcDir, dstDir} {
+		if _, err := os.Stat(dir); err != nil {
+			return res, fmt.Errorf("release train manifests are not checked out: %w", err)
+		}
+	}
+	if opts.Mode == PromoteManifests {
+		if err := os.RemoveAll(dstDir); err != nil {
+			return res, err
+		}
+		if _, err := copyTree(srcDir, dstDir, ""); err != nil {
+			return res, err
+		}
+	} else {
+		if res.Images, err = promoteImages(srcDir, dstDir); err != nil {
+			return res, err
+		}
+	}
+
+	msg := fmt.Sprintf("GitOps promotion of %s to %s from %s commit %s\n%s", opts.SourceTrain, opts.TargetTrain, opts.PRInto, res.SourceCommit, commitmsg.Metadata{
+		SourceBranch: opts.PRInto,
+		SourceCommit: res.SourceCommit,
+		PromotedFrom: &commitmsg.Promotion{Train: opts.SourceTrain, Commit: res.SourceCommit},
+	}.Generate())
+	committed, err := opts.Repo.Commit(msg, target)
+	if err != nil {
+		return res, err
+	}
+	if !committed {
+		log.Printf("Release train %s is already promoted to %s", opts.SourceTrain, opts.TargetTrain)
+		res.Status = StatusUnchanged
+		return res, nil
+	}
+	res.Status = StatusUpdated
+	if res.ChangedFiles, err = opts.Repo.GetLastCommitFiles(); err != nil {
+		return res, err
+	}
+	if opts.DryRun {
+		log.Println("dry-run: skipping push and PR creation: branch", res.Branch, "into", res.Into)
+		return res, nil
+	}
+	if _, err := opts.Repo.Push([]string{res.Branch}, git.PushForce); err != nil {
+		return res, err
+	}
+
+	title := opts.PRTitle
+	if title == "" {
+		title = fmt.Sprintf("GitOps promotion of %s to %s", opts.SourceTrain, opts.TargetTrain)
+	}
+	body := opts.PRBody
+	if body == "" {
+		if body, err = opts.describe(res, target); err != nil {
+			log.Printf("Unable to describe the changes of %s: %v", res.Branch, err)
+			body = res.Branch
+		}
+	}
+	pr, err := opts.Server.CreatePR(res.Branch, res.Into, title, body)
+	if err != nil {
+		return res, fmt.Errorf("unable to create PR: %w", err)
+	}
+	if pr == nil {
+		return res, nil
+	}
+	res.PR = &PRResult{Number: pr.Number, URL: pr.URL, Created: pr.Created}
+	if !pr.Created && pr.Number != 0 {
+		// the reused PR describes the previous promotion
+		err = opts.Server.UpdatePR(pr.Number, title, body)
+		switch {
+		case errors.Is(err, git.ErrNotSupported):
+			log.Printf("Unable to update PR %d: %v", pr.Number, err)
+		case err != nil:
+			return res, fmt.Errorf("unable to update PR %d: %w", pr.Number, err)
+		default:
+			res.PR.Updated = true
+		}
+	}
+	return res, nil
+}
+
+// checkMerged returns an error if the deployment branch of the source release train
+// has changes in the source path which are not in PRInto
+func (opts *PromoteOptions) checkMerged(source string) error {
+	branch := opts.DeploymentBranchPrefix + opts.SourceTrain + opts.DeploymentBranchSuffix
+	if err := opts.Repo.Fetch(opts.DeploymentBranchPrefix + "*"); err != nil {
+		return err
+	}
+	branches, err := opts.Repo.RemoteBranches(branch)
+	if err != nil {
+		return err
+	}
+	for _, b := range branches {
+		if b.Name != branch {
+			continue
+		}
+		changes, err := opts.Repo.Diff(opts.PRInto, opts.Repo.Remote()+"/"+branch, source)
+		if err != nil {
+			return err
+		}
+		if len(changes) > 0 {
+			return fmt.Errorf("refusing to promote release train %s, deployment branch %s is not merged into %s: %d files of %s differ", opts.SourceTrain, branch, opts.PRInto, len(changes), source)
+		}
+		return nil
+	}
+	log.Printf("Deployment branch %s of release train %s not found, assuming it was merged and deleted", branch, opts.SourceTrain)
+	return nil
+}
+
+// describe returns the Markdown description of the promotion PR
+func (opts *PromoteOptions) describe(res *PromoteResult, target string) (string, error) {
+	o := &Options{GitopsPath: target, MaxBodySize: opts.MaxBodySize, Repo: opts.Repo}
+	files, images, err := o.summarize(opts.PRInto, res.Branch)
+	if err != nil {
+		return "", err
+	}
+	header := fmt.Sprintf("GitOps promotion of the %s of release train `%s` to `%s` from `%s` commit `%s`.", opts.Mode, opts.SourceTrain, opts.TargetTrain, opts.PRInto, res.SourceCommit)
+	return o.renderBody(header, nil, files, images), nil
+}
+
+// promoteImages replaces the tags and digests of the images in the target manifests with the ones used in the source manifests.
+// Returns the updated images sorted by repository.
+func promoteImages(srcDir, dstDir string) ([]PromotedImage, error) {
+	source := make(map[string]string)
+	err := walkManifests(srcDir, func(name string, mode os.FileMode, data []byte) error {
+		for _, m := range imageField.FindAllSubmatch(data, -1) {
+			repo, ref := splitImage(string(m[2]))
+			if old, ok := source[repo]; ok && old != ref {
+				return fmt.Errorf("unable to promote image %s used with %s and %s", repo, old, ref)
+			}
+			source[repo] = ref
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	promoted := make(map[string]PromotedImage)
+	err = walkManifests(dstDir, func(name string, mode os.FileMode, data []byte) error {
+		updated := imageField.ReplaceAllFunc(data, func(line []byte) []byte {
+			m := imageField.FindSubmatch(line)
+			repo, ref := splitImage(string(m[2]))
+			newRef, ok := source[repo]
+			if !ok || newRef == ref {
+				return line
+			}
+			promoted[repo+"@"+ref] = PromotedImage{Image: repo, Old: ref, New: newRef}
+			return []byte(string(m[1]) + joinImage(repo, newRef))
+		})
+		if string(updated) == string(data) {
+			return nil
+		}
+		return os.WriteFile(name, updated, mode)
+	})
+	if err != nil {
+		return nil, err
+	}
+	images := make([]PromotedImage, 0, len(promoted))
+	for _, img := range promoted {
+		images = append(images, img)
+	}
+	sort.Slice(images, func(i, j int) bool {
+		if images[i].Image != images[j].Image {
+			return images[i].Image < images[j].Image
+		}
+		return images[i].Old < images[j].Old
+	})
+	return images, nil
+}
+
+// joinImage is the reverse of splitImage
+func joinImage(repo, ref string) string {
+	if strings.Contains(ref, ":") {
+		return repo + "@" + ref
+	}
+	return repo + ":" + ref
+}
+
+// walkManifests calls fn with the content of every manifests file in dir and its subdirectories
+func walkManifests(dir string, fn func(name string, mode os.FileMode, data []byte) error) error {
+	return filepath.Walk(dir, func(name string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !diff.IsManifest(name) {
+			return err
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		return fn(name, info.Mode().Perm(), data)
+	})
+}
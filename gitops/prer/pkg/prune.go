@@ -0,0 +1,240 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package prer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/adobe/rules_gitops/gitops/exec"
+)
+
+// indexDir is the directory of the manifest indexes in the gitops path
+const indexDir = ".gitops"
+
+// Index records the files generated by the gitops targets of a release train.
+// It is committed to the deployment branch, so the files of the removed targets could be found by the next run.
+type Index struct {
+	Train string `json:"train"`
+	// Targets are the repository relative slash separated paths of the files generated by the target
+	Targets map[string][]string `json:"targets"`
+}
+
+// IndexPath returns the location of the release train index relative to the repository root
+func IndexPath(gitopsPath, train string) string {
+	return path.Join(filepath.ToSlash(gitopsPath), indexDir, train+".json")
+}
+
+// readIndexes returns the indexes of all the release trains in the working copy
+func (opts *Options) readIndexes() (map[string]*Index, error) {
+	dir := filepath.Join(opts.Repo.WorkDir(), filepath.FromSlash(path.Join(filepath.ToSlash(opts.GitopsPath), indexDir)))
+	indexes := make(map[string]*Index)
+	err := filepath.Walk(dir, func(name string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) && name == dir {
+			return filepath.SkipDir
+		}
+		if err != nil || info.IsDir() || filepath.Ext(name) != ".json" {
+			return err
+		}
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		var idx Index
+		if err := json.Unmarshal(b, &idx); err != nil {
+			return fmt.Errorf("unable to parse index %s: %w", name, err)
+		}
+		indexes[idx.Train] = &idx
+		return nil
+	})
+	return indexes, err
+}
+
+func (opts *Options) writeIndex(idx *Index) error {
+	name := filepath.Join(opts.Repo.WorkDir(), filepath.FromSlash(IndexPath(opts.GitopsPath, idx.Train)))
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, append(b, '\n'), 0644)
+}
+
+// runTargetsIndexed runs every gitops target in its own empty deployment root and copies the generated files
+// into the working copy. Returns the index of the files generated by the targets.
+func (opts *Options) runTargetsIndexed(ctx context.Context, train string, targets []string) (*Index, error) {
+	logger := exec.Logger(ctx)
+	idx := &Index{Train: train, Targets: make(map[string][]string)}
+	for _, target := range targets {
+		logger.Println("train", train, "target", target)
+		root, err := os.MkdirTemp("", "gitops-target")
+		if err != nil {
+			return nil, err
+		}
+		err = opts.Runner.Run(ctx, target, "--nopush", "--nobazel", "--deployment_root", root)
+		if err == nil {
+			idx.Targets[target], err = copyTree(root, opts.Repo.WorkDir(), opts.GitopsPath)
+		} else {
+			err = fmt.Errorf("gitops target %s failed: %w", target, err)
+		}
+		os.RemoveAll(root)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return idx, nil
+}
+
+// copyTree copies the files from src to dst directory.
+// Returns the sorted slash separated relative paths of the files in gitopsPath.
+func copyTree(src, dst, gitopsPath string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(src, func(name string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(src, name)
+		if err != nil {
+			return err
+		}
+		if err := copyFile(name, filepath.Join(dst, rel), info.Mode()); err != nil {
+			return err
+		}
+		if inDir(filepath.ToSlash(rel), gitopsPath) {
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// inDir reports whether the slash separated path is inside dir, any path is inside the repository root
+func inDir(name, dir string) bool {
+	dir = strings.Trim(filepath.ToSlash(dir), "/")
+	return dir == "" || dir == "." || strings.HasPrefix(name, dir+"/")
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// orphanedFiles returns the files of the previous index of the release train which are not generated anymore.
+// The files of the current gitops targets of the other release trains are kept.
+func (opts *Options) orphanedFiles(idx *Index, indexes map[string]*Index) []string {
+	owned := make(map[string]bool)
+	for _, files := range idx.Targets {
+		for _, f := range files {
+			owned[f] = true
+		}
+	}
+	for train, other := range indexes {
+		if train == idx.Train {
+			continue
+		}
+		current := make(map[string]bool)
+		for _, t := range opts.ReleaseTrains[train] {
+			current[t] = true
+		}
+		for target, files := range other.Targets {
+			if current[target] {
+				for _, f := range files {
+					owned[f] = true
+				}
+			}
+		}
+	}
+	var orphaned []string
+	if prev := indexes[idx.Train]; prev != nil {
+		for _, files := range prev.Targets {
+			for _, f := range files {
+				if !owned[f] {
+					owned[f] = true
+					orphaned = append(orphaned, f)
+				}
+			}
+		}
+	}
+	sort.Strings(orphaned)
+	return orphaned
+}
+
+// pruneCounter counts the files pruned by the concurrently updated release trains
+type pruneCounter struct {
+	mu    sync.Mutex
+	count int
+}
+
+// add adds n files unless the total would exceed max, 0 is no limit. Returns the previous total.
+func (c *pruneCounter) add(n, max int) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if max > 0 && c.count+n > max {
+		return c.count, false
+	}
+	c.count += n
+	return c.count - n, true
+}
+
+// prune deletes the orphaned files from the working copy. It fails without deleting anything
+// if the run would prune more than MaxPrunedFiles in all the release trains. Returns the files that existed.
+func (opts *Options) prune(orphaned []string) ([]string, error) {
+	var existing []string
+	for _, f := range orphaned {
+		if _, err := os.Stat(filepath.Join(opts.Repo.WorkDir(), filepath.FromSlash(f))); err == nil {
+			existing = append(existing, f)
+		}
+	}
+	counter := opts.pruned
+	if counter == nil {
+		counter = &pruneCounter{}
+	}
+	if prev, ok := counter.add(len(existing), opts.MaxPrunedFiles); !ok {
+		msg := fmt.Sprintf("refusing to prune %d files, the limit is %d", len(existing), opts.MaxPrunedFiles)
+		if prev > 0 {
+			msg = fmt.Sprintf("refusing to prune %d more files after %d files of the other release trains, the limit is %d", len(existing), prev, opts.MaxPrunedFiles)
+		}
+		return nil, fmt.Errorf("%s: %s", msg, strings.Join(existing, ", "))
+	}
+	for _, f := range existing {
+		if err := os.Remove(filepath.Join(opts.Repo.WorkDir(), filepath.FromSlash(f))); err != nil {
+			return nil, err
+		}
+	}
+	return existing, nil
+}
@@ -0,0 +1,434 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package prer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/adobe/rules_gitops/gitops/commitmsg"
+	"github.com/adobe/rules_gitops/gitops/git"
+)
+
+// RollbackOptions configures Rollback
+type RollbackOptions struct {
+	// Targets are the gitops targets to roll back
+	Targets []string
+	// DeploymentBranch selects the gitops targets of the last commit of the deployment branch if Targets is empty
+	DeploymentBranch string
+	// Commit is the hash or the hash prefix of the deployment commit to roll back along with the later deployments
+	// of the targets, or of the deployment branch commit merged by it. The last deployment of the targets is rolled back if empty.
+	Commit string
+	// Reason explains the rollback in the PR description
+	Reason string
+	// GitopsPath is the location of the generated files in the repo
+	GitopsPath string
+	// PRInto is the branch with the merged deployments and the target of the rollback PR
+	PRInto  string
+	PRTitle string
+	// MaxBodySize limits the size of the PR description, DefaultMaxBodySize if not set
+	MaxBodySize int
+	// MaxHistory is the number of the most recent PRInto commits searched for the deployments, all if 0
+	MaxHistory int
+	// RollbackBranchPrefix followed by the short hash of the rolled back commit is the name of the rollback branch
+	RollbackBranchPrefix string
+	// DryRun disables the rollback branch push and PR creation
+	DryRun bool
+
+	Repo   git.Repo
+	Server git.Server
+}
+
+// RollbackResult describes the outcome of Rollback
+type RollbackResult struct {
+	Branch  string   `json:"branch"`
+	Into    string   `json:"into"`
+	Targets []string `json:"targets"`
+	// RevertedCommits are the PRInto deployment commits of the targets rolled back, newest first
+	RevertedCommits []string `json:"reverted_commits"`
+	// RestoredCommit is the PRInto commit the files are restored from, the first parent of the oldest reverted deployment
+	RestoredCommit string `json:"restored_commit"`
+	// Status is StatusUpdated or StatusUnchanged if the files are already restored
+	Status       string    `json:"status"`
+	ChangedFiles []string  `json:"changed_files,omitempty"`
+	PR           *PRResult `json:"pr,omitempty"`
+}
+
+// Rollback restores the files of the targets changed by the last deployment of the targets, or by the deployments
+// since Commit, to their content before the oldest reverted deployment and creates a PR of the rollback branch.
+// Any later change of these files, like a manual edit or a rollback, is discarded.
+// A deployment of other targets as well is reverted for the files of the targets in the manifest indexes only.
+// The deployments are the PRInto commits with the gitops targets in their commit message written by
+// commitmsg.Metadata, or the merge commits of such commits. Rollbacks are not counted as deployments.
+// The partial result is returned along with an error.
+func Rollback(opts RollbackOptions) (*RollbackResult, error) {
+	res := &RollbackResult{Into: opts.PRInto}
+	if opts.Repo == nil || opts.Server == nil {
+		return res, errors.New("Repo and Server options are required")
+	}
+	targets, err := opts.rollbackTargets()
+	if err != nil {
+		return res, err
+	}
+	res.Targets = targets
+	deployments, err := opts.deployments(targets)
+	if err != nil {
+		return res, err
+	}
+	// the deployments to revert are followed by the one to restore
+	i := 0
+	if opts.Commit != "" {
+		for i < len(deployments) && !deployments[i].matches(opts.Commit) {
+			i++
+		}
+		if i == len(deployments) {
+			return res, fmt.Errorf("commit %s is not a deployment of %s in %s", opts.Commit, strings.Join(targets, ", "), opts.PRInto)
+		}
+	}
+	if i+1 >= len(deployments) {
+		return res, fmt.Errorf("no deployment of %s in %s before the rolled back one", strings.Join(targets, ", "), opts.PRInto)
+	}
+	reverted := deployments[:i+1]
+	for _, d := range reverted {
+		if len(d.Parents) == 0 {
+			return res, fmt.Errorf("deployment commit %s has no parent", d.Hash)
+		}
+		res.RevertedCommits = append(res.RevertedCommits, d.Hash)
+	}
+	// the files are restored to their content before the oldest reverted deployment,
+	// so their later changes, like the rollbacks and the manual changes, are discarded
+	restored := reverted[len(reverted)-1].Parents[0]
+	res.RestoredCommit = restored
+	res.Branch = opts.RollbackBranchPrefix + ShortHash(reverted[len(reverted)-1].Hash)
+
+	if err := opts.Repo.RecreateBranch(res.Branch, opts.PRInto); err != nil {
+		return res, err
+	}
+	o := &Options{GitopsPath: opts.GitopsPath, Repo: opts.Repo}
+	current, err := o.readIndexes()
+	if err != nil {
+		return res, err
+	}
+	indexes := make(map[string]*Index)
+	for train, idx := range current {
+		indexes[IndexPath(opts.GitopsPath, train)] = idx
+	}
+	files, err := opts.rollbackFiles(targets, reverted, indexes)
+	if err != nil {
+		return res, err
+	}
+	for _, f := range files {
+		if err := opts.restoreFile(restored, f); err != nil {
+			return res, err
+		}
+	}
+	if err := opts.restoreIndexes(restored, targets, indexes); err != nil {
+		return res, err
+	}
+	meta := commitmsg.Metadata{
+		Targets:      targets,
+		SourceBranch: opts.PRInto,
+		SourceCommit: restored,
+		RolledBack:   res.RevertedCommits,
+	}
+	msg := fmt.Sprintf("GitOps rollback of %s to %s commit %s\n%s", strings.Join(targets, ", "), opts.PRInto, restored, meta.Generate())
+	committed, err := opts.Repo.Commit(msg, opts.GitopsPath)
+	if err != nil {
+		return res, err
+	}
+	if !committed {
+		log.Printf("The files of %s are already restored to %s", strings.Join(targets, ", "), restored)
+		res.Status = StatusUnchanged
+		return res, nil
+	}
+	res.Status = StatusUpdated
+	if res.ChangedFiles, err = opts.Repo.GetLastCommitFiles(); err != nil {
+		return res, err
+	}
+	if opts.DryRun {
+		log.Println("dry-run: skipping push and PR creation: branch", res.Branch, "into", res.Into)
+		return res, nil
+	}
+	if _, err := opts.Repo.Push([]string{res.Branch}, git.PushForce); err != nil {
+		return res, err
+	}
+
+	title := opts.PRTitle
+	if title == "" {
+		title = fmt.Sprintf("GitOps rollback of %s", ShortHash(reverted[len(reverted)-1].Hash))
+	}
+	body, err := opts.describe(res, reverted)
+	if err != nil {
+		log.Printf("Unable to describe the changes of %s: %v", res.Branch, err)
+		body = res.Branch
+	}
+	pr, err := opts.Server.CreatePR(res.Branch, res.Into, title, body)
+	if err != nil {
+		return res, fmt.Errorf("unable to create PR: %w", err)
+	}
+	if pr != nil {
+		res.PR = &PRResult{Number: pr.Number, URL: pr.URL, Created: pr.Created}
+	}
+	return res, nil
+}
+
+// matches returns whether the hash prefix selects the deployment commit or the deployment branch commit it merged
+func (d *deployment) matches(prefix string) bool {
+	return strings.HasPrefix(d.Hash, prefix) || len(d.Parents) > 1 && strings.HasPrefix(d.Parents[1], prefix)
+}
+
+// rollbackTargets returns the sorted gitops targets to roll back
+func (opts *RollbackOptions) rollbackTargets() ([]string, error) {
+	targets := append([]string(nil), opts.Targets...)
+	if len(targets) == 0 {
+		if opts.DeploymentBranch == "" {
+			return nil, errors.New("gitops targets or deployment branch are required")
+		}
+		if err := opts.Repo.Fetch(opts.DeploymentBranch); err != nil {
+			return nil, err
+		}
+		commits, err := opts.Repo.Log(opts.Repo.Remote()+"/"+opts.DeploymentBranch, opts.GitopsPath, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(commits) > 0 {
+			targets = commitmsg.ExtractTargets(commits[0].Message)
+		}
+		if len(targets) == 0 {
+			return nil, fmt.Errorf("no gitops targets in the last commit of %s", opts.DeploymentBranch)
+		}
+	}
+	sort.Strings(targets)
+	return targets, nil
+}
+
+// deployments returns the PRInto deployments of any of the targets, newest first
+func (opts *RollbackOptions) deployments(targets []string) ([]deployment, error) {
+	commits, err := deploymentCommits(opts.Repo, opts.PRInto, opts.GitopsPath, opts.MaxHistory)
+	if err != nil {
+		return nil, err
+	}
+	wanted := make(map[string]bool)
+	for _, t := range targets {
+		wanted[t] = true
+	}
+	var deployments []deployment
+	for _, d := range commits {
+		if len(d.Meta.RolledBack) > 0 {
+			continue
+		}
+		for _, t := range d.Meta.Targets {
+			if wanted[t] {
+				deployments = append(deployments, d)
+				break
+			}
+		}
+	}
+	return deployments, nil
+}
+
+// rollbackFiles returns the sorted files of the targets changed by the reverted deployments, except the manifest indexes.
+// The indexes are added to the working copy indexes keyed by path, nil if the working copy has no such index.
+// The files of a deployment of other targets as well are found by the indexes of the deployment and its parent,
+// the deployment fails the rollback if there are none.
+func (opts *RollbackOptions) rollbackFiles(targets []string, reverted []deployment, indexes map[string]*Index) ([]string, error) {
+	wanted := make(map[string]bool)
+	for _, t := range targets {
+		wanted[t] = true
+	}
+	files := make(map[string]bool)
+	for _, d := range reverted {
+		changes, err := opts.Repo.Diff(d.Parents[0], d.Hash, opts.GitopsPath)
+		if err != nil {
+			return nil, err
+		}
+		var others []string
+		for _, t := range d.Meta.Targets {
+			if !wanted[t] {
+				others = append(others, t)
+			}
+		}
+		for _, ch := range changes {
+			if opts.isIndex(ch.Path) {
+				if _, ok := indexes[ch.Path]; !ok {
+					indexes[ch.Path] = nil
+				}
+			}
+		}
+		var owned map[string]bool
+		if len(others) > 0 {
+			if owned, err = opts.ownedFiles(wanted, []string{d.Parents[0], d.Hash}, indexes); err != nil {
+				return nil, err
+			}
+			if len(owned) == 0 {
+				return nil, fmt.Errorf("deployment commit %s deployed %s as well, the files of %s are not found in the manifest indexes",
+					d.Hash, strings.Join(others, ", "), strings.Join(targets, ", "))
+			}
+		}
+		for _, ch := range changes {
+			if !opts.isIndex(ch.Path) && (owned == nil || owned[ch.Path]) {
+				files[ch.Path] = true
+			}
+		}
+	}
+	res := make([]string, 0, len(files))
+	for f := range files {
+		res = append(res, f)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// ownedFiles returns the files of the wanted targets in the indexes at the revisions
+func (opts *RollbackOptions) ownedFiles(wanted map[string]bool, revisions []string, indexes map[string]*Index) (map[string]bool, error) {
+	owned := make(map[string]bool)
+	for p := range indexes {
+		for _, rev := range revisions {
+			idx, err := opts.readIndex(rev, p)
+			if err != nil {
+				return nil, err
+			}
+			if idx == nil {
+				continue
+			}
+			for t, files := range idx.Targets {
+				if wanted[t] {
+					for _, f := range files {
+						owned[f] = true
+					}
+				}
+			}
+		}
+	}
+	return owned, nil
+}
+
+// restoreIndexes sets the entries of the targets in the working copy indexes to their entries in the revision.
+// The entries of the other targets are kept.
+func (opts *RollbackOptions) restoreIndexes(revision string, targets []string, indexes map[string]*Index) error {
+	o := &Options{GitopsPath: opts.GitopsPath, Repo: opts.Repo}
+	for p, cur := range indexes {
+		prev, err := opts.readIndex(revision, p)
+		if err != nil {
+			return err
+		}
+		if prev == nil && cur == nil {
+			continue
+		}
+		idx := &Index{Targets: make(map[string][]string)}
+		if cur != nil {
+			idx.Train = cur.Train
+			for t, files := range cur.Targets {
+				idx.Targets[t] = files
+			}
+		} else {
+			idx.Train = prev.Train
+		}
+		changed := false
+		for _, t := range targets {
+			files, ok := []string(nil), false
+			if prev != nil {
+				files, ok = prev.Targets[t]
+			}
+			if curFiles, curOK := idx.Targets[t]; curOK == ok && reflect.DeepEqual(curFiles, files) {
+				continue
+			}
+			changed = true
+			if ok {
+				idx.Targets[t] = files
+			} else {
+				delete(idx.Targets, t)
+			}
+		}
+		switch {
+		case !changed:
+		case len(idx.Targets) == 0:
+			if err := os.Remove(filepath.Join(opts.Repo.WorkDir(), filepath.FromSlash(p))); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		default:
+			if err := o.writeIndex(idx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isIndex reports whether the slash separated path is a manifest index
+func (opts *RollbackOptions) isIndex(name string) bool {
+	return inDir(name, path.Join(filepath.ToSlash(opts.GitopsPath), indexDir)) && path.Ext(name) == ".json"
+}
+
+// readIndex returns the index in the revision, nil if it does not exist
+func (opts *RollbackOptions) readIndex(revision, name string) (*Index, error) {
+	b, err := opts.Repo.ReadFile(revision, name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var idx Index
+	if err := json.Unmarshal(b, &idx); err != nil {
+		return nil, fmt.Errorf("unable to parse index %s in %s: %w", name, revision, err)
+	}
+	return &idx, nil
+}
+
+// restoreFile restores the file in the working copy to its content in the revision, or deletes it if it did not exist
+func (opts *RollbackOptions) restoreFile(revision, path string) error {
+	name := filepath.Join(opts.Repo.WorkDir(), filepath.FromSlash(path))
+	data, err := opts.Repo.ReadFile(revision, path)
+	if errors.Is(err, os.ErrNotExist) {
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, 0644)
+}
+
+// describe returns the Markdown description of the rollback PR
+func (opts *RollbackOptions) describe(res *RollbackResult, reverted []deployment) (string, error) {
+	o := &Options{GitopsPath: opts.GitopsPath, MaxBodySize: opts.MaxBodySize, Repo: opts.Repo}
+	files, images, err := o.summarize(opts.PRInto, res.Branch)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "GitOps rollback in `%s` to the commit `%s`.\n", opts.PRInto, res.RestoredCommit)
+	sb.WriteString("The files changed by the reverted deployments are restored to their content in this commit, their later changes are discarded.\n")
+	if opts.Reason != "" {
+		fmt.Fprintf(&sb, "\n**Reason:** %s\n", opts.Reason)
+	}
+	sb.WriteString("\n### Reverted Deployments\n\n")
+	for _, d := range reverted {
+		subject, _, _ := strings.Cut(d.Message, "\n")
+		fmt.Fprintf(&sb, "- `%s` %s\n", d.Hash, subject)
+	}
+	return o.renderBody(strings.TrimSuffix(sb.String(), "\n"), res.Targets, files, images), nil
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+
+	prer "github.com/adobe/rules_gitops/gitops/prer/pkg"
+)
+
+// report is the machine-readable result of a create_gitops_prs run
+type report struct {
+	ReleaseBranch string `json:"release_branch"`
+	BranchName    string `json:"branch_name"`
+	GitCommit     string `json:"git_commit"`
+	DryRun        bool   `json:"dry_run"`
+	prer.Result
+	// StaleBranches are the orphaned deployment branches found with --gc
+	StaleBranches []prer.StaleBranch `json:"stale_branches,omitempty"`
+}
+
+// write saves the report as JSON document. Trains and images are sorted for stable output.
+func (r *report) write(path string) error {
+	sort.Slice(r.Trains, func(i, j int) bool { return r.Trains[i].Train < r.Trains[j].Train })
+	sort.Slice(r.Images, func(i, j int) bool { return r.Images[i].Target < r.Images[j].Target })
+	if r.Trains == nil {
+		r.Trains = []prer.TrainResult{}
+	}
+	if r.Images == nil {
+		r.Images = []prer.ImageResult{}
+	}
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0666)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	prer "github.com/adobe/rules_gitops/gitops/prer/pkg"
+)
+
+func TestReportWrite(t *testing.T) {
+	rep := &report{
+		ReleaseBranch: "master",
+		BranchName:    "master",
+		GitCommit:     "abc123",
+	}
+	rep.Trains = append(rep.Trains,
+		prer.TrainResult{Train: "prod", Branch: "deploy/prod", Into: "master", Targets: []string{"//app:prod"}, Status: prer.StatusUnchanged},
+		prer.TrainResult{Train: "dev", Branch: "deploy/dev", Into: "master", Targets: []string{"//app:dev"}, Status: prer.StatusNew, ChangedFiles: []string{"cloud/dev/app.yaml"},
+			PR: &prer.PRResult{Number: 7, URL: "https://example.com/pr/7", Created: true}},
+	)
+	rep.Images = append(rep.Images, prer.ImageResult{Target: "//app:image", Digest: "sha256:1234"})
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := rep.write(path); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  "release_branch": "master",
+  "branch_name": "master",
+  "git_commit": "abc123",
+  "dry_run": false,
+  "trains": [
+    {
+      "train": "dev",
+      "branch": "deploy/dev",
+      "into": "master",
+      "targets": [
+        "//app:dev"
+      ],
+      "status": "new",
+      "changed_files": [
+        "cloud/dev/app.yaml"
+      ],
+      "pr": {
+        "number": 7,
+        "url": "https://example.com/pr/7",
+        "created": true
+      }
+    },
+    {
+      "train": "prod",
+      "branch": "deploy/prod",
+      "into": "master",
+      "targets": [
+        "//app:prod"
+      ],
+      "status": "unchanged"
+    }
+  ],
+  "images": [
+    {
+      "target": "//app:image",
+      "digest": "sha256:1234"
+    }
+  ]
+}
+`
+	if string(b) != expected {
+		t.Errorf("unexpected report:\n%s", b)
+	}
+}
+
+func TestReportHeaderConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("release_branch: release/team\nbranch_name: release/team\ngit_commit: def456\ndry_run: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"release_branch", "branch_name", "git_commit", "dry_run"} {
+		f := flag.Lookup(name)
+		t.Cleanup(func() { f.Value.Set(f.DefValue) })
+	}
+	if _, err := loadConfig(path, flag.CommandLine); err != nil {
+		t.Fatal(err)
+	}
+	var rep report
+	rep.setHeader()
+	expected := report{ReleaseBranch: "release/team", BranchName: "release/team", GitCommit: "def456", DryRun: true}
+	if !reflect.DeepEqual(rep, expected) {
+		t.Errorf("the report header should use the config file values: %+v", rep)
+	}
+}
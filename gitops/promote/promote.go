@@ -0,0 +1,117 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+// promote opens a PR promoting the images or the manifests of a release train merged into the
+// gitops_pr_into branch to another release train, e.g. from the staging to the production cluster.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/adobe/rules_gitops/gitops/git/hosting"
+	prer "github.com/adobe/rules_gitops/gitops/prer/pkg"
+)
+
+func init() {
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+}
+
+var (
+	repo                   = flag.String("git_repo", "", "git repo location")
+	gitMirror              = flag.String("git_mirror", "", "git mirror location, like /mnt/mirror/bitbucket.tubemogul.info/tm/repo.git for jenkins")
+	gitopsPath             = flag.String("gitops_path", "cloud", "location of the generated files in repo, the release train paths must be inside")
+	gitopsTmpDir           = flag.String("gitops_tmpdir", os.TempDir(), "location to check out git tree with /cloud.")
+	prInto                 = flag.String("gitops_pr_into", "master", "the branch with the merged manifests and the target for the promotion PR")
+	prTitle                = flag.String("gitops_pr_title", "", "a title for the promotion PR")
+	prBody                 = flag.String("gitops_pr_body", "", "a body message for the promotion PR. The summary of the promoted changes is generated if empty")
+	prBodyMaxSize          = flag.Int("gitops_pr_body_max_size", prer.DefaultMaxBodySize, "the size limit of the generated promotion PR body. Azure DevOps requires 4000")
+	deploymentBranchPrefix = flag.String("deployment_branch_prefix", "deploy/", "the prefix of the deployment branch names")
+	deploymentBranchSuffix = flag.String("deployment_branch_suffix", "", "the suffix of the deployment branch names")
+	promotionBranchPrefix  = flag.String("promotion_branch_prefix", "promote/", "the prefix to add to the target release train to name the promotion branch")
+	fromTrain              = flag.String("from_train", "", "the release train to promote, the deployment_branch attribute value")
+	toTrain                = flag.String("to_train", "", "the release train to promote to, the deployment_branch attribute value")
+	fromPath               = flag.String("from_path", "", "the location of the manifests of the promoted release train in repo, like cloud/staging")
+	toPath                 = flag.String("to_path", "", "the location of the manifests of the release train promoted to in repo, like cloud/prod")
+	mode                   = flag.String("mode", prer.PromoteImages, "what to promote: 'images' updates the image tags and digests, 'manifests' replaces the manifests")
+	gitHost                = flag.String("git_server", "bitbucket", "the git server api to use. 'bitbucket', 'bitbucket_cloud', 'github', 'gitlab', 'gitea' or 'azure'")
+	gitBackend             = flag.String("git_backend", "exec", "the git implementation to use. 'exec' runs git binary, 'native' does not require git to be installed")
+	dryRun                 = flag.Bool("dry_run", false, "Do not push the promotion branch and create the PR, just print what would be done")
+	reportFile             = flag.String("report_file", "", "write a JSON report of the promotion to this file")
+)
+
+func main() {
+	flag.Parse()
+	res, err := run()
+	if *reportFile != "" && res != nil {
+		b, werr := json.MarshalIndent(res, "", "  ")
+		if werr == nil {
+			werr = os.WriteFile(*reportFile, append(b, '\n'), 0666)
+		}
+		if werr != nil {
+			log.Printf("Unable to write report file %s: %v", *reportFile, werr)
+			if err == nil {
+				err = werr
+			}
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() (*prer.PromoteResult, error) {
+	gitServer, err := hosting.Server(*gitHost)
+	if err != nil {
+		return nil, err
+	}
+	clone, err := hosting.Cloner(*gitBackend, *gitHost)
+	if err != nil {
+		return nil, err
+	}
+	gitopsdir, err := ioutil.TempDir(*gitopsTmpDir, "gitops")
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create tempdir in %s: %w", *gitopsTmpDir, err)
+	}
+	defer os.RemoveAll(gitopsdir)
+	workdir, err := clone(*repo, gitopsdir, *gitMirror, *prInto, *gitopsPath)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to clone repo: %w", err)
+	}
+	opts := promoteOptions()
+	opts.Repo = workdir
+	opts.Server = gitServer
+	return prer.Promote(opts)
+}
+
+// promoteOptions returns the promotion options of the flags without the repo and the git server
+func promoteOptions() prer.PromoteOptions {
+	return prer.PromoteOptions{
+		SourceTrain:            *fromTrain,
+		TargetTrain:            *toTrain,
+		SourcePath:             *fromPath,
+		TargetPath:             *toPath,
+		Mode:                   *mode,
+		PRInto:                 *prInto,
+		PRTitle:                *prTitle,
+		PRBody:                 *prBody,
+		MaxBodySize:            *prBodyMaxSize,
+		DeploymentBranchPrefix: *deploymentBranchPrefix,
+		DeploymentBranchSuffix: *deploymentBranchSuffix,
+		PromotionBranchPrefix:  *promotionBranchPrefix,
+		DryRun:                 *dryRun,
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package main
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+
+	prer "github.com/adobe/rules_gitops/gitops/prer/pkg"
+)
+
+// setFlags sets the command line flags for the test and restores their defaults after it
+func setFlags(t *testing.T, values map[string]string) {
+	t.Helper()
+	for name, value := range values {
+		f := flag.Lookup(name)
+		if f == nil {
+			t.Fatalf("unknown flag %s", name)
+		}
+		if err := f.Value.Set(value); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { f.Value.Set(f.DefValue) })
+	}
+}
+
+func TestPromoteOptions(t *testing.T) {
+	expected := prer.PromoteOptions{
+		Mode:                   prer.PromoteImages,
+		PRInto:                 "master",
+		MaxBodySize:            prer.DefaultMaxBodySize,
+		DeploymentBranchPrefix: "deploy/",
+		PromotionBranchPrefix:  "promote/",
+	}
+	if opts := promoteOptions(); !reflect.DeepEqual(opts, expected) {
+		t.Errorf("unexpected default options: %+v", opts)
+	}
+
+	setFlags(t, map[string]string{
+		"from_train":               "stage",
+		"to_train":                 "prod",
+		"from_path":                "cloud/stage",
+		"to_path":                  "cloud/prod",
+		"mode":                     prer.PromoteManifests,
+		"gitops_pr_into":           "main",
+		"gitops_pr_title":          "Promote stage",
+		"gitops_pr_body":           "release 1.2",
+		"gitops_pr_body_max_size":  "4000",
+		"deployment_branch_prefix": "gitops/",
+		"deployment_branch_suffix": "-main",
+		"promotion_branch_prefix":  "release/",
+		"dry_run":                  "true",
+	})
+	expected = prer.PromoteOptions{
+		SourceTrain:            "stage",
+		TargetTrain:            "prod",
+		SourcePath:             "cloud/stage",
+		TargetPath:             "cloud/prod",
+		Mode:                   prer.PromoteManifests,
+		PRInto:                 "main",
+		PRTitle:                "Promote stage",
+		PRBody:                 "release 1.2",
+		MaxBodySize:            4000,
+		DeploymentBranchPrefix: "gitops/",
+		DeploymentBranchSuffix: "-main",
+		PromotionBranchPrefix:  "release/",
+		DryRun:                 true,
+	}
+	if opts := promoteOptions(); !reflect.DeepEqual(opts, expected) {
+		t.Errorf("unexpected options:\n%+v\nexpected:\n%+v", opts, expected)
+	}
+}
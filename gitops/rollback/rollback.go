@@ -0,0 +1,116 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+// rollback opens a PR restoring the files of the gitops targets changed by a deployment merged into
+// the gitops_pr_into branch to their content before the deployment.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/adobe/rules_gitops/gitops/git/hosting"
+	prer "github.com/adobe/rules_gitops/gitops/prer/pkg"
+)
+
+func init() {
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+}
+
+var (
+	repo                 = flag.String("git_repo", "", "git repo location")
+	gitMirror            = flag.String("git_mirror", "", "git mirror location, like /mnt/mirror/bitbucket.tubemogul.info/tm/repo.git for jenkins")
+	gitopsPath           = flag.String("gitops_path", "cloud", "location of the generated files in repo.")
+	gitopsTmpDir         = flag.String("gitops_tmpdir", os.TempDir(), "location to check out git tree with /cloud.")
+	prInto               = flag.String("gitops_pr_into", "master", "the branch with the merged deployments and the target for the rollback PR")
+	prTitle              = flag.String("gitops_pr_title", "", "a title for the rollback PR")
+	prBodyMaxSize        = flag.Int("gitops_pr_body_max_size", prer.DefaultMaxBodySize, "the size limit of the generated rollback PR body. Azure DevOps requires 4000")
+	target               = flag.String("target", "", "the gitops target to roll back, like //app:prod")
+	deploymentBranch     = flag.String("deployment_branch", "", "roll back the gitops targets of the deployment branch, like deploy/prod, if --target is not set")
+	commit               = flag.String("commit", "", "the hash of the deployment commit in gitops_pr_into, or of the deployment branch commit it merged, to roll back along with the later deployments of the targets. The last deployment is rolled back if empty")
+	reason               = flag.String("reason", "", "the reason of the rollback for the PR description")
+	maxHistory           = flag.Int("max_history", 500, "the number of the most recent gitops_pr_into commits searched for the deployments. 0 searches all")
+	rollbackBranchPrefix = flag.String("rollback_branch_prefix", "rollback/", "the prefix to add to the rolled back commit hash to name the rollback branch")
+	gitHost              = flag.String("git_server", "bitbucket", "the git server api to use. 'bitbucket', 'bitbucket_cloud', 'github', 'gitlab', 'gitea' or 'azure'")
+	gitBackend           = flag.String("git_backend", "exec", "the git implementation to use. 'exec' runs git binary, 'native' does not require git to be installed")
+	dryRun               = flag.Bool("dry_run", false, "Do not push the rollback branch and create the PR, just print what would be done")
+	reportFile           = flag.String("report_file", "", "write a JSON report of the rollback to this file")
+)
+
+func main() {
+	flag.Parse()
+	res, err := run()
+	if *reportFile != "" && res != nil {
+		b, werr := json.MarshalIndent(res, "", "  ")
+		if werr == nil {
+			werr = os.WriteFile(*reportFile, append(b, '\n'), 0666)
+		}
+		if werr != nil {
+			log.Printf("Unable to write report file %s: %v", *reportFile, werr)
+			if err == nil {
+				err = werr
+			}
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() (*prer.RollbackResult, error) {
+	gitServer, err := hosting.Server(*gitHost)
+	if err != nil {
+		return nil, err
+	}
+	clone, err := hosting.Cloner(*gitBackend, *gitHost)
+	if err != nil {
+		return nil, err
+	}
+	gitopsdir, err := ioutil.TempDir(*gitopsTmpDir, "gitops")
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create tempdir in %s: %w", *gitopsTmpDir, err)
+	}
+	defer os.RemoveAll(gitopsdir)
+	workdir, err := clone(*repo, gitopsdir, *gitMirror, *prInto, *gitopsPath)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to clone repo: %w", err)
+	}
+	opts := rollbackOptions()
+	opts.Repo = workdir
+	opts.Server = gitServer
+	return prer.Rollback(opts)
+}
+
+// rollbackOptions returns the rollback options of the flags without the repo and the git server
+func rollbackOptions() prer.RollbackOptions {
+	var targets []string
+	if *target != "" {
+		targets = []string{*target}
+	}
+	return prer.RollbackOptions{
+		Targets:              targets,
+		DeploymentBranch:     *deploymentBranch,
+		Commit:               *commit,
+		Reason:               *reason,
+		GitopsPath:           *gitopsPath,
+		PRInto:               *prInto,
+		PRTitle:              *prTitle,
+		MaxBodySize:          *prBodyMaxSize,
+		MaxHistory:           *maxHistory,
+		RollbackBranchPrefix: *rollbackBranchPrefix,
+		DryRun:               *dryRun,
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+package main
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+
+	prer "github.com/adobe/rules_gitops/gitops/prer/pkg"
+)
+
+// setFlags sets the command line flags for the test and restores their defaults after it
+func setFlags(t *testing.T, values map[string]string) {
+	t.Helper()
+	for name, value := range values {
+		f := flag.Lookup(name)
+		if f == nil {
+			t.Fatalf("unknown flag %s", name)
+		}
+		if err := f.Value.Set(value); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { f.Value.Set(f.DefValue) })
+	}
+}
+
+func TestRollbackOptions(t *testing.T) {
+	expected := prer.RollbackOptions{
+		GitopsPath:           "cloud",
+		PRInto:               "master",
+		MaxBodySize:          prer.DefaultMaxBodySize,
+		MaxHistory:           500,
+		RollbackBranchPrefix: "rollback/",
+	}
+	if opts := rollbackOptions(); !reflect.DeepEqual(opts, expected) {
+		t.Errorf("unexpected default options: %+v", opts)
+	}
+
+	setFlags(t, map[string]string{
+		"deployment_branch": "deploy/prod",
+		"commit":            "0123456789abcdef",
+		"reason":            "broken release",
+	})
+	expected.DeploymentBranch = "deploy/prod"
+	expected.Commit = "0123456789abcdef"
+	expected.Reason = "broken release"
+	if opts := rollbackOptions(); !reflect.DeepEqual(opts, expected) {
+		t.Errorf("unexpected deployment branch options:\n%+v\nexpected:\n%+v", opts, expected)
+	}
+
+	setFlags(t, map[string]string{
+		"target":                  "//app:prod",
+		"gitops_path":             "k8s",
+		"gitops_pr_into":          "main",
+		"gitops_pr_title":         "Roll back the app",
+		"gitops_pr_body_max_size": "4000",
+		"max_history":             "0",
+		"rollback_branch_prefix":  "revert/",
+		"dry_run":                 "true",
+	})
+	expected = prer.RollbackOptions{
+		Targets:              []string{"//app:prod"},
+		DeploymentBranch:     "deploy/prod",
+		Commit:               "0123456789abcdef",
+		Reason:               "broken release",
+		GitopsPath:           "k8s",
+		PRInto:               "main",
+		PRTitle:              "Roll back the app",
+		MaxBodySize:          4000,
+		MaxHistory:           0,
+		RollbackBranchPrefix: "revert/",
+		DryRun:               true,
+	}
+	if opts := rollbackOptions(); !reflect.DeepEqual(opts, expected) {
+		t.Errorf("unexpected options:\n%+v\nexpected:\n%+v", opts, expected)
+	}
+}